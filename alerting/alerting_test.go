@@ -0,0 +1,456 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+	"github.com/tommyorndorff/operata-events/pattern"
+)
+
+var testTime = time.Date(2023, 6, 1, 5, 0, 0, 0, time.UTC)
+
+func call(id, queue string, mos float64, at time.Duration) *events.CallSummaryEvent {
+	event := &events.CallSummaryEvent{}
+	event.ID = id
+	event.DetailType = events.EventTypeCallSummary
+	event.Time = testTime.Add(at)
+	event.Detail.Contact.QueueName = queue
+	event.Detail.WebRTCSession.Metrics.MOS.Avg = mos
+	return event
+}
+
+// recorder is a Notifier that keeps the alerts it receives
+type recorder struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (r *recorder) Notify(_ context.Context, alert Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func newEngine(t *testing.T, rules string) (*Engine, *recorder) {
+	t.Helper()
+	parsed, err := LoadRules(strings.NewReader(rules))
+	if err != nil {
+		t.Fatalf("Failed to load rules: %v", err)
+	}
+	rec := &recorder{}
+	engine, err := NewEngine(parsed, map[string]Notifier{"test": rec})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	return engine, rec
+}
+
+func TestLoadRules(t *testing.T) {
+	rules, err := LoadRules(strings.NewReader(`[{
+		"name": "poor-calls",
+		"filter": {"detail-type": ["CallSummary"]},
+		"groupBy": ["detail.contact.queueName"],
+		"window": "10m",
+		"aggregate": {"type": "avg", "field": "detail.webRTCSession.metrics.mos.avg"},
+		"threshold": {"op": "<", "value": 3.5},
+		"cooldown": 60
+	}]`))
+	if err != nil {
+		t.Fatalf("Failed to load rules: %v", err)
+	}
+	if len(rules) != 1 {
+		t.Fatalf("Expected 1 rule, got %d", len(rules))
+	}
+	rule := rules[0]
+	if time.Duration(rule.Window) != 10*time.Minute {
+		t.Errorf("Expected window 10m, got %s", time.Duration(rule.Window))
+	}
+	if time.Duration(rule.Cooldown) != time.Minute {
+		t.Errorf("Expected cooldown 1m, got %s", time.Duration(rule.Cooldown))
+	}
+	if rule.Filter == nil || rule.Filter.String() != `{"detail-type": ["CallSummary"]}` {
+		t.Errorf("Expected filter to be compiled, got %v", rule.Filter)
+	}
+
+	for _, invalid := range []string{
+		`[{"name": "x", "window": "soon"}]`,
+		`[{"name": "x", "filter": {"detail-type": "CallSummary"}}]`,
+		`[{"name": "x", "unknown": true}]`,
+	} {
+		if _, err := LoadRules(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected error loading %s", invalid)
+		}
+	}
+}
+
+func TestLoadYAMLRules(t *testing.T) {
+	rules, err := LoadRules(strings.NewReader(`
+- name: support-poor-calls
+  severity: warning
+  filter:
+    detail-type: [CallSummary]
+    detail:
+      webRTCSession:
+        metrics:
+          mos:
+            avg: [{numeric: ["<", 3.1]}]
+  groupBy: [detail.contact.queueName]
+  window: 10m
+  threshold: {op: ">", value: 5}
+  cooldown: 90
+  notifiers: [slack]
+`))
+	if err != nil {
+		t.Fatalf("Failed to load rules: %v", err)
+	}
+	if len(rules) != 1 {
+		t.Fatalf("Expected 1 rule, got %d", len(rules))
+	}
+	rule := rules[0]
+	if rule.Name != "support-poor-calls" || rule.Severity != "warning" || rule.Threshold != (Condition{Op: ">", Value: 5}) {
+		t.Errorf("Unexpected rule: %+v", rule)
+	}
+	if time.Duration(rule.Window) != 10*time.Minute || time.Duration(rule.Cooldown) != 90*time.Second {
+		t.Errorf("Expected window 10m and cooldown 1m30s, got %s and %s", time.Duration(rule.Window), time.Duration(rule.Cooldown))
+	}
+	if rule.Filter == nil {
+		t.Fatal("Expected filter to be compiled")
+	}
+	poor, _ := rule.Filter.MatchEvent(call("1", "Support", 2.0, 0))
+	good, _ := rule.Filter.MatchEvent(call("2", "Support", 4.0, 0))
+	if !poor || good {
+		t.Errorf("Expected filter to match poor calls only, got %v and %v", poor, good)
+	}
+
+	for _, invalid := range []string{
+		"- name: x\n  window: soon\n",
+		"- name: x\n  unknown: true\n",
+		"name: x\n",
+		"- name: [x\n",
+	} {
+		if _, err := LoadRules(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected error loading %q", invalid)
+		}
+	}
+}
+
+func TestNewEngineValidation(t *testing.T) {
+	notifiers := map[string]Notifier{"test": &recorder{}}
+	tests := []struct {
+		name  string
+		rules []Rule
+	}{
+		{"no name", []Rule{{}}},
+		{"duplicate", []Rule{{Name: "a"}, {Name: "a"}}},
+		{"unknown aggregate", []Rule{{Name: "a", Aggregate: Aggregate{Type: "median", Field: "x"}}}},
+		{"missing field", []Rule{{Name: "a", Aggregate: Aggregate{Type: AggregateAvg}}}},
+		{"unknown operator", []Rule{{Name: "a", Threshold: Condition{Op: "!="}}}},
+		{"unknown notifier", []Rule{{Name: "a", Notifiers: []string{"pager"}}}},
+		{"negative window", []Rule{{Name: "a", Window: Duration(-time.Minute)}}},
+	}
+
+	for _, test := range tests {
+		if _, err := NewEngine(test.rules, notifiers); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+func TestWindowedCount(t *testing.T) {
+	engine, rec := newEngine(t, `[{
+		"name": "poor-calls",
+		"severity": "warning",
+		"filter": {"detail-type": ["CallSummary"], "detail": {"webRTCSession": {"metrics": {"mos": {"avg": [{"numeric": ["<", 3.1]}]}}}}},
+		"groupBy": ["detail.contact.queueName"],
+		"window": "10m",
+		"threshold": {"op": ">=", "value": 3}
+	}]`)
+
+	steps := []struct {
+		event    *events.CallSummaryEvent
+		expected int
+	}{
+		{call("1", "Support", 2.5, 0), 0},
+		{call("2", "Sales", 2.5, time.Minute), 0},
+		{call("3", "Support", 4.4, 2*time.Minute), 0},
+		{call("4", "Support", 2.8, 3*time.Minute), 0},
+		{call("5", "Support", 3.0, 4*time.Minute), 1},
+		// Still over the threshold, so not fired again
+		{call("6", "Support", 2.0, 5*time.Minute), 1},
+		// The first three calls have left the window, resolving the alert
+		{call("7", "Support", 2.0, 14*time.Minute), 1},
+		{call("8", "Support", 2.0, 16*time.Minute), 1},
+		{call("9", "Support", 2.0, 17*time.Minute), 2},
+	}
+
+	for i, step := range steps {
+		if err := engine.Process(context.Background(), step.event); err != nil {
+			t.Fatalf("Step %d: unexpected error: %v", i, err)
+		}
+		if len(rec.alerts) != step.expected {
+			t.Fatalf("Step %d: expected %d alerts, got %d", i, step.expected, len(rec.alerts))
+		}
+	}
+
+	alert := rec.alerts[0]
+	if alert.Rule != "poor-calls" || alert.EventID != "5" || alert.Value != 3 || alert.Events != 3 {
+		t.Errorf("Unexpected alert: %+v", alert)
+	}
+	if alert.Group["detail.contact.queueName"] != "Support" {
+		t.Errorf("Expected group Support, got %v", alert.Group)
+	}
+	expected := "[WARNING] poor-calls (detail.contact.queueName=Support): count 3 >= 3 over 10m0s"
+	if summary := alert.Summary(); summary != expected {
+		t.Errorf("Expected summary %q, got %q", expected, summary)
+	}
+	if rec.alerts[1].EventID != "9" {
+		t.Errorf("Expected second alert from event 9, got %s", rec.alerts[1].EventID)
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	calls := []*events.CallSummaryEvent{
+		call("1", "Support", 4.0, 0),
+		call("2", "Support", 3.0, time.Minute),
+		call("3", "Support", 2.0, 2*time.Minute),
+	}
+	tests := []struct {
+		kind     string
+		op       string
+		value    float64
+		expected float64
+	}{
+		{AggregateAvg, "<", 3.2, 3},
+		{AggregateSum, ">=", 9, 9},
+		{AggregateMin, "<", 2.5, 2},
+		{AggregateMax, "==", 4, 4},
+		{AggregateCount, ">", 2, 3},
+	}
+
+	for _, test := range tests {
+		rule := Rule{
+			Name:      test.kind,
+			Window:    Duration(time.Hour),
+			Aggregate: Aggregate{Type: test.kind, Field: "detail.webRTCSession.metrics.mos.avg"},
+			Threshold: Condition{Op: test.op, Value: test.value},
+		}
+		rec := &recorder{}
+		engine, err := NewEngine([]Rule{rule}, map[string]Notifier{"test": rec})
+		if err != nil {
+			t.Fatalf("%s: failed to create engine: %v", test.kind, err)
+		}
+		for _, event := range calls {
+			if err := engine.Process(context.Background(), event); err != nil {
+				t.Fatalf("%s: unexpected error: %v", test.kind, err)
+			}
+		}
+		if len(rec.alerts) != 1 {
+			t.Fatalf("%s: expected 1 alert, got %d", test.kind, len(rec.alerts))
+		}
+		if rec.alerts[0].Value != test.expected {
+			t.Errorf("%s: expected value %g, got %g", test.kind, test.expected, rec.alerts[0].Value)
+		}
+	}
+}
+
+func TestCooldown(t *testing.T) {
+	engine, rec := newEngine(t, `[{
+		"name": "bad-call",
+		"filter": {"detail": {"webRTCSession": {"metrics": {"mos": {"avg": [{"numeric": ["<", 3.1]}]}}}}},
+		"cooldown": "5m"
+	}]`)
+
+	for i, at := range []time.Duration{0, time.Minute, 4 * time.Minute, 5 * time.Minute, 6 * time.Minute, 11 * time.Minute} {
+		if err := engine.Process(context.Background(), call("", "Support", 2.0, at)); err != nil {
+			t.Fatalf("Event %d: unexpected error: %v", i, err)
+		}
+	}
+	if err := engine.Process(context.Background(), call("", "Support", 4.5, 20*time.Minute)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Fires at 0, 5m and 11m; the good call does not match the filter
+	if len(rec.alerts) != 3 {
+		t.Fatalf("Expected 3 alerts, got %d", len(rec.alerts))
+	}
+	for i, expected := range []time.Duration{0, 5 * time.Minute, 11 * time.Minute} {
+		if !rec.alerts[i].Time.Equal(testTime.Add(expected)) {
+			t.Errorf("Alert %d: expected time %s, got %s", i, testTime.Add(expected), rec.alerts[i].Time)
+		}
+	}
+}
+
+func TestProcessFixture(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "events", "testdata", "call_summary.json"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	event, err := events.ParseEventBridgeEvent(data)
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+
+	rule := Rule{
+		Name:      "packet-loss",
+		Filter:    pattern.MustCompile(`{"detail-type": ["CallSummary"]}`),
+		GroupBy:   []string{"detail.serviceAgent.username"},
+		Aggregate: Aggregate{Type: AggregateMax, Field: "detail.webRTCSession.metrics.inbound.packetsLostPercentage"},
+		Threshold: Condition{Op: ">", Value: 1},
+	}
+	rec := &recorder{}
+	engine, err := NewEngine([]Rule{rule}, map[string]Notifier{"test": rec})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	// Raw JSON is accepted as well as parsed events
+	for _, e := range []interface{}{event, data} {
+		if err := engine.Process(context.Background(), e); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if len(rec.alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %d", len(rec.alerts))
+	}
+	for _, alert := range rec.alerts {
+		if alert.Value != 1.85 {
+			t.Errorf("Expected value 1.85, got %g", alert.Value)
+		}
+		if alert.Group["detail.serviceAgent.username"] != "andy" {
+			t.Errorf("Expected group andy, got %v", alert.Group)
+		}
+		if alert.EventID != "530848f3-1111-2222-3333-b33ba70c19f0" || !alert.Time.Equal(time.Date(2023, 6, 1, 5, 0, 13, 0, time.UTC)) {
+			t.Errorf("Unexpected alert: %+v", alert)
+		}
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	var mu sync.Mutex
+	bodies := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies[r.URL.Path] = string(body)
+		mu.Unlock()
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		if r.Header.Get("Authorization") == "" && r.URL.Path == "/webhook" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	mailer := &MemoryMailer{}
+	notifiers := map[string]Notifier{
+		"webhook": WebhookNotifier{URL: server.URL + "/webhook", Headers: map[string]string{"Authorization": "Bearer token"}},
+		"slack":   SlackNotifier{WebhookURL: server.URL + "/slack"},
+		"email":   EmailNotifier{Mailer: mailer, From: "alerts@example.com", To: []string{"ops@example.com"}},
+		"fail":    WebhookNotifier{URL: server.URL + "/fail"},
+	}
+	rules := []Rule{
+		{Name: "any-call", Severity: "info", Notifiers: []string{"webhook", "slack", "email"}},
+		{Name: "failing", Notifiers: []string{"fail"}},
+	}
+	engine, err := NewEngine(rules, notifiers)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	err = engine.Process(context.Background(), call("1", "Support", 4.0, 0))
+	if err == nil || !strings.Contains(err.Error(), "rule failing: notifier fail") {
+		t.Errorf("Expected failing notifier error, got %v", err)
+	}
+
+	var alert Alert
+	if err := json.Unmarshal([]byte(bodies["/webhook"]), &alert); err != nil {
+		t.Fatalf("Failed to decode webhook body: %v", err)
+	}
+	if alert.Rule != "any-call" || alert.EventID != "1" {
+		t.Errorf("Unexpected webhook alert: %+v", alert)
+	}
+
+	var message map[string]string
+	if err := json.Unmarshal([]byte(bodies["/slack"]), &message); err != nil {
+		t.Fatalf("Failed to decode slack body: %v", err)
+	}
+	if message["text"] != "[INFO] any-call: count 1 > 0" {
+		t.Errorf("Unexpected slack message: %q", message["text"])
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 email, got %d", len(messages))
+	}
+	if messages[0].Subject != "[INFO] any-call: count 1 > 0" || messages[0].To[0] != "ops@example.com" {
+		t.Errorf("Unexpected email: %+v", messages[0])
+	}
+	if !strings.Contains(messages[0].Body, `"rule": "any-call"`) {
+		t.Errorf("Expected email body to contain the alert, got %s", messages[0].Body)
+	}
+}
+
+func TestComposeEmail(t *testing.T) {
+	alert := Alert{Rule: "bad-call", Group: map[string]string{"detail.contact.queueName": "Support\r\nBcc: victim@example.com"}, Aggregate: "count", Value: 1}
+	data, err := compose(EmailMessage{From: "alerts@example.com", To: []string{"ops@example.com"}, Subject: alert.Summary(), Body: "details\n"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	headers, body, _ := strings.Cut(string(data), "\r\n\r\n")
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("Expected group values not to add headers, got:\n%s", headers)
+	}
+	if lines := strings.Split(headers, "\r\n"); len(lines) != 4 {
+		t.Errorf("Expected 4 header lines, got %q", lines)
+	}
+	if body != "details\r\n" {
+		t.Errorf("Expected CRLF body, got %q", body)
+	}
+
+	if _, err := compose(EmailMessage{From: "alerts@example.com\r\nBcc: victim@example.com"}); err == nil {
+		t.Error("Expected error for an address containing a line break")
+	}
+}
+
+func TestIdleGroupsEvicted(t *testing.T) {
+	engine, rec := newEngine(t, `[{
+		"name": "contact-calls",
+		"groupBy": ["id"],
+		"window": "10m",
+		"threshold": {"op": ">=", "value": 2},
+		"cooldown": "5m"
+	}]`)
+
+	for i := 0; i < 100; i++ {
+		at := time.Duration(i) * time.Minute
+		for _, id := range []string{fmt.Sprintf("contact-%d", i), "repeat"} {
+			if err := engine.Process(context.Background(), call(id, "Support", 4.0, at)); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+	}
+
+	// Groups are kept for at most a window plus the sweep interval
+	if groups := len(engine.rules[0].groups); groups > 21 {
+		t.Errorf("Expected idle groups to be evicted, got %d groups", groups)
+	}
+	// The repeating group stays crossed, so it fires once and is never evicted
+	if len(rec.alerts) != 1 || rec.alerts[0].Group["id"] != "repeat" {
+		t.Errorf("Expected a single alert for the repeating group, got %+v", rec.alerts)
+	}
+}
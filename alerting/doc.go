@@ -0,0 +1,36 @@
+// Package alerting raises alerts from streams of Operata events.
+//
+// Rules are declared in YAML or JSON. Each rule selects events with an
+// EventBridge pattern, optionally groups them by event fields, aggregates
+// them over a sliding window of event time (count, sum, avg, min or max of
+// a field), and fires when the aggregate crosses a threshold. For example,
+// more than five poor calls in the Support Queue in ten minutes:
+//
+//	# rules.yaml
+//	- name: support-poor-calls
+//	  severity: warning
+//	  filter:
+//	    detail-type: [CallSummary]
+//	    detail:
+//	      contact:
+//	        queueName: [Support Queue]
+//	      webRTCSession:
+//	        metrics:
+//	          mos:
+//	            avg: [{numeric: ["<", 3.1]}]
+//	  groupBy: [detail.contact.queueName]
+//	  window: 10m
+//	  threshold: {op: ">", value: 5}
+//	  cooldown: 30m
+//	  notifiers: [slack]
+//
+// A rule without a window evaluates every matching event on its own, so a
+// rule with only a filter fires for each match. A windowed rule fires once
+// when its threshold is crossed and not again until the aggregate falls
+// back below it; Cooldown further limits how often any rule fires per group.
+//
+// Alerts go to Notifiers: webhooks, Slack incoming webhooks and email via a
+// Mailer (SMTP, or an in-memory stand-in for development). The engine does
+// not drop repeated deliveries of the same event; wrap Process with
+// dedup.Middleware for that.
+package alerting
@@ -0,0 +1,318 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Engine evaluates rules against events and notifies when they fire
+type Engine struct {
+	mu        sync.Mutex
+	rules     []*ruleState
+	notifiers map[string]Notifier
+	now       func() time.Time
+}
+
+// ruleState is a rule and its per-group windows
+type ruleState struct {
+	rule   Rule
+	groups map[string]*groupState
+	// nextSweep is the event time at which idle groups are next evicted
+	nextSweep time.Time
+}
+
+// groupState is the window of one group of a rule
+type groupState struct {
+	samples []sample
+	// active is set while a windowed rule's threshold is crossed
+	active    bool
+	lastFired time.Time
+}
+
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+// pendingAlert is an alert waiting to be delivered outside the lock
+type pendingAlert struct {
+	alert     Alert
+	notifiers []string
+}
+
+// NewEngine validates the rules and returns an engine that alerts the named notifiers
+func NewEngine(rules []Rule, notifiers map[string]Notifier) (*Engine, error) {
+	e := &Engine{notifiers: notifiers, now: time.Now}
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if err := rule.validate(notifiers); err != nil {
+			return nil, err
+		}
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule %s", rule.Name)
+		}
+		seen[rule.Name] = true
+		e.rules = append(e.rules, &ruleState{rule: rule, groups: make(map[string]*groupState)})
+	}
+	return e, nil
+}
+
+// Process evaluates an event against every rule and delivers any alerts it
+// fires. It has the events.Handler signature, so an Engine can sit behind
+// middleware. Events may be parsed events or raw JSON, and are windowed by
+// their EventBridge time, or the current time when they have none.
+func (e *Engine) Process(ctx context.Context, event interface{}) error {
+	doc, err := document(event)
+	if err != nil {
+		return err
+	}
+
+	at := e.now()
+	if t, err := time.Parse(time.RFC3339, text(doc["time"])); err == nil && !t.IsZero() {
+		at = t
+	}
+	eventID := text(doc["id"])
+
+	e.mu.Lock()
+	var pending []pendingAlert
+	for _, state := range e.rules {
+		if alert, ok := state.evaluate(doc, at); ok {
+			alert.EventID = eventID
+			pending = append(pending, pendingAlert{alert: alert, notifiers: state.rule.Notifiers})
+		}
+	}
+	e.mu.Unlock()
+
+	var errs []error
+	for _, p := range pending {
+		names := p.notifiers
+		if len(names) == 0 {
+			names = e.notifierNames()
+		}
+		for _, name := range names {
+			if err := e.notifiers[name].Notify(ctx, p.alert); err != nil {
+				errs = append(errs, fmt.Errorf("rule %s: notifier %s: %w", p.alert.Rule, name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifierNames returns every notifier name
+func (e *Engine) notifierNames() []string {
+	names := make([]string, 0, len(e.notifiers))
+	for name := range e.notifiers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// evaluate adds a matching event to its group's window and reports whether the rule fires
+func (s *ruleState) evaluate(doc map[string]interface{}, at time.Time) (Alert, bool) {
+	rule := s.rule
+	if rule.Filter != nil && !rule.Filter.MatchDocument(doc) {
+		return Alert{}, false
+	}
+
+	value := 1.0
+	if rule.Aggregate.Type != "" && rule.Aggregate.Type != AggregateCount {
+		v, ok := number(lookup(doc, rule.Aggregate.Field))
+		if !ok {
+			return Alert{}, false
+		}
+		value = v
+	}
+
+	group := make(map[string]string, len(rule.GroupBy))
+	keys := make([]string, len(rule.GroupBy))
+	for i, path := range rule.GroupBy {
+		group[path] = text(lookup(doc, path))
+		keys[i] = group[path]
+	}
+	key := strings.Join(keys, "\x00")
+
+	s.sweep(at)
+	g, ok := s.groups[key]
+	if !ok {
+		g = &groupState{}
+		s.groups[key] = g
+	}
+
+	window := time.Duration(rule.Window)
+	if window > 0 {
+		g.samples = append(g.samples, sample{at: at, value: value})
+		g.prune(at.Add(-window))
+	} else {
+		g.samples = []sample{{at: at, value: value}}
+	}
+
+	result := aggregate(rule.Aggregate.Type, g.samples)
+	if !rule.Threshold.holds(result) {
+		g.active = false
+		return Alert{}, false
+	}
+	if window > 0 && g.active {
+		return Alert{}, false
+	}
+	if !g.lastFired.IsZero() && at.Sub(g.lastFired) < time.Duration(rule.Cooldown) {
+		return Alert{}, false
+	}
+	g.active = true
+	g.lastFired = at
+
+	aggregateName := rule.Aggregate.Type
+	if aggregateName == "" {
+		aggregateName = AggregateCount
+	}
+	if aggregateName != AggregateCount {
+		aggregateName += "(" + rule.Aggregate.Field + ")"
+	}
+	if len(group) == 0 {
+		group = nil
+	}
+
+	return Alert{
+		Rule:        rule.Name,
+		Description: rule.Description,
+		Severity:    rule.Severity,
+		Group:       group,
+		Aggregate:   aggregateName,
+		Value:       result,
+		Threshold:   rule.Threshold,
+		Events:      len(g.samples),
+		Window:      rule.Window,
+		Time:        at,
+	}, true
+}
+
+// sweep evicts groups with an empty window and no cooldown pending, so that
+// grouping by contact or agent does not keep every group ever seen. It runs
+// at most once per window or cooldown, whichever is longer, of event time.
+func (s *ruleState) sweep(at time.Time) {
+	if at.Before(s.nextSweep) {
+		return
+	}
+	window, cooldown := time.Duration(s.rule.Window), time.Duration(s.rule.Cooldown)
+	s.nextSweep = at.Add(max(window, cooldown))
+
+	for key, g := range s.groups {
+		if window > 0 {
+			g.prune(at.Add(-window))
+		}
+		if (window == 0 || len(g.samples) == 0) && (g.lastFired.IsZero() || at.Sub(g.lastFired) >= cooldown) {
+			delete(s.groups, key)
+		}
+	}
+}
+
+// prune drops samples at or before cutoff
+func (g *groupState) prune(cutoff time.Time) {
+	kept := g.samples[:0]
+	for _, s := range g.samples {
+		if s.at.After(cutoff) {
+			kept = append(kept, s)
+		}
+	}
+	g.samples = kept
+}
+
+// aggregate reduces the window's samples
+func aggregate(kind string, samples []sample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	switch kind {
+	case AggregateSum, AggregateAvg:
+		var sum float64
+		for _, s := range samples {
+			sum += s.value
+		}
+		if kind == AggregateAvg {
+			return sum / float64(len(samples))
+		}
+		return sum
+	case AggregateMin:
+		result := math.Inf(1)
+		for _, s := range samples {
+			result = math.Min(result, s.value)
+		}
+		return result
+	case AggregateMax:
+		result := math.Inf(-1)
+		for _, s := range samples {
+			result = math.Max(result, s.value)
+		}
+		return result
+	default:
+		return float64(len(samples))
+	}
+}
+
+// document converts an event to generic JSON values for matching and field lookup
+func document(event interface{}) (map[string]interface{}, error) {
+	var data []byte
+	switch e := event.(type) {
+	case []byte:
+		data = e
+	case json.RawMessage:
+		data = e
+	default:
+		var err error
+		if data, err = json.Marshal(event); err != nil {
+			return nil, fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+
+	var doc map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse event: %w", err)
+	}
+	return doc, nil
+}
+
+// lookup returns the value at a dot-separated path, or nil if it is missing
+func lookup(doc map[string]interface{}, path string) interface{} {
+	var value interface{} = doc
+	for _, name := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = obj[name]
+	}
+	return value
+}
+
+// number converts a JSON number to float64
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// text formats a JSON value as a group key
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,231 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Alert is raised when a rule's threshold is crossed
+type Alert struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+	// Group holds the GroupBy field values the alert applies to
+	Group     map[string]string `json:"group,omitempty"`
+	Aggregate string            `json:"aggregate"`
+	Value     float64           `json:"value"`
+	Threshold Condition         `json:"threshold"`
+	// Events is the number of events in the window
+	Events int       `json:"events"`
+	Window Duration  `json:"window"`
+	Time   time.Time `json:"time"`
+	// EventID is the event that fired the alert
+	EventID string `json:"eventId,omitempty"`
+}
+
+// Summary returns a one-line description of the alert
+func (a Alert) Summary() string {
+	var b strings.Builder
+	if a.Severity != "" {
+		fmt.Fprintf(&b, "[%s] ", strings.ToUpper(a.Severity))
+	}
+	b.WriteString(a.Rule)
+
+	if len(a.Group) > 0 {
+		names := make([]string, 0, len(a.Group))
+		for name := range a.Group {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = name + "=" + a.Group[name]
+		}
+		fmt.Fprintf(&b, " (%s)", strings.Join(parts, ", "))
+	}
+
+	fmt.Fprintf(&b, ": %s %g %s", a.Aggregate, a.Value, a.Threshold)
+	if a.Window > 0 {
+		fmt.Fprintf(&b, " over %s", time.Duration(a.Window))
+	}
+	return b.String()
+}
+
+// Notifier delivers alerts
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// NotifierFunc adapts a function to a Notifier
+type NotifierFunc func(ctx context.Context, alert Alert) error
+
+// Notify calls f
+func (f NotifierFunc) Notify(ctx context.Context, alert Alert) error {
+	return f(ctx, alert)
+}
+
+// WebhookNotifier POSTs each alert as JSON to a URL
+type WebhookNotifier struct {
+	URL     string
+	Headers map[string]string
+	// Client is used for requests; http.DefaultClient when nil
+	Client *http.Client
+}
+
+// Notify POSTs the alert and treats any non-2xx response as a failure
+func (n WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	return post(ctx, n.Client, n.URL, n.Headers, body)
+}
+
+// SlackNotifier posts alerts to a Slack incoming webhook
+type SlackNotifier struct {
+	WebhookURL string
+	// Client is used for requests; http.DefaultClient when nil
+	Client *http.Client
+}
+
+// Notify posts the alert summary as a Slack message
+func (n SlackNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(map[string]string{"text": alert.Summary()})
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	return post(ctx, n.Client, n.WebhookURL, nil, body)
+}
+
+// post sends a JSON body and treats any non-2xx response as a failure
+func post(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to notify %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification to %s failed with status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailMessage is a plain-text email
+type EmailMessage struct {
+	From    string
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email
+type Mailer interface {
+	Send(ctx context.Context, msg EmailMessage) error
+}
+
+// SMTPMailer sends email through an SMTP server
+type SMTPMailer struct {
+	// Addr is the server host:port
+	Addr string
+	// Auth is optional, e.g. smtp.PlainAuth
+	Auth smtp.Auth
+}
+
+// Send delivers the message with smtp.SendMail, which does not honour ctx
+func (m SMTPMailer) Send(_ context.Context, msg EmailMessage) error {
+	data, err := compose(msg)
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(m.Addr, m.Auth, msg.From, msg.To, data); err != nil {
+		return fmt.Errorf("failed to send email via %s: %w", m.Addr, err)
+	}
+	return nil
+}
+
+// compose formats a message for SMTP. Subjects carry event data, so they are
+// Q-encoded when needed, which keeps line breaks out of the headers; line
+// breaks in addresses are rejected.
+func compose(msg EmailMessage) ([]byte, error) {
+	for _, address := range append([]string{msg.From}, msg.To...) {
+		if strings.ContainsAny(address, "\r\n") {
+			return nil, fmt.Errorf("invalid email address %q", address)
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return b.Bytes(), nil
+}
+
+// MemoryMailer keeps sent messages in memory. It stands in for an SMTP
+// server in development and tests.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []EmailMessage
+}
+
+// Send records the message
+func (m *MemoryMailer) Send(_ context.Context, msg EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far
+func (m *MemoryMailer) Messages() []EmailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]EmailMessage(nil), m.messages...)
+}
+
+// EmailNotifier emails alerts
+type EmailNotifier struct {
+	Mailer Mailer
+	From   string
+	To     []string
+}
+
+// Notify emails the alert summary with its details as the body
+func (n EmailNotifier) Notify(ctx context.Context, alert Alert) error {
+	details, err := json.MarshalIndent(alert, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	return n.Mailer.Send(ctx, EmailMessage{
+		From:    n.From,
+		To:      n.To,
+		Subject: alert.Summary(),
+		Body:    string(details) + "\n",
+	})
+}
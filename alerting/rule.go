@@ -0,0 +1,194 @@
+package alerting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tommyorndorff/operata-events/pattern"
+)
+
+// Aggregate types
+const (
+	AggregateCount = "count"
+	AggregateSum   = "sum"
+	AggregateAvg   = "avg"
+	AggregateMin   = "min"
+	AggregateMax   = "max"
+)
+
+// Rule declares an alert
+type Rule struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+	// Filter selects the events the rule considers; nil matches every event
+	Filter *pattern.Pattern `json:"filter,omitempty"`
+	// GroupBy lists dot-separated event fields; each combination of values is evaluated separately
+	GroupBy []string `json:"groupBy,omitempty"`
+	// Window is the span of event time aggregated; zero evaluates each event alone
+	Window    Duration  `json:"window,omitempty"`
+	Aggregate Aggregate `json:"aggregate,omitempty"`
+	// Threshold fires the rule; the default fires whenever the aggregate is positive
+	Threshold Condition `json:"threshold,omitempty"`
+	// Cooldown is the least event time between alerts for the same group
+	Cooldown Duration `json:"cooldown,omitempty"`
+	// Notifiers names the notifiers to alert; empty means all of them
+	Notifiers []string `json:"notifiers,omitempty"`
+}
+
+// Aggregate is the value a rule computes over its window
+type Aggregate struct {
+	// Type is one of count (the default), sum, avg, min or max
+	Type string `json:"type,omitempty"`
+	// Field is the dot-separated numeric event field aggregated; not used by count
+	Field string `json:"field,omitempty"`
+}
+
+// Condition compares an aggregate with a value
+type Condition struct {
+	// Op is one of >, >=, <, <= or ==
+	Op    string  `json:"op"`
+	Value float64 `json:"value"`
+}
+
+// holds reports whether v satisfies the condition; the zero Condition means > 0
+func (c Condition) holds(v float64) bool {
+	switch c.Op {
+	case ">", "":
+		return v > c.Value
+	case ">=":
+		return v >= c.Value
+	case "<":
+		return v < c.Value
+	case "<=":
+		return v <= c.Value
+	case "==":
+		return v == c.Value
+	default:
+		return false
+	}
+}
+
+func (c Condition) String() string {
+	op := c.Op
+	if op == "" {
+		op = ">"
+	}
+	return fmt.Sprintf("%s %g", op, c.Value)
+}
+
+// Duration is a time.Duration written in JSON as a Go duration string such as "10m"
+type Duration time.Duration
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON reads a duration string, or a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+		*d = Duration(parsed)
+	case float64:
+		*d = Duration(value * float64(time.Second))
+	default:
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	return nil
+}
+
+// LoadRules reads a list of rules written in YAML or JSON. Field names and
+// values are the same in both.
+func LoadRules(r io.Reader) ([]Rule, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+
+	// YAML is converted to JSON so that both are checked by the same decoder
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] != '[' {
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse rules: %w", err)
+		}
+		if data, err = json.Marshal(jsonValue(doc)); err != nil {
+			return nil, fmt.Errorf("failed to convert YAML rules: %w", err)
+		}
+	}
+
+	var rules []Rule
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+	return rules, nil
+}
+
+// jsonValue converts a decoded YAML value to one encoding/json can marshal
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = jsonValue(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// validate checks a rule against the notifiers available
+func (r *Rule) validate(notifiers map[string]Notifier) error {
+	if r.Name == "" {
+		return fmt.Errorf("rule has no name")
+	}
+	switch r.Aggregate.Type {
+	case "", AggregateCount:
+	case AggregateSum, AggregateAvg, AggregateMin, AggregateMax:
+		if r.Aggregate.Field == "" {
+			return fmt.Errorf("rule %s: %s aggregate needs a field", r.Name, r.Aggregate.Type)
+		}
+	default:
+		return fmt.Errorf("rule %s: unknown aggregate %q", r.Name, r.Aggregate.Type)
+	}
+	switch r.Threshold.Op {
+	case "", ">", ">=", "<", "<=", "==":
+	default:
+		return fmt.Errorf("rule %s: unknown threshold operator %q", r.Name, r.Threshold.Op)
+	}
+	if r.Window < 0 || r.Cooldown < 0 {
+		return fmt.Errorf("rule %s: window and cooldown must not be negative", r.Name)
+	}
+	for _, name := range r.Notifiers {
+		if _, ok := notifiers[name]; !ok {
+			return fmt.Errorf("rule %s: unknown notifier %q", r.Name, name)
+		}
+	}
+	return nil
+}
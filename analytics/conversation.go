@@ -0,0 +1,170 @@
+package analytics
+
+import (
+	"io"
+	"sort"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// Conversation flags set by ConversationThresholds.Classify
+const (
+	FlagAgentOverTalking      = "agent-over-talking"
+	FlagLongSilences          = "long-silences"
+	FlagFrequentInterruptions = "frequent-interruptions"
+)
+
+// ConversationDynamics are derived from a call's headset speech metrics. Transmitted
+// speech is the agent's and received speech is the customer's.
+type ConversationDynamics struct {
+	// TalkListenRatio is agent speech time over customer speech time, or zero
+	// when the customer did not speak
+	TalkListenRatio float64 `json:"talkListenRatio"`
+	// AgentOnly is set when the agent spoke and the customer did not, a
+	// ratio too large to represent
+	AgentOnly bool `json:"agentOnly,omitempty"`
+	// DeadAirRatio is the fraction of the call with nobody speaking
+	DeadAirRatio float64 `json:"deadAirRatio"`
+	// InterruptionIndex is the fraction of speech time where both parties spoke at once
+	InterruptionIndex float64  `json:"interruptionIndex"`
+	Flags             []string `json:"flags"`
+}
+
+// ConversationThresholds decide which flags a call receives
+type ConversationThresholds struct {
+	OverTalkRatio     float64 `json:"overTalkRatio"`
+	DeadAirRatio      float64 `json:"deadAirRatio"`
+	InterruptionIndex float64 `json:"interruptionIndex"`
+}
+
+// DefaultConversationThresholds flag agents talking 1.5 times as much as the
+// customer, calls more than 20% silent, and calls with 10% cross-talk
+var DefaultConversationThresholds = ConversationThresholds{
+	OverTalkRatio:     1.5,
+	DeadAirRatio:      0.2,
+	InterruptionIndex: 0.1,
+}
+
+// NewConversationDynamics derives conversation metrics from speech metrics.
+// Ratios with a zero denominator are zero; see AgentOnly.
+func NewConversationDynamics(speech events.SpeechMetrics) ConversationDynamics {
+	return ConversationDynamics{
+		TalkListenRatio:   ratio(speech.TxSpeechTotal, speech.RxSpeechTotal),
+		AgentOnly:         speech.TxSpeechTotal > 0 && speech.RxSpeechTotal <= 0,
+		DeadAirRatio:      ratio(speech.SilenceTotal, speech.TotalSeconds),
+		InterruptionIndex: ratio(speech.CrossTalkTotal, speech.TxSpeechTotal+speech.RxSpeechTotal),
+		Flags:             []string{},
+	}
+}
+
+// Classify sets the flags of d that its metrics exceed
+func (t ConversationThresholds) Classify(d *ConversationDynamics) {
+	d.Flags = d.Flags[:0]
+	if d.AgentOnly || d.TalkListenRatio > t.OverTalkRatio {
+		d.Flags = append(d.Flags, FlagAgentOverTalking)
+	}
+	if d.DeadAirRatio > t.DeadAirRatio {
+		d.Flags = append(d.Flags, FlagLongSilences)
+	}
+	if d.InterruptionIndex > t.InterruptionIndex {
+		d.Flags = append(d.Flags, FlagFrequentInterruptions)
+	}
+}
+
+// QueueConversation summarises conversation dynamics over a queue's calls
+type QueueConversation struct {
+	QueueName string `json:"queueName"`
+	Calls     int    `json:"calls"`
+	// AverageTalkListenRatio covers the calls on which the customer spoke
+	AverageTalkListenRatio   float64        `json:"averageTalkListenRatio"`
+	AverageDeadAirRatio      float64        `json:"averageDeadAirRatio"`
+	AverageInterruptionIndex float64        `json:"averageInterruptionIndex"`
+	FlagCounts               map[string]int `json:"flagCounts"`
+}
+
+// ConversationReport summarises conversation dynamics per queue
+type ConversationReport struct {
+	Thresholds ConversationThresholds `json:"thresholds"`
+	Queues     []QueueConversation    `json:"queues"`
+}
+
+// ConversationBuilder accumulates HeadsetSummary events into a ConversationReport
+type ConversationBuilder struct {
+	Thresholds ConversationThresholds
+
+	queues map[string]*conversationTotals
+}
+
+type conversationTotals struct {
+	calls             int
+	talkListen        mean
+	deadAir           mean
+	interruptionIndex mean
+	flags             map[string]int
+}
+
+// NewConversationBuilder creates a builder using DefaultConversationThresholds
+func NewConversationBuilder() *ConversationBuilder {
+	return &ConversationBuilder{Thresholds: DefaultConversationThresholds, queues: make(map[string]*conversationTotals)}
+}
+
+// Dynamics returns the classified conversation dynamics of a single event
+func (b *ConversationBuilder) Dynamics(event *events.HeadsetSummaryEvent) ConversationDynamics {
+	dynamics := NewConversationDynamics(event.Detail.Headset.Metrics.Speech)
+	b.Thresholds.Classify(&dynamics)
+	return dynamics
+}
+
+// Add accumulates a HeadsetSummary event with speech metrics; other events are ignored
+func (b *ConversationBuilder) Add(event interface{}) {
+	e, ok := event.(*events.HeadsetSummaryEvent)
+	if !ok || e.Detail.Headset.Metrics.Speech.TotalSeconds <= 0 {
+		return
+	}
+
+	queue := e.Detail.Contact.QueueName
+	totals, ok := b.queues[queue]
+	if !ok {
+		totals = &conversationTotals{flags: make(map[string]int)}
+		b.queues[queue] = totals
+	}
+
+	dynamics := b.Dynamics(e)
+	totals.calls++
+	if e.Detail.Headset.Metrics.Speech.RxSpeechTotal > 0 {
+		totals.talkListen.add(dynamics.TalkListenRatio)
+	}
+	totals.deadAir.add(dynamics.DeadAirRatio)
+	totals.interruptionIndex.add(dynamics.InterruptionIndex)
+	for _, flag := range dynamics.Flags {
+		totals.flags[flag]++
+	}
+}
+
+// Report returns the per-queue summary so far, in queue name order
+func (b *ConversationBuilder) Report() *ConversationReport {
+	report := &ConversationReport{Thresholds: b.Thresholds, Queues: make([]QueueConversation, 0, len(b.queues))}
+	for queue, totals := range b.queues {
+		flags := make(map[string]int, len(totals.flags))
+		for flag, count := range totals.flags {
+			flags[flag] = count
+		}
+		report.Queues = append(report.Queues, QueueConversation{
+			QueueName:                queue,
+			Calls:                    totals.calls,
+			AverageTalkListenRatio:   totals.talkListen.value(),
+			AverageDeadAirRatio:      totals.deadAir.value(),
+			AverageInterruptionIndex: totals.interruptionIndex.value(),
+			FlagCounts:               flags,
+		})
+	}
+	sort.Slice(report.Queues, func(i, j int) bool {
+		return report.Queues[i].QueueName < report.Queues[j].QueueName
+	})
+	return report
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *ConversationReport) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
@@ -0,0 +1,90 @@
+package analytics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// speechEvent builds a HeadsetSummary event in queue with the given speech metrics
+func speechEvent(queue string, speech events.SpeechMetrics) *events.HeadsetSummaryEvent {
+	event := &events.HeadsetSummaryEvent{EventBridgeEvent: events.EventBridgeEvent{DetailType: events.EventTypeHeadsetSummary}}
+	event.Detail.Contact.QueueName = queue
+	event.Detail.Headset.Metrics.Speech = speech
+	return event
+}
+
+func TestConversationDynamics(t *testing.T) {
+	tests := []struct {
+		name     string
+		speech   events.SpeechMetrics
+		expected ConversationDynamics
+	}{
+		{
+			"balanced",
+			events.SpeechMetrics{TxSpeechTotal: 40, RxSpeechTotal: 50, SilenceTotal: 10, CrossTalkTotal: 4.5, TotalSeconds: 100},
+			ConversationDynamics{TalkListenRatio: 0.8, DeadAirRatio: 0.1, InterruptionIndex: 0.05, Flags: []string{}},
+		},
+		{
+			"over-talking and silent",
+			events.SpeechMetrics{TxSpeechTotal: 60, RxSpeechTotal: 10, SilenceTotal: 30, CrossTalkTotal: 14, TotalSeconds: 100},
+			ConversationDynamics{TalkListenRatio: 6, DeadAirRatio: 0.3, InterruptionIndex: 0.2, Flags: []string{FlagAgentOverTalking, FlagLongSilences, FlagFrequentInterruptions}},
+		},
+		{
+			"silent customer",
+			events.SpeechMetrics{TxSpeechTotal: 50, SilenceTotal: 10, TotalSeconds: 60},
+			ConversationDynamics{AgentOnly: true, DeadAirRatio: 1.0 / 6, Flags: []string{FlagAgentOverTalking}},
+		},
+		{
+			"no speech",
+			events.SpeechMetrics{},
+			ConversationDynamics{Flags: []string{}},
+		},
+	}
+
+	builder := NewConversationBuilder()
+	for _, test := range tests {
+		got := builder.Dynamics(speechEvent("", test.speech))
+		if math.Abs(got.TalkListenRatio-test.expected.TalkListenRatio) > 1e-9 ||
+			got.AgentOnly != test.expected.AgentOnly ||
+			math.Abs(got.DeadAirRatio-test.expected.DeadAirRatio) > 1e-9 ||
+			math.Abs(got.InterruptionIndex-test.expected.InterruptionIndex) > 1e-9 ||
+			!reflect.DeepEqual(got.Flags, test.expected.Flags) {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestConversationByQueue(t *testing.T) {
+	builder := NewConversationBuilder()
+	builder.Add(loadFixture(t, "headset_summary.json"))
+	builder.Add(speechEvent("Sales", events.SpeechMetrics{TxSpeechTotal: 60, RxSpeechTotal: 20, SilenceTotal: 20, TotalSeconds: 100}))
+	builder.Add(speechEvent("Sales", events.SpeechMetrics{TxSpeechTotal: 30, RxSpeechTotal: 30, SilenceTotal: 40, TotalSeconds: 100}))
+	builder.Add(speechEvent("Sales", events.SpeechMetrics{}))
+	builder.Add(speechEvent("Sales", events.SpeechMetrics{TxSpeechTotal: 80, SilenceTotal: 20, TotalSeconds: 100}))
+	builder.Add(loadFixture(t, "call_summary.json"))
+
+	report := builder.Report()
+	if len(report.Queues) != 2 {
+		t.Fatalf("Expected 2 queues, got %+v", report.Queues)
+	}
+
+	fixture := report.Queues[0]
+	if fixture.QueueName != "Operata Prod Default Queue" || fixture.Calls != 1 || len(fixture.FlagCounts) != 0 {
+		t.Errorf("Unexpected fixture queue: %+v", fixture)
+	}
+	if math.Abs(fixture.AverageTalkListenRatio-100.9/140.2) > 1e-9 {
+		t.Errorf("Expected talk-listen ratio %v, got %v", 100.9/140.2, fixture.AverageTalkListenRatio)
+	}
+
+	sales := report.Queues[1]
+	// The silent customer call is flagged but not averaged into the talk-listen ratio
+	if sales.Calls != 3 || sales.AverageTalkListenRatio != 2 || math.Abs(sales.AverageDeadAirRatio-0.8/3) > 1e-9 {
+		t.Errorf("Unexpected Sales queue: %+v", sales)
+	}
+	if sales.FlagCounts[FlagAgentOverTalking] != 2 || sales.FlagCounts[FlagLongSilences] != 1 {
+		t.Errorf("Unexpected Sales flags: %v", sales.FlagCounts)
+	}
+}
@@ -0,0 +1,349 @@
+package analytics
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// Device connection types inferred from device labels
+const (
+	DeviceBluetooth = "bluetooth"
+	DeviceUSB       = "usb"
+	DeviceBuiltIn   = "built-in"
+	DeviceWired     = "wired"
+	DeviceVirtual   = "virtual"
+	DeviceUnknown   = unknownValue
+)
+
+// Device kinds reported by the browser
+const (
+	DeviceKindInput  = "audioinput"
+	DeviceKindOutput = "audiooutput"
+)
+
+// deviceLabelPrefixes are added by Chrome to the system default and communications devices
+var deviceLabelPrefixes = []string{"default - ", "communications - "}
+
+// usbIDPattern matches the USB vendor:product ID Chrome appends to USB device labels
+var usbIDPattern = regexp.MustCompile(`\([0-9a-f]{4}:[0-9a-f]{4}\)`)
+
+// deviceKeywords map label fragments to connection types, checked in order
+var deviceKeywords = []struct {
+	deviceType string
+	keywords   []string
+}{
+	{DeviceVirtual, []string{"virtual", "blackhole", "vb-audio", "voicemeeter", "krisp", "nvidia broadcast", "zoomaudiodevice", "microsoft teams audio"}},
+	{DeviceBluetooth, []string{"bluetooth", "hands-free", "handsfree", "airpods", "buds", "(bt)", "hfp", "a2dp"}},
+	{DeviceUSB, []string{"usb"}},
+	{DeviceWired, []string{"headset microphone", "headset earphone", "external microphone", "line in", "headphones"}},
+	{DeviceBuiltIn, []string{"built-in", "builtin", "internal", "macbook", "imac", "microphone array", "realtek", "intel smart sound", "conexant", "high definition audio", "facetime"}},
+}
+
+// ClassifyDevice infers how a device is connected from its label, such as
+// "Default - Jabra Evolve2 65 (0b0e:0a4c)". It returns DeviceUnknown when the
+// label gives no clue.
+func ClassifyDevice(label string) string {
+	name := deviceName(label)
+	if name == "" {
+		return DeviceUnknown
+	}
+	for _, entry := range deviceKeywords {
+		if containsAny(name, entry.keywords) || entry.deviceType == DeviceUSB && usbIDPattern.MatchString(name) {
+			return entry.deviceType
+		}
+	}
+	return DeviceUnknown
+}
+
+// deviceName lower-cases a label and strips Chrome's default device prefixes
+func deviceName(label string) string {
+	name := strings.ToLower(strings.TrimSpace(label))
+	for _, prefix := range deviceLabelPrefixes {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return name
+}
+
+func containsAny(s string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(s, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceUsage is a device selected during a call
+type DeviceUsage struct {
+	Time  time.Time `json:"time"`
+	Kind  string    `json:"kind"`
+	Label string    `json:"label"`
+	Type  string    `json:"type"`
+}
+
+// DeviceSwitch is a change from one device to another of the same kind after the call connected
+type DeviceSwitch struct {
+	From DeviceUsage `json:"from"`
+	To   DeviceUsage `json:"to"`
+}
+
+// CallDevices describes the devices used on one call
+type CallDevices struct {
+	ContactID string `json:"contactId"`
+	Agent     string `json:"agent"`
+	// Devices are in time order
+	Devices  []DeviceUsage  `json:"devices"`
+	Switches []DeviceSwitch `json:"switches"`
+	// InputType is the type of the microphone used for longest
+	InputType string `json:"inputType"`
+}
+
+// AnalyzeDevices orders a call's device events and finds mid-call switches.
+// Devices selected before the call connected to the agent are set-up, not
+// switches. Each microphone counts as in use until the next one is selected
+// or the call ends.
+func AnalyzeDevices(detail *events.CallSummaryDetail) CallDevices {
+	call := CallDevices{
+		ContactID: detail.Contact.ID.Current,
+		Agent:     detail.ServiceAgent.Username,
+		Devices:   make([]DeviceUsage, 0, len(detail.WebRTCSession.UsedDevices)),
+		Switches:  []DeviceSwitch{},
+		InputType: DeviceUnknown,
+	}
+	if call.Agent == "" {
+		call.Agent = UnknownAgent
+	}
+
+	for _, device := range detail.WebRTCSession.UsedDevices {
+		label := device.Label
+		if label == "" {
+			label = device.DeviceID
+		}
+		call.Devices = append(call.Devices, DeviceUsage{
+			Time:  device.Timestamp,
+			Kind:  device.Kind,
+			Label: label,
+			Type:  ClassifyDevice(device.Label),
+		})
+	}
+	sort.SliceStable(call.Devices, func(i, j int) bool {
+		return call.Devices[i].Time.Before(call.Devices[j].Time)
+	})
+
+	connected := detail.Contact.Events.ConnectingToAgent
+	current := make(map[string]DeviceUsage)
+	for _, device := range call.Devices {
+		previous, ok := current[device.Kind]
+		current[device.Kind] = device
+		if !ok || deviceName(previous.Label) == deviceName(device.Label) {
+			continue
+		}
+		if !connected.IsZero() && device.Time.Before(connected) {
+			continue
+		}
+		call.Switches = append(call.Switches, DeviceSwitch{From: previous, To: device})
+	}
+
+	call.InputType = inputType(call.Devices, detail.Timestamp)
+	return call
+}
+
+// inputType returns the type of the microphone in use for longest before end;
+// ties, including an unknown end, go to the later microphone
+func inputType(devices []DeviceUsage, end time.Time) string {
+	var inputs []DeviceUsage
+	for _, device := range devices {
+		if device.Kind == DeviceKindInput {
+			inputs = append(inputs, device)
+		}
+	}
+
+	result := DeviceUnknown
+	var longest time.Duration = -1
+	for i, input := range inputs {
+		until := end
+		if i+1 < len(inputs) {
+			until = inputs[i+1].Time
+		}
+		used := until.Sub(input.Time)
+		if until.IsZero() || used < 0 {
+			used = 0
+		}
+		if used >= longest {
+			longest = used
+			result = input.Type
+		}
+	}
+	return result
+}
+
+// DeviceReport correlates device types and mid-call switches with call quality
+type DeviceReport struct {
+	Calls             int     `json:"calls"`
+	CallsWithSwitches int     `json:"callsWithSwitches"`
+	SwitchRate        float64 `json:"switchRate"`
+	// ByType compares calls by the type of microphone used, busiest first
+	ByType []DeviceQuality `json:"byType"`
+	// SwitchImpact compares calls with and without mid-call switches
+	SwitchImpact SwitchImpact `json:"switchImpact"`
+	// Agents lists agents who switched devices mid-call, most switches first
+	Agents []AgentDeviceSwitches `json:"agents"`
+	// UnclassifiedLabels lists lower-cased device labels that gave no clue to their type
+	UnclassifiedLabels []string `json:"unclassifiedLabels"`
+}
+
+// DeviceQuality summarises the calls made with one type of microphone
+type DeviceQuality struct {
+	Type       string       `json:"type"`
+	Calls      int          `json:"calls"`
+	AverageMOS float64      `json:"averageMos"`
+	MOS        Distribution `json:"mos"`
+	// PoorCallRate is the fraction of calls with Poor or Bad quality
+	PoorCallRate float64 `json:"poorCallRate"`
+}
+
+// SwitchImpact compares the MOS of calls with and without mid-call switches
+type SwitchImpact struct {
+	MOSWithSwitches    float64 `json:"mosWithSwitches"`
+	MOSWithoutSwitches float64 `json:"mosWithoutSwitches"`
+}
+
+// AgentDeviceSwitches counts an agent's mid-call device switches
+type AgentDeviceSwitches struct {
+	Agent             string `json:"agent"`
+	Calls             int    `json:"calls"`
+	CallsWithSwitches int    `json:"callsWithSwitches"`
+	Switches          int    `json:"switches"`
+}
+
+// DeviceBuilder accumulates CallSummary events into a DeviceReport
+type DeviceBuilder struct {
+	calls         int
+	switchedCalls int
+	types         map[string][]float64
+	typeCalls     map[string]int
+	withSwitch    mean
+	withoutSwitch mean
+	agents        map[string]*AgentDeviceSwitches
+	unclassified  map[string]bool
+}
+
+// NewDeviceBuilder creates an empty builder
+func NewDeviceBuilder() *DeviceBuilder {
+	return &DeviceBuilder{
+		types:        make(map[string][]float64),
+		typeCalls:    make(map[string]int),
+		agents:       make(map[string]*AgentDeviceSwitches),
+		unclassified: make(map[string]bool),
+	}
+}
+
+// Add accumulates a CallSummary event; other event types are ignored
+func (b *DeviceBuilder) Add(event interface{}) {
+	e, ok := event.(*events.CallSummaryEvent)
+	if !ok {
+		return
+	}
+	call := AnalyzeDevices(&e.Detail)
+	mos := e.Detail.WebRTCSession.Metrics.MOS.Avg
+
+	b.calls++
+	b.typeCalls[call.InputType]++
+	if mos > 0 {
+		b.types[call.InputType] = append(b.types[call.InputType], mos)
+	}
+
+	agent, ok := b.agents[call.Agent]
+	if !ok {
+		agent = &AgentDeviceSwitches{Agent: call.Agent}
+		b.agents[call.Agent] = agent
+	}
+	agent.Calls++
+
+	if len(call.Switches) > 0 {
+		b.switchedCalls++
+		agent.CallsWithSwitches++
+		agent.Switches += len(call.Switches)
+		b.withSwitch.addPositive(mos)
+	} else {
+		b.withoutSwitch.addPositive(mos)
+	}
+
+	for _, device := range call.Devices {
+		if device.Type == DeviceUnknown && device.Label != "" {
+			b.unclassified[deviceName(device.Label)] = true
+		}
+	}
+}
+
+// Report returns the device analysis so far
+func (b *DeviceBuilder) Report() *DeviceReport {
+	report := &DeviceReport{
+		Calls:             b.calls,
+		CallsWithSwitches: b.switchedCalls,
+		SwitchRate:        ratio(float64(b.switchedCalls), float64(b.calls)),
+		ByType:            make([]DeviceQuality, 0, len(b.typeCalls)),
+		SwitchImpact: SwitchImpact{
+			MOSWithSwitches:    b.withSwitch.value(),
+			MOSWithoutSwitches: b.withoutSwitch.value(),
+		},
+		Agents:             []AgentDeviceSwitches{},
+		UnclassifiedLabels: make([]string, 0, len(b.unclassified)),
+	}
+
+	for deviceType, calls := range b.typeCalls {
+		samples := append([]float64(nil), b.types[deviceType]...)
+		var total mean
+		poor := 0
+		for _, mos := range samples {
+			total.add(mos)
+			switch events.GetCallQualityLevel(mos) {
+			case events.QualityPoor, events.QualityBad:
+				poor++
+			}
+		}
+		report.ByType = append(report.ByType, DeviceQuality{
+			Type:         deviceType,
+			Calls:        calls,
+			AverageMOS:   total.value(),
+			MOS:          newDistribution(samples),
+			PoorCallRate: ratio(float64(poor), float64(len(samples))),
+		})
+	}
+	sort.Slice(report.ByType, func(i, j int) bool {
+		a, c := report.ByType[i], report.ByType[j]
+		if a.Calls != c.Calls {
+			return a.Calls > c.Calls
+		}
+		return a.Type < c.Type
+	})
+
+	for _, agent := range b.agents {
+		if agent.Switches > 0 {
+			report.Agents = append(report.Agents, *agent)
+		}
+	}
+	sort.Slice(report.Agents, func(i, j int) bool {
+		a, c := report.Agents[i], report.Agents[j]
+		if a.Switches != c.Switches {
+			return a.Switches > c.Switches
+		}
+		return a.Agent < c.Agent
+	})
+
+	for label := range b.unclassified {
+		report.UnclassifiedLabels = append(report.UnclassifiedLabels, label)
+	}
+	sort.Strings(report.UnclassifiedLabels)
+	return report
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *DeviceReport) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
@@ -0,0 +1,134 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// deviceCall builds a CallSummary event for agent that connected at testTime
+// and ended a minute later, with devices selected at the given offsets
+func deviceCall(agent string, mos float64, devices ...events.Device) *events.CallSummaryEvent {
+	event := &events.CallSummaryEvent{EventBridgeEvent: events.EventBridgeEvent{DetailType: events.EventTypeCallSummary, Time: testTime}}
+	event.Detail.ServiceAgent.Username = agent
+	event.Detail.Contact.Events.ConnectingToAgent = testTime
+	event.Detail.Timestamp = testTime.Add(time.Minute)
+	event.Detail.WebRTCSession.Metrics.MOS.Avg = mos
+	event.Detail.WebRTCSession.UsedDevices = devices
+	return event
+}
+
+func device(offset time.Duration, kind, label string) events.Device {
+	return events.Device{Timestamp: testTime.Add(offset), DeviceID: label, Kind: kind, Label: label}
+}
+
+func TestClassifyDevice(t *testing.T) {
+	tests := []struct {
+		label    string
+		expected string
+	}{
+		{"Default - Elgato Wave:3 (0fd9:0070)", DeviceUSB},
+		{"Jabra Evolve2 65 (0b0e:0a4c)", DeviceUSB},
+		{"USB Audio Device", DeviceUSB},
+		{"Headset (WH-1000XM4 Hands-Free AG Audio)", DeviceBluetooth},
+		{"AirPods Pro", DeviceBluetooth},
+		{"Communications - Jabra Evolve 75 (Bluetooth)", DeviceBluetooth},
+		{"MacBook Pro Microphone (Built-in)", DeviceBuiltIn},
+		{"Microphone Array (Realtek(R) Audio)", DeviceBuiltIn},
+		{"Headset Microphone (2- Realtek Audio)", DeviceWired},
+		{"External Microphone", DeviceWired},
+		{"Krisp Microphone (Virtual)", DeviceVirtual},
+		{"BlackHole 2ch", DeviceVirtual},
+		{"Poly Voyager", DeviceUnknown},
+		{"", DeviceUnknown},
+	}
+
+	for _, test := range tests {
+		if deviceType := ClassifyDevice(test.label); deviceType != test.expected {
+			t.Errorf("ClassifyDevice(%q) = %s, expected %s", test.label, deviceType, test.expected)
+		}
+	}
+}
+
+func TestAnalyzeDevices(t *testing.T) {
+	event := deviceCall("andy", 3.2,
+		// Out of order, as the browser may report them
+		device(30*time.Second, DeviceKindInput, "AirPods Pro"),
+		device(-10*time.Second, DeviceKindInput, "MacBook Pro Microphone (Built-in)"),
+		device(-5*time.Second, DeviceKindInput, "Default - Jabra Evolve2 65 (0b0e:0a4c)"),
+		device(-5*time.Second, DeviceKindOutput, "Default - Jabra Evolve2 65 (0b0e:0a4c)"),
+		device(10*time.Second, DeviceKindOutput, "Jabra Evolve2 65 (0b0e:0a4c)"),
+		device(45*time.Second, DeviceKindInput, "Default - Jabra Evolve2 65 (0b0e:0a4c)"),
+	)
+
+	call := AnalyzeDevices(&event.Detail)
+	if len(call.Devices) != 6 || call.Devices[0].Type != DeviceBuiltIn || call.Devices[5].Label != "Default - Jabra Evolve2 65 (0b0e:0a4c)" {
+		t.Fatalf("Expected devices in time order, got %+v", call.Devices)
+	}
+
+	// The switch to the Jabra before connecting is set-up, and the output
+	// relabelled without its default prefix is the same device
+	if len(call.Switches) != 2 {
+		t.Fatalf("Expected 2 switches, got %d: %+v", len(call.Switches), call.Switches)
+	}
+	if call.Switches[0].From.Type != DeviceUSB || call.Switches[0].To.Type != DeviceBluetooth {
+		t.Errorf("Expected a switch from USB to Bluetooth, got %+v", call.Switches[0])
+	}
+	if call.Switches[1].From.Type != DeviceBluetooth || call.Switches[1].To.Type != DeviceUSB {
+		t.Errorf("Expected a switch from Bluetooth back to USB, got %+v", call.Switches[1])
+	}
+
+	// The Jabra was used from -5s to 30s, AirPods for 15s, then the Jabra again for 15s
+	if call.InputType != DeviceUSB {
+		t.Errorf("Expected input type %s, got %s", DeviceUSB, call.InputType)
+	}
+
+	empty := AnalyzeDevices(&events.CallSummaryDetail{})
+	if empty.Agent != UnknownAgent || empty.InputType != DeviceUnknown || len(empty.Switches) != 0 {
+		t.Errorf("Unexpected analysis of an empty call: %+v", empty)
+	}
+}
+
+func TestDeviceReport(t *testing.T) {
+	builder := NewDeviceBuilder()
+	builder.Add(loadFixture(t, "call_summary.json"))
+	builder.Add(loadFixture(t, "headset_summary.json"))
+	builder.Add(deviceCall("beth", 3.0,
+		device(0, DeviceKindInput, "Headset (WH-1000XM4 Hands-Free AG Audio)"),
+	))
+	builder.Add(deviceCall("beth", 2.8,
+		device(0, DeviceKindInput, "AirPods Pro"),
+		device(20*time.Second, DeviceKindInput, "Poly Voyager"),
+		device(40*time.Second, DeviceKindInput, "AirPods Pro"),
+	))
+	builder.Add(deviceCall("carl", 4.4,
+		device(0, DeviceKindInput, "Jabra Evolve2 65 (0b0e:0a4c)"),
+	))
+
+	report := builder.Report()
+	if report.Calls != 4 || report.CallsWithSwitches != 1 || report.SwitchRate != 0.25 {
+		t.Errorf("Expected 1 of 4 calls with switches, got %d of %d (%v)", report.CallsWithSwitches, report.Calls, report.SwitchRate)
+	}
+
+	if len(report.ByType) != 2 {
+		t.Fatalf("Expected 2 device types, got %+v", report.ByType)
+	}
+	bluetooth, usb := report.ByType[0], report.ByType[1]
+	if bluetooth.Type != DeviceBluetooth || bluetooth.Calls != 2 || bluetooth.AverageMOS != 2.9 || bluetooth.PoorCallRate != 1 {
+		t.Errorf("Unexpected Bluetooth quality: %+v", bluetooth)
+	}
+	if usb.Type != DeviceUSB || usb.Calls != 2 || usb.AverageMOS != 4.325 || usb.PoorCallRate != 0 || usb.MOS.Max != 4.4 {
+		t.Errorf("Unexpected USB quality: %+v", usb)
+	}
+
+	if report.SwitchImpact.MOSWithSwitches != 2.8 {
+		t.Errorf("Expected MOS with switches 2.8, got %v", report.SwitchImpact.MOSWithSwitches)
+	}
+	if len(report.Agents) != 1 || report.Agents[0].Agent != "beth" || report.Agents[0].Calls != 2 || report.Agents[0].Switches != 2 {
+		t.Errorf("Unexpected agents: %+v", report.Agents)
+	}
+	if len(report.UnclassifiedLabels) != 1 || report.UnclassifiedLabels[0] != "poly voyager" {
+		t.Errorf("Expected the Poly label to be unclassified, got %v", report.UnclassifiedLabels)
+	}
+}
@@ -0,0 +1,19 @@
+// Package analytics builds reports from streams of Operata events.
+//
+// Each report has a builder whose Add method accepts any value returned by
+// events.ParseEventBridgeEvent and ignores the event types it does not use,
+// so a single stream can feed several builders. Reports are plain structs
+// that encode to JSON and, where they are meant for people, render as
+// Markdown or HTML.
+//
+// Example usage:
+//
+//	builder := analytics.NewScorecardBuilder(weekStart, weekStart.AddDate(0, 0, 7))
+//	for event, err := range events.NewReader(archive).All() {
+//		if err == nil {
+//			builder.Add(event)
+//		}
+//	}
+//	scorecard := builder.Scorecard()
+//	scorecard.WriteMarkdown(os.Stdout)
+package analytics
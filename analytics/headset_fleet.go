@@ -0,0 +1,308 @@
+package analytics
+
+import (
+	"io"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// Metric names used in FirmwareFlag
+const (
+	MetricExposureDB        = "exposureDb"
+	MetricBackgroundNoiseDB = "backgroundNoiseDb"
+	MetricMisalignmentRate  = "misalignmentRate"
+	MetricCrossTalkPct      = "crossTalkPct"
+)
+
+// FirmwareThresholds decide when a firmware version is flagged. A version is
+// flagged for a metric when it is worse than the other versions of the same
+// model combined by at least the given margin, and both sides have MinCalls
+// calls reporting that metric.
+type FirmwareThresholds struct {
+	MinCalls          int
+	ExposureDB        float64
+	BackgroundNoiseDB float64
+	MisalignmentRate  float64
+	CrossTalkPct      float64
+}
+
+// DefaultFirmwareThresholds are the thresholds used by NewHeadsetFleetBuilder
+var DefaultFirmwareThresholds = FirmwareThresholds{
+	MinCalls:          20,
+	ExposureDB:        3,
+	BackgroundNoiseDB: 3,
+	MisalignmentRate:  0.1,
+	CrossTalkPct:      2,
+}
+
+// HeadsetFleetReport summarises headset metrics across a fleet
+type HeadsetFleetReport struct {
+	Groups  []HeadsetGroup  `json:"groups"`
+	Serials []SerialHistory `json:"serials"`
+	Flags   []FirmwareFlag  `json:"flags"`
+}
+
+// HeadsetGroup summarises the calls made on one model and firmware version
+type HeadsetGroup struct {
+	ModelName       string `json:"modelName"`
+	FirmwareVersion string `json:"firmwareVersion"`
+	Calls           int    `json:"calls"`
+	// Headsets is the number of distinct serial numbers seen
+	Headsets                 int     `json:"headsets"`
+	AverageExposureDB        float64 `json:"averageExposureDb"`
+	AverageBackgroundNoiseDB float64 `json:"averageBackgroundNoiseDb"`
+	// MisalignmentRate is the fraction of calls with at least one misaligned boom arm
+	MisalignmentRate    float64 `json:"misalignmentRate"`
+	AverageCrossTalkPct float64 `json:"averageCrossTalkPct"`
+}
+
+// SerialHistory tracks one headset over time
+type SerialHistory struct {
+	SerialNumber string             `json:"serialNumber"`
+	ModelName    string             `json:"modelName"`
+	Calls        int                `json:"calls"`
+	FirstSeen    time.Time          `json:"firstSeen"`
+	LastSeen     time.Time          `json:"lastSeen"`
+	Firmware     []FirmwareSighting `json:"firmware"`
+}
+
+// FirmwareSighting records when a headset was first seen on a firmware version
+type FirmwareSighting struct {
+	Version   string    `json:"version"`
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+// FirmwareFlag reports a firmware version whose metric is worse than the rest of its model
+type FirmwareFlag struct {
+	ModelName       string  `json:"modelName"`
+	FirmwareVersion string  `json:"firmwareVersion"`
+	Metric          string  `json:"metric"`
+	Value           float64 `json:"value"`
+	Baseline        float64 `json:"baseline"`
+}
+
+// HeadsetFleetBuilder accumulates HeadsetSummary events into a HeadsetFleetReport
+type HeadsetFleetBuilder struct {
+	Thresholds FirmwareThresholds
+
+	groups  map[headsetGroupKey]*headsetTotals
+	serials map[string]*serialTotals
+}
+
+type headsetGroupKey struct {
+	model, firmware string
+}
+
+type headsetTotals struct {
+	calls        int
+	misaligned   int
+	exposure     mean
+	noise        mean
+	crossTalkPct mean
+	serials      map[string]bool
+}
+
+type serialTotals struct {
+	model     string
+	calls     int
+	firstSeen time.Time
+	lastSeen  time.Time
+	firmware  map[string]time.Time
+}
+
+// NewHeadsetFleetBuilder creates a builder using DefaultFirmwareThresholds
+func NewHeadsetFleetBuilder() *HeadsetFleetBuilder {
+	return &HeadsetFleetBuilder{
+		Thresholds: DefaultFirmwareThresholds,
+		groups:     make(map[headsetGroupKey]*headsetTotals),
+		serials:    make(map[string]*serialTotals),
+	}
+}
+
+// Add accumulates a HeadsetSummary event; other event types are ignored
+func (b *HeadsetFleetBuilder) Add(event interface{}) {
+	e, ok := event.(*events.HeadsetSummaryEvent)
+	if !ok {
+		return
+	}
+	headset := e.Detail.Headset
+	metrics := headset.Metrics
+
+	key := headsetGroupKey{model: headset.ModelName, firmware: headset.FirmwareVersion}
+	group, ok := b.groups[key]
+	if !ok {
+		group = &headsetTotals{serials: make(map[string]bool)}
+		b.groups[key] = group
+	}
+	group.calls++
+	if metrics.MisalignedBoomArmCount > 0 {
+		group.misaligned++
+	}
+	group.exposure.addPositive(metrics.ExposureDB.Avg)
+	group.noise.addPositive(metrics.BackgroundNoiseDB.Avg)
+	if metrics.Speech.TotalSeconds > 0 {
+		group.crossTalkPct.add(metrics.Speech.CrossTalkTotalPct)
+	}
+
+	if headset.SerialNumber == "" {
+		return
+	}
+	group.serials[headset.SerialNumber] = true
+
+	serial, ok := b.serials[headset.SerialNumber]
+	if !ok {
+		serial = &serialTotals{firstSeen: e.Time, lastSeen: e.Time, firmware: make(map[string]time.Time)}
+		b.serials[headset.SerialNumber] = serial
+	}
+	serial.model = headset.ModelName
+	serial.calls++
+	if e.Time.Before(serial.firstSeen) {
+		serial.firstSeen = e.Time
+	}
+	if e.Time.After(serial.lastSeen) {
+		serial.lastSeen = e.Time
+	}
+	if seen, ok := serial.firmware[headset.FirmwareVersion]; !ok || e.Time.Before(seen) {
+		serial.firmware[headset.FirmwareVersion] = e.Time
+	}
+}
+
+// Report returns the fleet report so far. Groups are ordered by model and
+// firmware, serials by serial number, and flags by model, firmware and metric.
+func (b *HeadsetFleetBuilder) Report() *HeadsetFleetReport {
+	report := &HeadsetFleetReport{
+		Groups:  make([]HeadsetGroup, 0, len(b.groups)),
+		Serials: make([]SerialHistory, 0, len(b.serials)),
+		Flags:   []FirmwareFlag{},
+	}
+
+	for key, totals := range b.groups {
+		report.Groups = append(report.Groups, HeadsetGroup{
+			ModelName:                key.model,
+			FirmwareVersion:          key.firmware,
+			Calls:                    totals.calls,
+			Headsets:                 len(totals.serials),
+			AverageExposureDB:        totals.exposure.value(),
+			AverageBackgroundNoiseDB: totals.noise.value(),
+			MisalignmentRate:         ratio(float64(totals.misaligned), float64(totals.calls)),
+			AverageCrossTalkPct:      totals.crossTalkPct.value(),
+		})
+		report.Flags = append(report.Flags, b.flags(key, totals)...)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, c := report.Groups[i], report.Groups[j]
+		if a.ModelName != c.ModelName {
+			return a.ModelName < c.ModelName
+		}
+		return a.FirmwareVersion < c.FirmwareVersion
+	})
+	sort.Slice(report.Flags, func(i, j int) bool {
+		a, c := report.Flags[i], report.Flags[j]
+		if a.ModelName != c.ModelName {
+			return a.ModelName < c.ModelName
+		}
+		if a.FirmwareVersion != c.FirmwareVersion {
+			return a.FirmwareVersion < c.FirmwareVersion
+		}
+		return a.Metric < c.Metric
+	})
+
+	for number, totals := range b.serials {
+		history := SerialHistory{
+			SerialNumber: number,
+			ModelName:    totals.model,
+			Calls:        totals.calls,
+			FirstSeen:    totals.firstSeen,
+			LastSeen:     totals.lastSeen,
+		}
+		for version, seen := range totals.firmware {
+			history.Firmware = append(history.Firmware, FirmwareSighting{Version: version, FirstSeen: seen})
+		}
+		sort.Slice(history.Firmware, func(i, j int) bool {
+			return history.Firmware[i].FirstSeen.Before(history.Firmware[j].FirstSeen)
+		})
+		report.Serials = append(report.Serials, history)
+	}
+	sort.Slice(report.Serials, func(i, j int) bool {
+		return report.Serials[i].SerialNumber < report.Serials[j].SerialNumber
+	})
+
+	return report
+}
+
+// flags compares a firmware group with the other firmware versions of its model
+func (b *HeadsetFleetBuilder) flags(key headsetGroupKey, group *headsetTotals) []FirmwareFlag {
+	var baseline headsetTotals
+	for other, totals := range b.groups {
+		if other.model != key.model || other.firmware == key.firmware {
+			continue
+		}
+		baseline.calls += totals.calls
+		baseline.misaligned += totals.misaligned
+		baseline.exposure.merge(totals.exposure)
+		baseline.noise.merge(totals.noise)
+		baseline.crossTalkPct.merge(totals.crossTalkPct)
+	}
+
+	minCalls := b.Thresholds.MinCalls
+	if group.calls < minCalls || baseline.calls < minCalls || baseline.calls == 0 {
+		return nil
+	}
+
+	// sampled reports whether both sides have enough samples of a metric;
+	// one with none would otherwise be compared as a zero average
+	sampled := func(group, baseline mean) bool {
+		return group.n >= max(minCalls, 1) && baseline.n >= max(minCalls, 1)
+	}
+	comparisons := []struct {
+		metric          string
+		value, baseline float64
+		margin          float64
+		sampled         bool
+	}{
+		{MetricExposureDB, group.exposure.value(), baseline.exposure.value(), b.Thresholds.ExposureDB, sampled(group.exposure, baseline.exposure)},
+		{MetricBackgroundNoiseDB, group.noise.value(), baseline.noise.value(), b.Thresholds.BackgroundNoiseDB, sampled(group.noise, baseline.noise)},
+		{MetricMisalignmentRate, ratio(float64(group.misaligned), float64(group.calls)), ratio(float64(baseline.misaligned), float64(baseline.calls)), b.Thresholds.MisalignmentRate, true},
+		{MetricCrossTalkPct, group.crossTalkPct.value(), baseline.crossTalkPct.value(), b.Thresholds.CrossTalkPct, sampled(group.crossTalkPct, baseline.crossTalkPct)},
+	}
+
+	var flags []FirmwareFlag
+	for _, c := range comparisons {
+		if c.sampled && c.value-c.baseline >= c.margin {
+			flags = append(flags, FirmwareFlag{
+				ModelName:       key.model,
+				FirmwareVersion: key.firmware,
+				Metric:          c.metric,
+				Value:           c.value,
+				Baseline:        c.baseline,
+			})
+		}
+	}
+	return flags
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *HeadsetFleetReport) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
+
+// WriteMarkdown writes the model and firmware groups and any flags as Markdown
+func (r *HeadsetFleetReport) WriteMarkdown(w io.Writer) error {
+	return headsetFleetMarkdown.Execute(w, r)
+}
+
+var headsetFleetMarkdown = template.Must(template.New("fleet").Funcs(templateFuncs).Parse(`# Headset Fleet
+
+| Model | Firmware | Calls | Headsets | Exposure dB | Background noise dB | Boom arm misaligned | Cross-talk |
+|-------|----------|------:|---------:|------------:|--------------------:|--------------------:|-----------:|
+{{range .Groups}}| {{cell .ModelName}} | {{cell .FirmwareVersion}} | {{.Calls}} | {{.Headsets}} | {{fixed .AverageExposureDB}} | {{fixed .AverageBackgroundNoiseDB}} | {{percent .MisalignmentRate}} | {{fixed .AverageCrossTalkPct}}% |
+{{end}}{{if .Flags}}
+## Flagged Firmware
+
+| Model | Firmware | Metric | Value | Rest of model |
+|-------|----------|--------|------:|--------------:|
+{{range .Flags}}| {{cell .ModelName}} | {{cell .FirmwareVersion}} | {{.Metric}} | {{fixed .Value}} | {{fixed .Baseline}} |
+{{end}}{{end}}`))
@@ -0,0 +1,141 @@
+package analytics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// headsetEvent builds a HeadsetSummary event for a headset at an offset from testTime
+func headsetEvent(serial, firmware string, offset time.Duration, exposure float64, misaligned int) *events.HeadsetSummaryEvent {
+	event := &events.HeadsetSummaryEvent{EventBridgeEvent: events.EventBridgeEvent{DetailType: events.EventTypeHeadsetSummary, Time: testTime.Add(offset)}}
+	event.Detail.Headset = events.Headset{ModelName: "Evolve2 65", FirmwareVersion: firmware, SerialNumber: serial}
+	event.Detail.Headset.Metrics.ExposureDB.Avg = exposure
+	event.Detail.Headset.Metrics.BackgroundNoiseDB.Avg = 40
+	event.Detail.Headset.Metrics.MisalignedBoomArmCount = misaligned
+	event.Detail.Headset.Metrics.Speech = events.SpeechMetrics{TotalSeconds: 100, CrossTalkTotalPct: 3}
+	return event
+}
+
+func TestHeadsetFleet(t *testing.T) {
+	builder := NewHeadsetFleetBuilder()
+	builder.Thresholds.MinCalls = 2
+
+	builder.Add(loadFixture(t, "headset_summary.json"))
+	builder.Add(loadFixture(t, "call_summary.json"))
+	builder.Add(headsetEvent("A1", "2.0", 2*time.Hour, 76, 1))
+	builder.Add(headsetEvent("A1", "1.0", time.Hour, 66, 0))
+	builder.Add(headsetEvent("B2", "1.0", time.Hour, 70, 0))
+	builder.Add(headsetEvent("B2", "2.0", 3*time.Hour, 74, 2))
+
+	report := builder.Report()
+
+	if len(report.Groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %+v", report.Groups)
+	}
+	v2 := report.Groups[1]
+	if v2.ModelName != "Evolve2 65" || v2.FirmwareVersion != "2.0" || v2.Calls != 2 || v2.Headsets != 2 {
+		t.Errorf("Unexpected group: %+v", v2)
+	}
+	if v2.AverageExposureDB != 75 || v2.MisalignmentRate != 1 || v2.AverageCrossTalkPct != 3 {
+		t.Errorf("Unexpected group metrics: %+v", v2)
+	}
+
+	fixture := report.Groups[2]
+	if fixture.ModelName != "Jabra Evolve2 65" || fixture.AverageExposureDB != 68.4 || fixture.MisalignmentRate != 1 {
+		t.Errorf("Unexpected fixture group: %+v", fixture)
+	}
+
+	if len(report.Serials) != 3 {
+		t.Fatalf("Expected 3 serials, got %+v", report.Serials)
+	}
+	a1 := report.Serials[1]
+	if a1.SerialNumber != "A1" || a1.Calls != 2 || !a1.FirstSeen.Equal(testTime.Add(time.Hour)) || !a1.LastSeen.Equal(testTime.Add(2*time.Hour)) {
+		t.Errorf("Unexpected serial history: %+v", a1)
+	}
+	if len(a1.Firmware) != 2 || a1.Firmware[0].Version != "1.0" || a1.Firmware[1].Version != "2.0" {
+		t.Errorf("Expected firmware upgrade 1.0 -> 2.0, got %+v", a1.Firmware)
+	}
+
+	expected := []struct {
+		firmware string
+		metric   string
+	}{
+		{"2.0", MetricExposureDB},
+		{"2.0", MetricMisalignmentRate},
+	}
+	if len(report.Flags) != len(expected) {
+		t.Fatalf("Expected %d flags, got %+v", len(expected), report.Flags)
+	}
+	for i, flag := range expected {
+		if report.Flags[i].FirmwareVersion != flag.firmware || report.Flags[i].Metric != flag.metric {
+			t.Errorf("Flag %d = %+v, expected %s %s", i, report.Flags[i], flag.firmware, flag.metric)
+		}
+	}
+	if report.Flags[0].Value != 75 || report.Flags[0].Baseline != 68 {
+		t.Errorf("Unexpected exposure flag: %+v", report.Flags[0])
+	}
+}
+
+func TestHeadsetFleetMinCalls(t *testing.T) {
+	builder := NewHeadsetFleetBuilder()
+	builder.Add(headsetEvent("A1", "1.0", 0, 60, 0))
+	builder.Add(headsetEvent("A1", "2.0", time.Hour, 90, 5))
+
+	report := builder.Report()
+	if len(report.Flags) != 0 {
+		t.Errorf("Expected no flags below MinCalls, got %+v", report.Flags)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteMarkdown(&buf); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "| Evolve2 65 | 2.0 | 1 | 1 | 90.00 | 40.00 | 100.0% | 3.00% |") || strings.Contains(buf.String(), "Flagged") {
+		t.Errorf("Unexpected Markdown:\n%s", buf.String())
+	}
+}
+
+func TestHeadsetFleetUnreportedBaseline(t *testing.T) {
+	builder := NewHeadsetFleetBuilder()
+	builder.Thresholds.MinCalls = 2
+	for i := 0; i < 2; i++ {
+		// Firmware 1.0 reports no exposure, noise or speech metrics
+		old := headsetEvent("A1", "1.0", time.Duration(i)*time.Hour, 0, 0)
+		old.Detail.Headset.Metrics.BackgroundNoiseDB.Avg = 0
+		old.Detail.Headset.Metrics.Speech = events.SpeechMetrics{}
+		builder.Add(old)
+		builder.Add(headsetEvent("B2", "2.0", time.Duration(i)*time.Hour, 70, 0))
+	}
+
+	if flags := builder.Report().Flags; len(flags) != 0 {
+		t.Errorf("Expected no flags against an unreported baseline, got %+v", flags)
+	}
+}
+
+func TestHeadsetFleetSparseMetric(t *testing.T) {
+	builder := NewHeadsetFleetBuilder()
+	builder.Thresholds.MinCalls = 3
+	for i := 0; i < 3; i++ {
+		offset := time.Duration(i) * time.Hour
+		// Only one call on each firmware reports exposure
+		exposure := 0.0
+		if i == 0 {
+			exposure = 60
+		}
+		builder.Add(headsetEvent("A1", "1.0", offset, exposure, 0))
+		if i == 0 {
+			exposure = 80
+		}
+		builder.Add(headsetEvent("B2", "2.0", offset, exposure, 0))
+	}
+
+	for _, flag := range builder.Report().Flags {
+		if flag.Metric == MetricExposureDB {
+			t.Errorf("Expected no exposure flag from a single sample each, got %+v", flag)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// IntervalReport holds per-window aggregates, such as 15-minute WFM intervals
+type IntervalReport struct {
+	Windows         WindowSpec `json:"windows"`
+	AllowedLateness Duration   `json:"allowedLateness"`
+	// Intervals are in start order, with empty windows between the first and last filled in
+	Intervals []Interval `json:"intervals"`
+	// LateEvents counts events dropped because every window they fall in had closed
+	LateEvents int `json:"lateEvents"`
+}
+
+// Interval aggregates the events in one window
+type Interval struct {
+	Window
+	Events   int `json:"events"`
+	Calls    int `json:"calls"`
+	Inbound  int `json:"inbound"`
+	Outbound int `json:"outbound"`
+	Answered int `json:"answered"`
+	// Abandoned counts queued calls that never connected to an agent
+	Abandoned int `json:"abandoned"`
+	// AverageHandleTimeSec is the mean interaction time of answered calls
+	AverageHandleTimeSec float64 `json:"averageHandleTimeSec"`
+	// AverageQueueWaitSec is the mean queue wait of answered calls
+	AverageQueueWaitSec float64 `json:"averageQueueWaitSec"`
+	AverageMOS          float64 `json:"averageMos"`
+	// PoorCalls counts calls with Poor or Bad quality
+	PoorCalls int `json:"poorCalls"`
+	// AverageLossPercent is the mean packet loss of the worse direction
+	AverageLossPercent float64 `json:"averageLossPercent"`
+	// Issues counts AgentReportedIssue events
+	Issues int `json:"issues"`
+	// CallsWithInsights counts InsightsSummary events with at least one insight
+	CallsWithInsights int `json:"callsWithInsights"`
+}
+
+// IntervalBuilder assigns events to windows and aggregates each window.
+//
+// Events may arrive out of order. The builder tracks a watermark, the
+// latest event time seen less AllowedLateness, and closes each window once
+// the watermark passes its end. Events for closed windows are dropped and
+// counted as late. Add returns the windows each event closes, so the
+// builder can feed a live interval report; Report closes the rest and
+// advances the watermark past them, so later events for those windows are
+// counted as late rather than reopening them.
+type IntervalBuilder struct {
+	Windows         WindowSpec
+	AllowedLateness time.Duration
+	// Time returns an event's time; EventTime when nil. Events without a time are ignored.
+	Time func(event interface{}) time.Time
+	// OnLate is called with each event dropped as late, when set
+	OnLate func(event interface{})
+
+	open      map[time.Time]*intervalTotals
+	closed    []Interval
+	latest    time.Time
+	watermark time.Time
+	late      int
+}
+
+type intervalTotals struct {
+	window    Window
+	events    int
+	calls     int
+	inbound   int
+	outbound  int
+	answered  int
+	abandoned int
+	poor      int
+	handle    mean
+	wait      mean
+	mos       mean
+	loss      mean
+	issues    int
+	insights  int
+}
+
+// NewIntervalBuilder creates a builder for windows with no allowed lateness
+func NewIntervalBuilder(windows WindowSpec) *IntervalBuilder {
+	return &IntervalBuilder{Windows: windows, open: make(map[time.Time]*intervalTotals)}
+}
+
+// Add assigns an event to its open windows and returns any windows closed
+// by the watermark it advances, in start order
+func (b *IntervalBuilder) Add(event interface{}) []Interval {
+	timeOf := b.Time
+	if timeOf == nil {
+		timeOf = EventTime
+	}
+	at := timeOf(event)
+	if at.IsZero() {
+		return nil
+	}
+
+	added := false
+	for _, window := range b.Windows.Windows(at) {
+		if !b.watermark.IsZero() && !window.End.After(b.watermark) {
+			continue
+		}
+		totals, ok := b.open[window.Start]
+		if !ok {
+			totals = &intervalTotals{window: window}
+			b.open[window.Start] = totals
+		}
+		totals.add(event)
+		added = true
+	}
+	if !added {
+		b.late++
+		if b.OnLate != nil {
+			b.OnLate(event)
+		}
+	}
+
+	if at.After(b.latest) {
+		b.latest = at
+		if watermark := at.Add(-b.AllowedLateness); watermark.After(b.watermark) {
+			b.watermark = watermark
+		}
+	}
+	return b.close(func(w Window) bool { return !w.End.After(b.watermark) })
+}
+
+// Watermark returns the time before which windows are closed
+func (b *IntervalBuilder) Watermark() time.Time {
+	return b.watermark
+}
+
+// close removes and returns the open windows matching done, in start order
+func (b *IntervalBuilder) close(done func(Window) bool) []Interval {
+	var intervals []Interval
+	for start, totals := range b.open {
+		if done(totals.window) {
+			intervals = append(intervals, totals.interval())
+			delete(b.open, start)
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start.Before(intervals[j].Start)
+	})
+	b.closed = append(b.closed, intervals...)
+	return intervals
+}
+
+// Report closes every open window, advancing the watermark to the end of
+// the last, and returns all the intervals so far
+func (b *IntervalBuilder) Report() *IntervalReport {
+	for _, interval := range b.close(func(Window) bool { return true }) {
+		if interval.End.After(b.watermark) {
+			b.watermark = interval.End
+		}
+	}
+	sort.Slice(b.closed, func(i, j int) bool {
+		return b.closed[i].Start.Before(b.closed[j].Start)
+	})
+
+	report := &IntervalReport{
+		Windows:         b.Windows,
+		AllowedLateness: Duration(b.AllowedLateness),
+		Intervals:       []Interval{},
+		LateEvents:      b.late,
+	}
+	step := b.Windows.step()
+	for _, interval := range b.closed {
+		if n := len(report.Intervals); n > 0 {
+			for start := report.Intervals[n-1].Start.Add(step); start.Before(interval.Start); start = start.Add(step) {
+				report.Intervals = append(report.Intervals, Interval{Window: Window{Start: start, End: start.Add(time.Duration(b.Windows.Size))}})
+			}
+		}
+		report.Intervals = append(report.Intervals, interval)
+	}
+	return report
+}
+
+func (t *intervalTotals) add(event interface{}) {
+	t.events++
+	switch e := event.(type) {
+	case *events.CallSummaryEvent:
+		detail := &e.Detail
+		t.calls++
+		switch detail.Contact.Direction {
+		case "Inbound":
+			t.inbound++
+		case "Outbound":
+			t.outbound++
+		}
+
+		if detail.WasAnswered() {
+			t.answered++
+			if duration := detail.Duration(); duration > 0 {
+				t.handle.add(duration.Seconds())
+			}
+			if wait, ok := detail.QueueWait(); ok {
+				t.wait.add(wait.Seconds())
+			}
+		} else if detail.WasQueued() {
+			t.abandoned++
+		}
+
+		if mos := detail.WebRTCSession.Metrics.MOS.Avg; mos > 0 {
+			t.mos.add(mos)
+			switch detail.QualityLevel() {
+			case events.QualityPoor, events.QualityBad:
+				t.poor++
+			}
+		}
+		t.loss.add(detail.WebRTCSession.Metrics.WorstPacketLossPercentage())
+
+	case *events.AgentReportedIssueEvent:
+		t.issues++
+
+	case *events.InsightsSummaryEvent:
+		if e.Detail.Insights.Count > 0 || len(e.Detail.Insights.Tags) > 0 {
+			t.insights++
+		}
+	}
+}
+
+func (t *intervalTotals) interval() Interval {
+	return Interval{
+		Window:               t.window,
+		Events:               t.events,
+		Calls:                t.calls,
+		Inbound:              t.inbound,
+		Outbound:             t.outbound,
+		Answered:             t.answered,
+		Abandoned:            t.abandoned,
+		AverageHandleTimeSec: t.handle.value(),
+		AverageQueueWaitSec:  t.wait.value(),
+		AverageMOS:           t.mos.value(),
+		PoorCalls:            t.poor,
+		AverageLossPercent:   t.loss.value(),
+		Issues:               t.issues,
+		CallsWithInsights:    t.insights,
+	}
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *IntervalReport) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
+
+// WriteCSV writes one row per interval with a header row, for import into WFM tools
+func (r *IntervalReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{
+		"start", "end", "events", "calls", "inbound", "outbound", "answered", "abandoned",
+		"averageHandleTimeSec", "averageQueueWaitSec", "averageMos", "poorCalls",
+		"averageLossPercent", "issues", "callsWithInsights",
+	})
+	for _, i := range r.Intervals {
+		_ = writer.Write([]string{
+			i.Start.Format(time.RFC3339), i.End.Format(time.RFC3339),
+			strconv.Itoa(i.Events), strconv.Itoa(i.Calls), strconv.Itoa(i.Inbound), strconv.Itoa(i.Outbound),
+			strconv.Itoa(i.Answered), strconv.Itoa(i.Abandoned),
+			formatFloat(i.AverageHandleTimeSec), formatFloat(i.AverageQueueWaitSec), formatFloat(i.AverageMOS),
+			strconv.Itoa(i.PoorCalls), formatFloat(i.AverageLossPercent),
+			strconv.Itoa(i.Issues), strconv.Itoa(i.CallsWithInsights),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
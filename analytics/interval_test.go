@@ -0,0 +1,245 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// intervalCall builds a CallSummary event that ended offset after testTime.
+// Answered calls waited 10 seconds in queue and lasted a minute.
+func intervalCall(offset time.Duration, direction string, answered bool, mos float64) *events.CallSummaryEvent {
+	end := testTime.Add(offset)
+	event := &events.CallSummaryEvent{EventBridgeEvent: events.EventBridgeEvent{DetailType: events.EventTypeCallSummary, Time: end.Add(time.Second)}}
+	event.Detail.Timestamp = end
+	event.Detail.Contact.Direction = direction
+	event.Detail.Contact.Events.Enqueued = end.Add(-70 * time.Second)
+	if answered {
+		event.Detail.Contact.Events.ConnectingToAgent = end.Add(-time.Minute)
+		event.Detail.ServiceAgent.Interaction.TotalDurationSec = 60
+	}
+	event.Detail.WebRTCSession.Metrics.MOS.Avg = mos
+	event.Detail.WebRTCSession.Metrics.Outbound.PacketsLostPercentage = 1
+	return event
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2023, 6, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func TestWindowSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     WindowSpec
+		time     time.Time
+		expected []time.Time
+	}{
+		{"tumbling", Tumbling(15 * time.Minute), at(5, 7), []time.Time{at(5, 0)}},
+		{"tumbling boundary", Tumbling(15 * time.Minute), at(5, 15), []time.Time{at(5, 15)}},
+		{"sliding", Sliding(15*time.Minute, 5*time.Minute), at(5, 7), []time.Time{at(4, 55), at(5, 0), at(5, 5)}},
+		{"hopping gap", Sliding(5*time.Minute, 15*time.Minute), at(5, 7), nil},
+		{"hopping", Sliding(5*time.Minute, 15*time.Minute), at(5, 3), []time.Time{at(5, 0)}},
+		{"no size", WindowSpec{}, at(5, 7), nil},
+		{"no time", Tumbling(time.Minute), time.Time{}, nil},
+	}
+
+	for _, test := range tests {
+		windows := test.spec.Windows(test.time)
+		if len(windows) != len(test.expected) {
+			t.Errorf("%s: expected %d windows, got %+v", test.name, len(test.expected), windows)
+			continue
+		}
+		for i, window := range windows {
+			if !window.Start.Equal(test.expected[i]) || window.End.Sub(window.Start) != time.Duration(test.spec.Size) || !window.Contains(test.time) {
+				t.Errorf("%s: unexpected window %d: %+v", test.name, i, window)
+			}
+		}
+	}
+
+	// Windows are aligned to UTC whatever the zone of the time
+	sydney := time.FixedZone("AEST", 10*60*60)
+	if windows := Tumbling(15 * time.Minute).Windows(at(5, 7).In(sydney)); !windows[0].Start.Equal(at(5, 0)) {
+		t.Errorf("Expected window at 05:00 UTC, got %v", windows[0].Start)
+	}
+}
+
+func TestEventTime(t *testing.T) {
+	call := loadFixture(t, "call_summary.json")
+	if eventTime := EventTime(call); !eventTime.Equal(time.Date(2023, 6, 1, 5, 0, 11, 871000000, time.UTC)) {
+		t.Errorf("Expected the call's detail timestamp, got %v", eventTime)
+	}
+	if headerTime := HeaderTime(call); !headerTime.Equal(time.Date(2023, 6, 1, 5, 0, 13, 0, time.UTC)) {
+		t.Errorf("Expected the call's EventBridge time, got %v", headerTime)
+	}
+	if eventTime := EventTime(loadFixture(t, "headset_summary.json")); !eventTime.Equal(time.Date(2023, 6, 1, 5, 0, 20, 0, time.UTC)) {
+		t.Errorf("Expected the headset event's EventBridge time, got %v", eventTime)
+	}
+	if eventTime := EventTime("not an event"); !eventTime.IsZero() {
+		t.Errorf("Expected zero time, got %v", eventTime)
+	}
+}
+
+func TestIntervalBuilder(t *testing.T) {
+	builder := NewIntervalBuilder(Tumbling(15 * time.Minute))
+	builder.AllowedLateness = 5 * time.Minute
+	var late []interface{}
+	builder.OnLate = func(event interface{}) {
+		late = append(late, event)
+	}
+
+	issue := issueEvent("andy", "Audio")
+	issue.Time = testTime.Add(10 * time.Minute)
+
+	steps := []struct {
+		event  interface{}
+		closed []time.Time
+	}{
+		{intervalCall(time.Minute, "Inbound", true, 4.2), nil},
+		{issue, nil},
+		{intervalCall(2*time.Minute, "Outbound", false, 3.0), nil},
+		// Advances the watermark to 05:17, closing 05:00
+		{intervalCall(22*time.Minute, "Inbound", true, 4.0), []time.Time{at(5, 0)}},
+		// Its window has closed
+		{intervalCall(12*time.Minute, "Inbound", true, 4.0), nil},
+		// Late, but within the allowed lateness
+		{intervalCall(16*time.Minute, "Inbound", true, 3.5), nil},
+		{&events.EventBridgeEvent{}, nil},
+		{intervalCall(65*time.Minute, "Inbound", true, 4.4), []time.Time{at(5, 15)}},
+	}
+
+	var first Interval
+	for i, step := range steps {
+		closed := builder.Add(step.event)
+		if len(closed) != len(step.closed) {
+			t.Fatalf("Step %d: expected %d closed windows, got %+v", i, len(step.closed), closed)
+		}
+		for j, interval := range closed {
+			if !interval.Start.Equal(step.closed[j]) {
+				t.Errorf("Step %d: expected window at %v, got %v", i, step.closed[j], interval.Start)
+			}
+		}
+		if i == 3 {
+			first = closed[0]
+		}
+	}
+
+	if first.Events != 3 || first.Calls != 2 || first.Inbound != 1 || first.Outbound != 1 || first.Answered != 1 || first.Abandoned != 1 || first.Issues != 1 {
+		t.Errorf("Unexpected first interval: %+v", first)
+	}
+	if first.AverageHandleTimeSec != 60 || first.AverageQueueWaitSec != 10 || first.AverageMOS != 3.6 || first.PoorCalls != 1 || first.AverageLossPercent != 1 {
+		t.Errorf("Unexpected first interval metrics: %+v", first)
+	}
+	if len(late) != 1 {
+		t.Errorf("Expected 1 late event, got %d", len(late))
+	}
+	if watermark := builder.Watermark(); !watermark.Equal(at(6, 0)) {
+		t.Errorf("Expected watermark 06:00, got %v", watermark)
+	}
+
+	report := builder.Report()
+	if report.LateEvents != 1 {
+		t.Errorf("Expected 1 late event, got %d", report.LateEvents)
+	}
+	starts := make([]string, len(report.Intervals))
+	for i, interval := range report.Intervals {
+		starts[i] = interval.Start.Format("15:04")
+	}
+	if strings.Join(starts, ",") != "05:00,05:15,05:30,05:45,06:00" {
+		t.Fatalf("Unexpected intervals: %v", starts)
+	}
+	if second := report.Intervals[1]; second.Calls != 2 || second.AverageMOS != 3.75 {
+		t.Errorf("Unexpected second interval: %+v", second)
+	}
+	if empty := report.Intervals[2]; empty.Events != 0 || !empty.End.Equal(at(5, 45)) {
+		t.Errorf("Expected an empty filler interval, got %+v", empty)
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteCSV(&out); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	rows := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(rows) != 6 {
+		t.Fatalf("Expected 6 CSV rows, got %d", len(rows))
+	}
+	if rows[1] != "2023-06-01T05:00:00Z,2023-06-01T05:15:00Z,3,2,1,1,1,1,60.00,10.00,3.60,1,1.00,1,0" {
+		t.Errorf("Unexpected CSV row: %s", rows[1])
+	}
+}
+
+func TestSlidingIntervals(t *testing.T) {
+	builder := NewIntervalBuilder(Sliding(30*time.Minute, 15*time.Minute))
+	builder.Add(intervalCall(20*time.Minute, "Inbound", true, 4.0))
+	insights := loadFixture(t, "insights_summary.json")
+	builder.Add(insights)
+
+	report := builder.Report()
+	if len(report.Intervals) != 2 {
+		t.Fatalf("Expected 2 overlapping intervals, got %+v", report.Intervals)
+	}
+	if report.Intervals[0].Calls != 1 || report.Intervals[1].Calls != 1 {
+		t.Errorf("Expected the call in both windows, got %+v", report.Intervals)
+	}
+	// The insights at 05:00 arrive after 05:15, so only their later window is open
+	if report.Intervals[0].CallsWithInsights != 1 || report.LateEvents != 0 {
+		t.Errorf("Expected the insights in the 05:00 window only, got %+v", report)
+	}
+}
+
+func TestIntervalReportClosesWindows(t *testing.T) {
+	builder := NewIntervalBuilder(Tumbling(15 * time.Minute))
+	builder.AllowedLateness = 30 * time.Minute
+	builder.Add(intervalCall(time.Minute, "Inbound", true, 4.2))
+	builder.Add(intervalCall(20*time.Minute, "Inbound", true, 4.0))
+
+	if report := builder.Report(); len(report.Intervals) != 2 {
+		t.Fatalf("Expected 2 intervals, got %+v", report.Intervals)
+	}
+	if !builder.Watermark().Equal(at(5, 30)) {
+		t.Errorf("Expected Report to advance the watermark to 05:30, got %v", builder.Watermark())
+	}
+
+	// Both windows were reported, so these are late rather than reopening them
+	if closed := builder.Add(intervalCall(2*time.Minute, "Inbound", true, 4.0)); closed != nil {
+		t.Errorf("Expected no windows closed, got %+v", closed)
+	}
+	builder.Add(intervalCall(25*time.Minute, "Inbound", true, 4.0))
+
+	report := builder.Report()
+	if len(report.Intervals) != 2 || report.LateEvents != 2 {
+		t.Errorf("Expected the same 2 intervals and 2 late events, got %+v", report)
+	}
+	if report.Intervals[0].Calls != 1 || report.Intervals[1].Calls != 1 {
+		t.Errorf("Expected the late calls dropped, got %+v", report.Intervals)
+	}
+}
+
+func TestIntervalReportDurationJSON(t *testing.T) {
+	builder := NewIntervalBuilder(Sliding(15*time.Minute, 5*time.Minute))
+	builder.AllowedLateness = 2 * time.Minute
+
+	data, err := json.Marshal(builder.Report())
+	if err != nil {
+		t.Fatalf("Failed to encode report: %v", err)
+	}
+	for _, expected := range []string{`"size":"15m0s"`, `"slide":"5m0s"`, `"allowedLateness":"2m0s"`} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected %s in %s", expected, data)
+		}
+	}
+
+	var spec WindowSpec
+	if err := json.Unmarshal([]byte(`{"size": "15m", "slide": 300}`), &spec); err != nil {
+		t.Fatalf("Failed to decode window spec: %v", err)
+	}
+	if spec != Sliding(15*time.Minute, 5*time.Minute) {
+		t.Errorf("Expected 15m windows every 5m, got %+v", spec)
+	}
+	if err := json.Unmarshal([]byte(`{"size": "soon"}`), &spec); err == nil {
+		t.Error("Expected an invalid duration to fail")
+	}
+}
@@ -0,0 +1,347 @@
+package analytics
+
+import (
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tommyorndorff/operata-events/events"
+	"github.com/tommyorndorff/operata-events/phone"
+)
+
+// unknownValue labels calls with no value for a dimension
+const unknownValue = "unknown"
+
+// awsRegion matches an AWS region label in a media endpoint FQDN
+var awsRegion = regexp.MustCompile(`^[a-z]{2}(-gov)?-[a-z]+-\d$`)
+
+// NetworkThresholds tune the comparisons in a NetworkReport
+type NetworkThresholds struct {
+	// MinCalls is the number of calls an ISP needs before it is compared with the fleet
+	MinCalls int `json:"minCalls"`
+	// ZScore is the two-proportion z-score at which an ISP's poor-call rate
+	// counts as significantly worse than the rest of the fleet
+	ZScore float64 `json:"zScore"`
+	// WiFiLossPercent is the average packet loss at which a Wi-Fi agent is reported
+	WiFiLossPercent float64 `json:"wifiLossPercent"`
+	// MinWiFiCalls is the number of Wi-Fi calls an agent needs before it is reported
+	MinWiFiCalls int `json:"minWifiCalls"`
+}
+
+// DefaultNetworkThresholds use a one-sided 95% confidence level and flag Wi-Fi
+// agents averaging noticeable packet loss over at least five calls
+var DefaultNetworkThresholds = NetworkThresholds{MinCalls: 30, ZScore: 1.645, WiFiLossPercent: 1.0, MinWiFiCalls: 5}
+
+// NetworkReport rolls call quality up by network path
+type NetworkReport struct {
+	Thresholds       NetworkThresholds `json:"thresholds"`
+	Fleet            NetworkQuality    `json:"fleet"`
+	ByISP            []NetworkQuality  `json:"byIsp"`
+	ByCity           []NetworkQuality  `json:"byCity"`
+	ByCountry        []NetworkQuality  `json:"byCountry"`
+	ByConnectionType []NetworkQuality  `json:"byConnectionType"`
+	ByMediaRegion    []NetworkQuality  `json:"byMediaRegion"`
+	ByTransport      []NetworkQuality  `json:"byTransport"`
+	// ByCallerCountry and ByCallerLineType roll up by the customer's number
+	ByCallerCountry  []NetworkQuality `json:"byCallerCountry"`
+	ByCallerLineType []NetworkQuality `json:"byCallerLineType"`
+	WorseISPs        []ISPComparison  `json:"worseIsps"`
+	WiFiAgents       []WiFiAgent      `json:"wifiAgents"`
+}
+
+// NetworkQuality summarises the calls sharing one value of a dimension
+type NetworkQuality struct {
+	Key                string  `json:"key"`
+	Calls              int     `json:"calls"`
+	AverageMOS         float64 `json:"averageMos"`
+	AverageLossPercent float64 `json:"averageLossPercent"`
+	AverageRTT         float64 `json:"averageRtt"`
+	AverageJitter      float64 `json:"averageJitter"`
+	// PoorCallRate is the fraction of calls with Poor or Bad quality
+	PoorCallRate float64 `json:"poorCallRate"`
+}
+
+// ISPComparison reports an ISP whose poor-call rate is significantly above the rest of the fleet
+type ISPComparison struct {
+	ISP              string  `json:"isp"`
+	Calls            int     `json:"calls"`
+	PoorCallRate     float64 `json:"poorCallRate"`
+	RestPoorCallRate float64 `json:"restPoorCallRate"`
+	ZScore           float64 `json:"zScore"`
+}
+
+// WiFiAgent reports an agent on Wi-Fi with high average packet loss
+type WiFiAgent struct {
+	Agent              string  `json:"agent"`
+	ISP                string  `json:"isp"`
+	Calls              int     `json:"calls"`
+	AverageLossPercent float64 `json:"averageLossPercent"`
+}
+
+// NetworkBuilder accumulates CallSummary events into a NetworkReport
+type NetworkBuilder struct {
+	Thresholds NetworkThresholds
+
+	fleet      networkTotals
+	dimensions map[string]map[string]*networkTotals
+	wifi       map[string]*wifiTotals
+}
+
+type networkTotals struct {
+	calls  int
+	poor   int
+	mos    mean
+	loss   mean
+	rtt    mean
+	jitter mean
+}
+
+type wifiTotals struct {
+	isp  string
+	loss mean
+}
+
+// Dimension names used by NetworkBuilder
+const (
+	dimensionISP            = "isp"
+	dimensionCity           = "city"
+	dimensionCountry        = "country"
+	dimensionConnectionType = "connectionType"
+	dimensionMediaRegion    = "mediaRegion"
+	dimensionTransport      = "transport"
+	dimensionCallerCountry  = "callerCountry"
+	dimensionCallerLineType = "callerLineType"
+)
+
+// NewNetworkBuilder creates a builder using DefaultNetworkThresholds
+func NewNetworkBuilder() *NetworkBuilder {
+	return &NetworkBuilder{
+		Thresholds: DefaultNetworkThresholds,
+		dimensions: make(map[string]map[string]*networkTotals),
+		wifi:       make(map[string]*wifiTotals),
+	}
+}
+
+// Add accumulates a CallSummary event; other event types are ignored
+func (b *NetworkBuilder) Add(event interface{}) {
+	e, ok := event.(*events.CallSummaryEvent)
+	if !ok {
+		return
+	}
+	network := e.Detail.ServiceAgent.Network
+	session := e.Detail.WebRTCSession
+	geo := network.Geolocation
+
+	city := unknownValue
+	if geo.City != "" {
+		city = strings.Join(nonEmpty(geo.City, geo.Region, geo.Country), ", ")
+	}
+
+	keys := map[string]string{
+		dimensionISP:            orUnknown(network.ISP),
+		dimensionCity:           city,
+		dimensionCountry:        orUnknown(geo.Country),
+		dimensionConnectionType: orUnknown(network.Type),
+		dimensionMediaRegion:    orUnknown(MediaRegion(session.MediaEndpoint.FQDN)),
+		dimensionTransport:      orUnknown(session.MediaEndpoint.Transport),
+		dimensionCallerCountry:  unknownValue,
+		dimensionCallerLineType: unknownValue,
+	}
+	if number, err := phone.Parse(e.Detail.Contact.CallerID); err == nil {
+		keys[dimensionCallerCountry] = orUnknown(number.Country)
+		keys[dimensionCallerLineType] = number.Type
+	}
+
+	metrics := session.Metrics
+	loss := math.Max(metrics.Inbound.PacketsLostPercentage, metrics.Outbound.PacketsLostPercentage)
+
+	b.fleet.add(metrics, loss)
+	for dimension, key := range keys {
+		values, ok := b.dimensions[dimension]
+		if !ok {
+			values = make(map[string]*networkTotals)
+			b.dimensions[dimension] = values
+		}
+		totals, ok := values[key]
+		if !ok {
+			totals = &networkTotals{}
+			values[key] = totals
+		}
+		totals.add(metrics, loss)
+	}
+
+	if isWiFi(network.Type) {
+		agent := e.Detail.ServiceAgent.Username
+		if agent == "" {
+			agent = UnknownAgent
+		}
+		totals, ok := b.wifi[agent]
+		if !ok {
+			totals = &wifiTotals{}
+			b.wifi[agent] = totals
+		}
+		totals.isp = network.ISP
+		totals.loss.add(loss)
+	}
+}
+
+func (t *networkTotals) add(metrics events.WebRTCMetrics, loss float64) {
+	t.calls++
+	t.loss.add(loss)
+	t.rtt.addPositive(float64(metrics.RTT.Avg))
+	t.jitter.addPositive(float64(metrics.Jitter.Avg))
+	if metrics.MOS.Avg > 0 {
+		t.mos.add(metrics.MOS.Avg)
+		switch events.GetCallQualityLevel(metrics.MOS.Avg) {
+		case events.QualityPoor, events.QualityBad:
+			t.poor++
+		}
+	}
+}
+
+func (t *networkTotals) quality(key string) NetworkQuality {
+	return NetworkQuality{
+		Key:                key,
+		Calls:              t.calls,
+		AverageMOS:         t.mos.value(),
+		AverageLossPercent: t.loss.value(),
+		AverageRTT:         t.rtt.value(),
+		AverageJitter:      t.jitter.value(),
+		PoorCallRate:       ratio(float64(t.poor), float64(t.mos.n)),
+	}
+}
+
+// Report returns the rollups so far. Each rollup is ordered by call count,
+// busiest first; worse ISPs by z-score and Wi-Fi agents by loss, worst first.
+func (b *NetworkBuilder) Report() *NetworkReport {
+	report := &NetworkReport{
+		Thresholds:       b.Thresholds,
+		Fleet:            b.fleet.quality("fleet"),
+		ByISP:            b.rollup(dimensionISP),
+		ByCity:           b.rollup(dimensionCity),
+		ByCountry:        b.rollup(dimensionCountry),
+		ByConnectionType: b.rollup(dimensionConnectionType),
+		ByMediaRegion:    b.rollup(dimensionMediaRegion),
+		ByTransport:      b.rollup(dimensionTransport),
+		ByCallerCountry:  b.rollup(dimensionCallerCountry),
+		ByCallerLineType: b.rollup(dimensionCallerLineType),
+		WorseISPs:        b.worseISPs(),
+		WiFiAgents:       []WiFiAgent{},
+	}
+
+	for agent, totals := range b.wifi {
+		if totals.loss.n >= b.Thresholds.MinWiFiCalls && totals.loss.value() >= b.Thresholds.WiFiLossPercent {
+			report.WiFiAgents = append(report.WiFiAgents, WiFiAgent{
+				Agent:              agent,
+				ISP:                totals.isp,
+				Calls:              totals.loss.n,
+				AverageLossPercent: totals.loss.value(),
+			})
+		}
+	}
+	sort.Slice(report.WiFiAgents, func(i, j int) bool {
+		a, c := report.WiFiAgents[i], report.WiFiAgents[j]
+		if a.AverageLossPercent != c.AverageLossPercent {
+			return a.AverageLossPercent > c.AverageLossPercent
+		}
+		return a.Agent < c.Agent
+	})
+
+	return report
+}
+
+func (b *NetworkBuilder) rollup(dimension string) []NetworkQuality {
+	values := b.dimensions[dimension]
+	rollup := make([]NetworkQuality, 0, len(values))
+	for key, totals := range values {
+		rollup = append(rollup, totals.quality(key))
+	}
+	sort.Slice(rollup, func(i, j int) bool {
+		if rollup[i].Calls != rollup[j].Calls {
+			return rollup[i].Calls > rollup[j].Calls
+		}
+		return rollup[i].Key < rollup[j].Key
+	})
+	return rollup
+}
+
+// worseISPs compares each ISP's poor-call rate with the rest of the fleet
+// using a two-proportion z-test
+func (b *NetworkBuilder) worseISPs() []ISPComparison {
+	worse := []ISPComparison{}
+	for isp, totals := range b.dimensions[dimensionISP] {
+		n1 := float64(totals.mos.n)
+		n2 := float64(b.fleet.mos.n) - n1
+		if totals.mos.n < b.Thresholds.MinCalls || n2 < float64(b.Thresholds.MinCalls) {
+			continue
+		}
+
+		x1 := float64(totals.poor)
+		x2 := float64(b.fleet.poor) - x1
+		p1, p2 := x1/n1, x2/n2
+		pooled := (x1 + x2) / (n1 + n2)
+		se := math.Sqrt(pooled * (1 - pooled) * (1/n1 + 1/n2))
+		if se == 0 {
+			continue
+		}
+
+		if z := (p1 - p2) / se; z >= b.Thresholds.ZScore {
+			worse = append(worse, ISPComparison{
+				ISP:              isp,
+				Calls:            totals.calls,
+				PoorCallRate:     p1,
+				RestPoorCallRate: p2,
+				ZScore:           z,
+			})
+		}
+	}
+	sort.Slice(worse, func(i, j int) bool {
+		return worse[i].ZScore > worse[j].ZScore
+	})
+	return worse
+}
+
+// MediaRegion returns the AWS region in a media endpoint FQDN such as
+// "turnnlb-93f2de0c97c4316b.elb.ap-southeast-2.amazonaws.com.", or "" if there is none
+func MediaRegion(fqdn string) string {
+	for _, label := range strings.Split(strings.TrimSuffix(fqdn, "."), ".") {
+		if awsRegion.MatchString(label) {
+			return label
+		}
+	}
+	return ""
+}
+
+// isWiFi reports whether a connection type is wireless
+func isWiFi(connectionType string) bool {
+	switch strings.ToLower(connectionType) {
+	case "wlan", "wifi", "wi-fi", "wireless":
+		return true
+	default:
+		return false
+	}
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return unknownValue
+	}
+	return value
+}
+
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, value := range values {
+		if value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *NetworkReport) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
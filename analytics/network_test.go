@@ -0,0 +1,133 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// networkCall builds a CallSummary event for agent on the given network
+func networkCall(agent, isp, connectionType string, mos, loss float64) *events.CallSummaryEvent {
+	event := callEvent(agent, mos, loss, events.Interaction{}, 0, 0)
+	event.Detail.ServiceAgent.Network.ISP = isp
+	event.Detail.ServiceAgent.Network.Type = connectionType
+	event.Detail.ServiceAgent.Network.Geolocation = events.Geolocation{City: "Sydney", Region: "NSW", Country: "Australia"}
+	event.Detail.WebRTCSession.MediaEndpoint = events.MediaEndpoint{FQDN: "turn.elb.us-east-1.amazonaws.com.", Transport: "udp"}
+	return event
+}
+
+func TestNetworkReport(t *testing.T) {
+	builder := NewNetworkBuilder()
+	builder.Thresholds.MinWiFiCalls = 1
+	builder.Add(loadFixture(t, "call_summary.json"))
+	for i := 0; i < 40; i++ {
+		poorMOS := 4.2
+		if i%2 == 0 {
+			poorMOS = 3.0
+		}
+		builder.Add(networkCall("bea", "SlowNet", "ethernet", poorMOS, 0.5))
+
+		goodMOS := 4.3
+		if i%20 == 0 {
+			goodMOS = 3.0
+		}
+		builder.Add(networkCall("cal", "FastNet", "wifi", goodMOS, 0.2))
+	}
+	builder.Add(networkCall("dee", "FastNet", "WLAN", 3.9, 2.0))
+
+	report := builder.Report()
+
+	if report.Fleet.Calls != 82 {
+		t.Errorf("Expected 82 fleet calls, got %d", report.Fleet.Calls)
+	}
+
+	tests := []struct {
+		name     string
+		rollup   []NetworkQuality
+		key      string
+		calls    int
+		length   int
+		poorRate float64
+	}{
+		{"ISP", report.ByISP, "FastNet", 41, 3, 2.0 / 41},
+		{"city", report.ByCity, "Sydney, NSW, Australia", 81, 2, 22.0 / 81},
+		{"country", report.ByCountry, "Australia", 82, 1, 22.0 / 82},
+		{"connection type", report.ByConnectionType, "ethernet", 40, 4, 0.5},
+		{"media region", report.ByMediaRegion, "us-east-1", 81, 2, 22.0 / 81},
+		{"transport", report.ByTransport, "udp", 82, 1, 22.0 / 82},
+		{"caller country", report.ByCallerCountry, "unknown", 81, 2, 22.0 / 81},
+		{"caller line type", report.ByCallerLineType, "unknown", 81, 2, 22.0 / 81},
+	}
+	for _, test := range tests {
+		if len(test.rollup) != test.length {
+			t.Errorf("%s: expected %d groups, got %+v", test.name, test.length, test.rollup)
+			continue
+		}
+		first := test.rollup[0]
+		if first.Key != test.key || first.Calls != test.calls || first.PoorCallRate != test.poorRate {
+			t.Errorf("%s: expected %s with %d calls and poor rate %v, got %+v", test.name, test.key, test.calls, test.poorRate, first)
+		}
+	}
+
+	if report.ByMediaRegion[1].Key != "ap-southeast-2" || report.ByCity[1].Key != "Nutfield, Victoria, Australia" {
+		t.Errorf("Expected fixture media region and city, got %+v and %+v", report.ByMediaRegion[1], report.ByCity[1])
+	}
+	if report.ByCallerCountry[1].Key != "AU" || report.ByCallerLineType[1].Key != "mobile" {
+		t.Errorf("Expected fixture caller country and line type, got %+v and %+v", report.ByCallerCountry[1], report.ByCallerLineType[1])
+	}
+
+	if len(report.WorseISPs) != 1 || report.WorseISPs[0].ISP != "SlowNet" || report.WorseISPs[0].PoorCallRate != 0.5 {
+		t.Errorf("Expected SlowNet to be worse than the fleet, got %+v", report.WorseISPs)
+	}
+
+	expectedWiFi := []string{"dee", "andy"}
+	if len(report.WiFiAgents) != len(expectedWiFi) {
+		t.Fatalf("Expected Wi-Fi agents %v, got %+v", expectedWiFi, report.WiFiAgents)
+	}
+	for i, agent := range expectedWiFi {
+		if report.WiFiAgents[i].Agent != agent {
+			t.Errorf("Wi-Fi agent %d = %s, expected %s", i, report.WiFiAgents[i].Agent, agent)
+		}
+	}
+}
+
+func TestNetworkReportMinimums(t *testing.T) {
+	builder := NewNetworkBuilder()
+	builder.Add(networkCall("eve", "FastNet", "wifi", 3.9, 3.0))
+	for i := 0; i < DefaultNetworkThresholds.MinWiFiCalls; i++ {
+		builder.Add(networkCall("fay", "FastNet", "wifi", 3.9, 2.0))
+	}
+	reported := networkCall("gus", "FastNet", "ethernet", 4.2, 0)
+	reported.Detail.WebRTCSession.Metrics.RTT.Avg = 40
+	reported.Detail.WebRTCSession.Metrics.Jitter.Avg = 6
+	builder.Add(reported)
+
+	report := builder.Report()
+
+	// eve has a single Wi-Fi call, too few to report
+	if len(report.WiFiAgents) != 1 || report.WiFiAgents[0].Agent != "fay" {
+		t.Errorf("Expected only fay on Wi-Fi, got %+v", report.WiFiAgents)
+	}
+	// The other calls did not report RTT or jitter
+	if report.Fleet.AverageRTT != 40 || report.Fleet.AverageJitter != 6 {
+		t.Errorf("Expected RTT 40 and jitter 6 from the one reporting call, got %+v", report.Fleet)
+	}
+}
+
+func TestMediaRegion(t *testing.T) {
+	tests := []struct {
+		fqdn     string
+		expected string
+	}{
+		{"turnnlb-93f2de0c97c4316b.elb.ap-southeast-2.amazonaws.com.", "ap-southeast-2"},
+		{"media.us-gov-west-1.amazonaws.com", "us-gov-west-1"},
+		{"turn.example.com", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if region := MediaRegion(test.fqdn); region != test.expected {
+			t.Errorf("MediaRegion(%q) = %q, expected %q", test.fqdn, region, test.expected)
+		}
+	}
+}
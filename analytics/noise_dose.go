@@ -0,0 +1,208 @@
+package analytics
+
+import (
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// Violation kinds reported in NoiseViolation
+const (
+	ViolationTWA  = "twa"
+	ViolationPeak = "peak"
+)
+
+// NoiseLimits are the occupational noise limits checked by NoiseDoseBuilder
+type NoiseLimits struct {
+	// TWA is the criterion level in dB(A) for an exposure of ReferenceDuration
+	TWA float64 `json:"twa"`
+	// ExchangeRate is the increase in dB that halves the allowed exposure time
+	ExchangeRate float64 `json:"exchangeRate"`
+	// ReferenceDuration is the working day the criterion level applies to
+	ReferenceDuration Duration `json:"referenceDuration"`
+	// PeakDB, when positive, flags any call whose maximum exposure exceeds it
+	PeakDB float64 `json:"peakDb,omitempty"`
+	// Location sets the day boundaries; nil means UTC
+	Location *time.Location `json:"-"`
+}
+
+// DefaultNoiseLimits are an 85 dB(A) eight-hour TWA with a 3 dB exchange rate
+var DefaultNoiseLimits = NoiseLimits{TWA: 85, ExchangeRate: 3, ReferenceDuration: Duration(8 * time.Hour)}
+
+// withDefaults replaces an unset or negative ExchangeRate or ReferenceDuration,
+// which the dose calculation divides by, with the default
+func (l NoiseLimits) withDefaults() NoiseLimits {
+	if l.ExchangeRate <= 0 {
+		l.ExchangeRate = DefaultNoiseLimits.ExchangeRate
+	}
+	if l.ReferenceDuration <= 0 {
+		l.ReferenceDuration = DefaultNoiseLimits.ReferenceDuration
+	}
+	return l
+}
+
+// NoiseDoseReport lists each agent's daily noise dose and any limit violations
+type NoiseDoseReport struct {
+	Limits     NoiseLimits      `json:"limits"`
+	Days       []DailyNoiseDose `json:"days"`
+	Violations []NoiseViolation `json:"violations"`
+}
+
+// DailyNoiseDose is one agent's exposure over one day
+type DailyNoiseDose struct {
+	Agent       string  `json:"agent"`
+	Date        string  `json:"date"`
+	Calls       int     `json:"calls"`
+	ExposureSec float64 `json:"exposureSec"`
+	// DosePercent is the share of the daily allowance used; 100 is the limit
+	DosePercent float64 `json:"dosePercent"`
+	// TWA is the equivalent level over ReferenceDuration, in dB(A)
+	TWA   float64 `json:"twa"`
+	MaxDB float64 `json:"maxDb"`
+}
+
+// NoiseViolation reports a day on which an agent exceeded a limit
+type NoiseViolation struct {
+	Agent string  `json:"agent"`
+	Date  string  `json:"date"`
+	Kind  string  `json:"kind"`
+	Value float64 `json:"value"`
+	Limit float64 `json:"limit"`
+}
+
+// NoiseDoseBuilder accumulates headset exposure into daily noise doses per agent.
+//
+// HeadsetSummary events do not name the agent, so the builder also reads
+// CallSummary events to learn which agent handled each contact. Exposure on
+// contacts with no matching CallSummary is attributed to "headset:<serial>",
+// or "contact:<id>" when the headset has no serial number, or UnknownAgent.
+// Each call contributes its average ExposureDB for its total interaction time.
+type NoiseDoseBuilder struct {
+	Limits NoiseLimits
+
+	agents    map[string]string
+	exposures []headsetExposure
+}
+
+type headsetExposure struct {
+	contactID string
+	serial    string
+	time      time.Time
+	seconds   float64
+	avgDB     float64
+	maxDB     float64
+}
+
+type doseKey struct {
+	agent, date string
+}
+
+// NewNoiseDoseBuilder creates a builder using DefaultNoiseLimits
+func NewNoiseDoseBuilder() *NoiseDoseBuilder {
+	return &NoiseDoseBuilder{Limits: DefaultNoiseLimits, agents: make(map[string]string)}
+}
+
+// Add accumulates a HeadsetSummary event, or learns a contact's agent from a
+// CallSummary event. Other event types are ignored.
+func (b *NoiseDoseBuilder) Add(event interface{}) {
+	switch e := event.(type) {
+	case *events.CallSummaryEvent:
+		if contactID := e.Detail.Contact.ID.Current; contactID != "" && e.Detail.ServiceAgent.Username != "" {
+			b.agents[contactID] = e.Detail.ServiceAgent.Username
+		}
+
+	case *events.HeadsetSummaryEvent:
+		seconds := float64(e.Detail.Contact.Interaction.TotalDurationSec)
+		if seconds == 0 {
+			seconds = e.Detail.Headset.Metrics.Speech.TotalSeconds
+		}
+		exposure := e.Detail.Headset.Metrics.ExposureDB
+		if seconds <= 0 || exposure.Avg <= 0 {
+			return
+		}
+		b.exposures = append(b.exposures, headsetExposure{
+			contactID: e.Detail.Contact.ID.Current,
+			serial:    e.Detail.Headset.SerialNumber,
+			time:      e.Time,
+			seconds:   seconds,
+			avgDB:     exposure.Avg,
+			maxDB:     exposure.Max,
+		})
+	}
+}
+
+// Report returns the daily doses so far, ordered by agent and date, and the
+// days that exceed the TWA or peak limits
+func (b *NoiseDoseBuilder) Report() *NoiseDoseReport {
+	limits := b.Limits.withDefaults()
+	location := limits.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	days := make(map[doseKey]*DailyNoiseDose)
+	for _, exposure := range b.exposures {
+		agent := b.agent(exposure)
+		key := doseKey{agent: agent, date: exposure.time.In(location).Format(time.DateOnly)}
+
+		day, ok := days[key]
+		if !ok {
+			day = &DailyNoiseDose{Agent: key.agent, Date: key.date}
+			days[key] = day
+		}
+		day.Calls++
+		day.ExposureSec += exposure.seconds
+		day.DosePercent += 100 * exposure.seconds / time.Duration(limits.ReferenceDuration).Seconds() *
+			math.Pow(2, (exposure.avgDB-limits.TWA)/limits.ExchangeRate)
+		day.MaxDB = math.Max(day.MaxDB, exposure.maxDB)
+	}
+
+	report := &NoiseDoseReport{Limits: limits, Days: make([]DailyNoiseDose, 0, len(days)), Violations: []NoiseViolation{}}
+	for _, day := range days {
+		day.TWA = limits.TWA + limits.ExchangeRate*math.Log2(day.DosePercent/100)
+		report.Days = append(report.Days, *day)
+	}
+	sort.Slice(report.Days, func(i, j int) bool {
+		if report.Days[i].Agent != report.Days[j].Agent {
+			return report.Days[i].Agent < report.Days[j].Agent
+		}
+		return report.Days[i].Date < report.Days[j].Date
+	})
+
+	for _, day := range report.Days {
+		if day.DosePercent > 100 {
+			report.Violations = append(report.Violations, NoiseViolation{
+				Agent: day.Agent, Date: day.Date, Kind: ViolationTWA, Value: day.TWA, Limit: limits.TWA,
+			})
+		}
+		if limits.PeakDB > 0 && day.MaxDB > limits.PeakDB {
+			report.Violations = append(report.Violations, NoiseViolation{
+				Agent: day.Agent, Date: day.Date, Kind: ViolationPeak, Value: day.MaxDB, Limit: limits.PeakDB,
+			})
+		}
+	}
+	return report
+}
+
+// agent returns who an exposure is attributed to
+func (b *NoiseDoseBuilder) agent(exposure headsetExposure) string {
+	if agent, ok := b.agents[exposure.contactID]; ok {
+		return agent
+	}
+	switch {
+	case exposure.serial != "":
+		return "headset:" + exposure.serial
+	case exposure.contactID != "":
+		return "contact:" + exposure.contactID
+	default:
+		return UnknownAgent
+	}
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *NoiseDoseReport) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
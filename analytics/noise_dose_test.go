@@ -0,0 +1,139 @@
+package analytics
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// exposureEvent builds a HeadsetSummary event for contactID lasting seconds at avgDB
+func exposureEvent(contactID string, at time.Time, seconds int, avgDB, maxDB float64) *events.HeadsetSummaryEvent {
+	event := &events.HeadsetSummaryEvent{EventBridgeEvent: events.EventBridgeEvent{DetailType: events.EventTypeHeadsetSummary, Time: at}}
+	event.Detail.Contact.ID.Current = contactID
+	event.Detail.Contact.Interaction.TotalDurationSec = seconds
+	event.Detail.Headset.SerialNumber = "SN-" + contactID
+	event.Detail.Headset.Metrics.ExposureDB = events.DBMetrics{Avg: avgDB, Max: maxDB}
+	return event
+}
+
+// agentCall builds a CallSummary event linking contactID to agent
+func agentCall(contactID, agent string) *events.CallSummaryEvent {
+	event := &events.CallSummaryEvent{EventBridgeEvent: events.EventBridgeEvent{DetailType: events.EventTypeCallSummary}}
+	event.Detail.Contact.ID.Current = contactID
+	event.Detail.ServiceAgent.Username = agent
+	return event
+}
+
+func TestNoiseDose(t *testing.T) {
+	builder := NewNoiseDoseBuilder()
+	builder.Limits.PeakDB = 100
+
+	day := testTime
+	builder.Add(exposureEvent("c1", day, 2*3600, 88, 95))
+	builder.Add(agentCall("c1", "andy"))
+	builder.Add(agentCall("c2", "andy"))
+	builder.Add(exposureEvent("c2", day.Add(3*time.Hour), 2*3600, 88, 101))
+	builder.Add(exposureEvent("c3", day.Add(24*time.Hour), 4*3600, 82, 90))
+	builder.Add(agentCall("c3", "andy"))
+	builder.Add(exposureEvent("c4", day, 3600, 70, 75))
+	builder.Add(loadFixture(t, "headset_summary.json"))
+	builder.Add(loadFixture(t, "call_summary.json"))
+
+	report := builder.Report()
+
+	expected := []struct {
+		agent string
+		date  string
+		calls int
+		dose  float64
+		twa   float64
+	}{
+		{"andy", "2023-06-01", 3, 100 + 100*312/28800.0*math.Pow(2, (68.4-85)/3), 85.0},
+		{"andy", "2023-06-02", 1, 25, 79},
+		{"headset:SN-c4", "2023-06-01", 1, 100 / 8.0 * math.Pow(2, -5), 61},
+	}
+	if len(report.Days) != len(expected) {
+		t.Fatalf("Expected %d days, got %+v", len(expected), report.Days)
+	}
+	for i, e := range expected {
+		got := report.Days[i]
+		if got.Agent != e.agent || got.Date != e.date || got.Calls != e.calls {
+			t.Errorf("Day %d = %+v, expected %s %s with %d calls", i, got, e.agent, e.date, e.calls)
+		}
+		if math.Abs(got.DosePercent-e.dose) > 1e-9 {
+			t.Errorf("Day %d dose = %v, expected %v", i, got.DosePercent, e.dose)
+		}
+		if math.Abs(got.TWA-e.twa) > 0.1 {
+			t.Errorf("Day %d TWA = %v, expected about %v", i, got.TWA, e.twa)
+		}
+	}
+
+	if len(report.Violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %+v", report.Violations)
+	}
+	if v := report.Violations[0]; v.Agent != "andy" || v.Kind != ViolationTWA || v.Limit != 85 {
+		t.Errorf("Unexpected TWA violation: %+v", v)
+	}
+	if v := report.Violations[1]; v.Kind != ViolationPeak || v.Value != 101 {
+		t.Errorf("Unexpected peak violation: %+v", v)
+	}
+}
+
+func TestNoiseDoseLocation(t *testing.T) {
+	builder := NewNoiseDoseBuilder()
+	builder.Limits.Location = time.FixedZone("AEST", 10*3600)
+	builder.Add(exposureEvent("c1", time.Date(2023, 6, 1, 20, 0, 0, 0, time.UTC), 60, 80, 80))
+
+	report := builder.Report()
+	if len(report.Days) != 1 || report.Days[0].Date != "2023-06-02" {
+		t.Errorf("Expected exposure on local date 2023-06-02, got %+v", report.Days)
+	}
+}
+
+func TestNoiseDoseUnattributed(t *testing.T) {
+	builder := NewNoiseDoseBuilder()
+	builder.Limits = NoiseLimits{TWA: 85}
+	for _, contactID := range []string{"c1", "c2", ""} {
+		event := exposureEvent(contactID, testTime, 3600, 85, 90)
+		event.Detail.Headset.SerialNumber = ""
+		builder.Add(event)
+	}
+
+	report := builder.Report()
+	if report.Limits.ExchangeRate != 3 || report.Limits.ReferenceDuration != Duration(8*time.Hour) {
+		t.Errorf("Expected unset limits to fall back to the defaults, got %+v", report.Limits)
+	}
+
+	expected := []string{"contact:c1", "contact:c2", UnknownAgent}
+	if len(report.Days) != len(expected) {
+		t.Fatalf("Expected %d days, got %+v", len(expected), report.Days)
+	}
+	for i, agent := range expected {
+		if day := report.Days[i]; day.Agent != agent || day.DosePercent != 12.5 || math.Abs(day.TWA-76) > 1e-9 {
+			t.Errorf("Day %d = %+v, expected %s with a 12.5%% dose", i, day, agent)
+		}
+	}
+	if err := report.WriteJSON(io.Discard); err != nil {
+		t.Errorf("Expected report to encode, got %v", err)
+	}
+}
+
+func TestNoiseLimitsJSON(t *testing.T) {
+	data, err := json.Marshal(DefaultNoiseLimits)
+	if err != nil || !strings.Contains(string(data), `"referenceDuration":"8h0m0s"`) {
+		t.Fatalf("Expected a duration string, got %s (%v)", data, err)
+	}
+
+	var limits NoiseLimits
+	if err := json.Unmarshal([]byte(`{"twa": 80, "exchangeRate": 3, "referenceDuration": 28800}`), &limits); err != nil {
+		t.Fatalf("Failed to decode limits: %v", err)
+	}
+	if limits.ReferenceDuration != Duration(8*time.Hour) {
+		t.Errorf("Expected seconds to decode as 8h, got %v", time.Duration(limits.ReferenceDuration))
+	}
+}
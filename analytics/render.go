@@ -0,0 +1,97 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// templateFuncs are available to the Markdown and HTML report templates
+var templateFuncs = map[string]interface{}{
+	"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) },
+	"fixed":   func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"counts":  formatCounts,
+	"cell":    markdownCell,
+	"period":  formatPeriod,
+}
+
+// markdownCell escapes a value for a Markdown table cell, so pipes and line
+// breaks in event data cannot split the row
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// formatPeriod renders the dates a report covers, leaving out unset bounds
+func formatPeriod(start, end time.Time) string {
+	const layout = "2006-01-02"
+	switch {
+	case !start.IsZero() && !end.IsZero():
+		return start.Format(layout) + " to " + end.Format(layout)
+	case !start.IsZero():
+		return "From " + start.Format(layout)
+	case !end.IsZero():
+		return "Up to " + end.Format(layout)
+	}
+	return ""
+}
+
+// Duration is a time.Duration written in JSON as a Go duration string such as "15m"
+type Duration time.Duration
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON reads a duration string, or a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+		*d = Duration(parsed)
+	case float64:
+		*d = Duration(value * float64(time.Second))
+	default:
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	return nil
+}
+
+// writeJSON encodes a report as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	return nil
+}
+
+// formatCounts renders counts as "a: 2, b: 1" in key order, or "-" when empty
+func formatCounts(counts map[string]int) string {
+	if len(counts) == 0 {
+		return "-"
+	}
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s: %d", key, counts[key])
+	}
+	return strings.Join(parts, ", ")
+}
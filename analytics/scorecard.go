@@ -0,0 +1,210 @@
+package analytics
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"math"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// UnknownAgent labels events that carry no agent username
+const UnknownAgent = "unknown"
+
+// Scorecard summarises each agent's calls and reported issues over a period
+type Scorecard struct {
+	Start  time.Time        `json:"start"`
+	End    time.Time        `json:"end"`
+	Agents []AgentScorecard `json:"agents"`
+}
+
+// AgentScorecard is one agent's row in a Scorecard
+type AgentScorecard struct {
+	Username     string `json:"username"`
+	FriendlyName string `json:"friendlyName,omitempty"`
+	Calls        int    `json:"calls"`
+	// AverageMOS is the mean of per-call average MOS, over calls that reported one
+	AverageMOS float64 `json:"averageMos"`
+	// PacketLossCallRatio is the fraction of calls whose inbound or outbound
+	// loss was Noticeable or worse
+	PacketLossCallRatio float64 `json:"packetLossCallRatio"`
+	// HoldRatio and MuteRatio are the fractions of total call time spent on hold and muted
+	HoldRatio        float64        `json:"holdRatio"`
+	MuteRatio        float64        `json:"muteRatio"`
+	IssuesReported   int            `json:"issuesReported"`
+	IssuesByCategory map[string]int `json:"issuesByCategory"`
+	// CPU and Memory summarise each call's average machine utilisation percentage
+	CPU    Distribution `json:"cpu"`
+	Memory Distribution `json:"memory"`
+}
+
+// ScorecardBuilder accumulates CallSummary and AgentReportedIssue events into a Scorecard
+type ScorecardBuilder struct {
+	start, end time.Time
+	agents     map[string]*agentTotals
+}
+
+type agentTotals struct {
+	friendlyName  string
+	calls         int
+	mosSum        float64
+	mosCalls      int
+	lossCalls     int
+	totalSec      int
+	holdSec       int
+	muteSec       int
+	issues        int
+	issueCategory map[string]int
+	cpu, memory   []float64
+}
+
+// NewScorecardBuilder creates a builder for events in [start, end). Zero bounds are open.
+func NewScorecardBuilder(start, end time.Time) *ScorecardBuilder {
+	return &ScorecardBuilder{start: start, end: end, agents: make(map[string]*agentTotals)}
+}
+
+// Add accumulates an event. Other event types, and events outside the period, are ignored.
+func (b *ScorecardBuilder) Add(event interface{}) {
+	switch e := event.(type) {
+	case *events.CallSummaryEvent:
+		if inWindow(e.Time, b.start, b.end) {
+			b.addCall(&e.Detail)
+		}
+	case *events.AgentReportedIssueEvent:
+		if inWindow(e.Time, b.start, b.end) {
+			b.addIssue(&e.Detail)
+		}
+	}
+}
+
+func (b *ScorecardBuilder) agent(username string) *agentTotals {
+	if username == "" {
+		username = UnknownAgent
+	}
+	totals, ok := b.agents[username]
+	if !ok {
+		totals = &agentTotals{issueCategory: make(map[string]int)}
+		b.agents[username] = totals
+	}
+	return totals
+}
+
+func (b *ScorecardBuilder) addCall(detail *events.CallSummaryDetail) {
+	agent := detail.ServiceAgent
+	totals := b.agent(agent.Username)
+	if agent.FriendlyName != "" {
+		totals.friendlyName = agent.FriendlyName
+	}
+	totals.calls++
+
+	metrics := detail.WebRTCSession.Metrics
+	if metrics.MOS.Avg > 0 {
+		totals.mosSum += metrics.MOS.Avg
+		totals.mosCalls++
+	}
+	loss := math.Max(metrics.Inbound.PacketsLostPercentage, metrics.Outbound.PacketsLostPercentage)
+	switch events.GetPacketLossLevel(loss) {
+	case events.PacketLossMinimal, events.PacketLossAcceptable:
+	default:
+		totals.lossCalls++
+	}
+
+	totals.totalSec += agent.Interaction.TotalDurationSec
+	totals.holdSec += agent.Interaction.OnHoldDurationSec
+	totals.muteSec += agent.Interaction.OnMuteDurationSec
+
+	if cpu := agent.Machine.CPU.UtilisedPercentage.Avg; cpu > 0 {
+		totals.cpu = append(totals.cpu, cpu)
+	}
+	if memory := agent.Machine.Memory.UtilisedPercentage.Avg; memory > 0 {
+		totals.memory = append(totals.memory, memory)
+	}
+}
+
+func (b *ScorecardBuilder) addIssue(detail *events.AgentReportedIssueDetail) {
+	totals := b.agent(detail.Agent)
+	totals.issues++
+	category := detail.Context.Category
+	if category == "" {
+		category = "Uncategorised"
+	}
+	totals.issueCategory[category]++
+}
+
+// Scorecard returns the scorecard so far, with agents in username order
+func (b *ScorecardBuilder) Scorecard() *Scorecard {
+	scorecard := &Scorecard{Start: b.start, End: b.end, Agents: make([]AgentScorecard, 0, len(b.agents))}
+	for username, totals := range b.agents {
+		categories := make(map[string]int, len(totals.issueCategory))
+		for category, count := range totals.issueCategory {
+			categories[category] = count
+		}
+
+		scorecard.Agents = append(scorecard.Agents, AgentScorecard{
+			Username:            username,
+			FriendlyName:        totals.friendlyName,
+			Calls:               totals.calls,
+			AverageMOS:          ratio(totals.mosSum, float64(totals.mosCalls)),
+			PacketLossCallRatio: ratio(float64(totals.lossCalls), float64(totals.calls)),
+			HoldRatio:           ratio(float64(totals.holdSec), float64(totals.totalSec)),
+			MuteRatio:           ratio(float64(totals.muteSec), float64(totals.totalSec)),
+			IssuesReported:      totals.issues,
+			IssuesByCategory:    categories,
+			CPU:                 newDistribution(append([]float64(nil), totals.cpu...)),
+			Memory:              newDistribution(append([]float64(nil), totals.memory...)),
+		})
+	}
+	sort.Slice(scorecard.Agents, func(i, j int) bool {
+		return scorecard.Agents[i].Username < scorecard.Agents[j].Username
+	})
+	return scorecard
+}
+
+// WriteJSON writes the scorecard as indented JSON
+func (s *Scorecard) WriteJSON(w io.Writer) error {
+	return writeJSON(w, s)
+}
+
+// WriteMarkdown writes the scorecard as a Markdown table
+func (s *Scorecard) WriteMarkdown(w io.Writer) error {
+	return scorecardMarkdown.Execute(w, s)
+}
+
+// WriteHTML writes the scorecard as a standalone HTML page
+func (s *Scorecard) WriteHTML(w io.Writer) error {
+	return scorecardHTML.Execute(w, s)
+}
+
+var scorecardMarkdown = template.Must(template.New("scorecard").Funcs(templateFuncs).Parse(`# Agent Scorecard
+{{with period .Start .End}}
+{{.}}
+{{end}}
+| Agent | Calls | Avg MOS | Calls with packet loss | On hold | Muted | Issues | CPU (median / p90) | Memory (median / p90) |
+|-------|------:|--------:|-----------------------:|--------:|------:|--------|-------------------:|----------------------:|
+{{range .Agents}}| {{cell .Username}} | {{.Calls}} | {{fixed .AverageMOS}} | {{percent .PacketLossCallRatio}} | {{percent .HoldRatio}} | {{percent .MuteRatio}} | {{cell (counts .IssuesByCategory)}} | {{fixed .CPU.Median}}% / {{fixed .CPU.P90}}% | {{fixed .Memory.Median}}% / {{fixed .Memory.P90}}% |
+{{end}}`))
+
+var scorecardHTML = htmltemplate.Must(htmltemplate.New("scorecard").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Agent Scorecard</title>
+<style>
+table { border-collapse: collapse; font-family: sans-serif; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; }
+td.num { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Agent Scorecard</h1>
+{{with period .Start .End}}<p>{{.}}</p>{{end}}
+<table>
+<tr><th>Agent</th><th>Calls</th><th>Avg MOS</th><th>Calls with packet loss</th><th>On hold</th><th>Muted</th><th>Issues</th><th>CPU (median / p90)</th><th>Memory (median / p90)</th></tr>
+{{range .Agents}}<tr><td title="{{.FriendlyName}}">{{.Username}}</td><td class="num">{{.Calls}}</td><td class="num">{{fixed .AverageMOS}}</td><td class="num">{{percent .PacketLossCallRatio}}</td><td class="num">{{percent .HoldRatio}}</td><td class="num">{{percent .MuteRatio}}</td><td>{{counts .IssuesByCategory}}</td><td class="num">{{fixed .CPU.Median}}% / {{fixed .CPU.P90}}%</td><td class="num">{{fixed .Memory.Median}}% / {{fixed .Memory.P90}}%</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
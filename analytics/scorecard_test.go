@@ -0,0 +1,153 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+var testTime = time.Date(2023, 6, 1, 5, 0, 0, 0, time.UTC)
+
+// loadFixture parses an event from the events package fixtures
+func loadFixture(t *testing.T, name string) interface{} {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "events", "testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	event, err := events.ParseEventBridgeEvent(data)
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	return event
+}
+
+// callEvent builds a CallSummary event for agent with the given MOS and packet loss
+func callEvent(agent string, mos, loss float64, interaction events.Interaction, cpu, memory float64) *events.CallSummaryEvent {
+	event := &events.CallSummaryEvent{EventBridgeEvent: events.EventBridgeEvent{DetailType: events.EventTypeCallSummary, Time: testTime}}
+	event.Detail.ServiceAgent.Username = agent
+	event.Detail.ServiceAgent.Interaction = interaction
+	event.Detail.ServiceAgent.Machine.CPU.UtilisedPercentage.Avg = cpu
+	event.Detail.ServiceAgent.Machine.Memory.UtilisedPercentage.Avg = memory
+	event.Detail.WebRTCSession.Metrics.MOS.Avg = mos
+	event.Detail.WebRTCSession.Metrics.Inbound.PacketsLostPercentage = loss
+	return event
+}
+
+// issueEvent builds an AgentReportedIssue event for agent
+func issueEvent(agent, category string) *events.AgentReportedIssueEvent {
+	event := &events.AgentReportedIssueEvent{EventBridgeEvent: events.EventBridgeEvent{DetailType: events.EventTypeAgentReportedIssue, Time: testTime}}
+	event.Detail.Agent = agent
+	event.Detail.Context.Category = category
+	return event
+}
+
+func TestScorecard(t *testing.T) {
+	builder := NewScorecardBuilder(testTime, testTime.AddDate(0, 0, 7))
+	builder.Add(loadFixture(t, "call_summary.json"))
+	builder.Add(loadFixture(t, "agent_reported_issue.json"))
+	builder.Add(loadFixture(t, "insights_summary.json"))
+	builder.Add(callEvent("andy", 3.25, 2.5, events.Interaction{TotalDurationSec: 86, OnHoldDurationSec: 20, OnMuteDurationSec: 10}, 50, 80))
+	builder.Add(callEvent("andy", 0, 0, events.Interaction{}, 0, 0))
+	builder.Add(issueEvent("bea", "Network"))
+	builder.Add(issueEvent("bea", ""))
+
+	late := callEvent("bea", 4, 0, events.Interaction{}, 0, 0)
+	late.Time = testTime.AddDate(0, 0, 7)
+	builder.Add(late)
+
+	scorecard := builder.Scorecard()
+	if len(scorecard.Agents) != 2 {
+		t.Fatalf("Expected 2 agents, got %+v", scorecard.Agents)
+	}
+
+	andy := scorecard.Agents[0]
+	tests := []struct {
+		name     string
+		got      float64
+		expected float64
+	}{
+		{"Calls", float64(andy.Calls), 3},
+		{"AverageMOS", andy.AverageMOS, 3.75},
+		{"PacketLossCallRatio", andy.PacketLossCallRatio, 2.0 / 3},
+		{"HoldRatio", andy.HoldRatio, 0.2},
+		{"MuteRatio", andy.MuteRatio, 0.1},
+		{"IssuesReported", float64(andy.IssuesReported), 1},
+		{"CPU.Samples", float64(andy.CPU.Samples), 2},
+		{"CPU.Max", andy.CPU.Max, 50},
+		{"Memory.Median", andy.Memory.Median, 86.155},
+	}
+	for _, test := range tests {
+		if diff := test.got - test.expected; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("andy %s = %v, expected %v", test.name, test.got, test.expected)
+		}
+	}
+	if andy.FriendlyName != "Andy" || andy.IssuesByCategory["Audio"] != 1 {
+		t.Errorf("Unexpected andy scorecard: %+v", andy)
+	}
+
+	bea := scorecard.Agents[1]
+	if bea.Calls != 0 || bea.IssuesReported != 2 || bea.IssuesByCategory["Uncategorised"] != 1 {
+		t.Errorf("Unexpected bea scorecard: %+v", bea)
+	}
+}
+
+func TestScorecardRendering(t *testing.T) {
+	builder := NewScorecardBuilder(testTime, testTime.AddDate(0, 0, 7))
+	builder.Add(callEvent("<andy>", 4.2, 0, events.Interaction{TotalDurationSec: 100, OnHoldDurationSec: 25}, 40, 60))
+	builder.Add(issueEvent("<andy>", "Audio"))
+	scorecard := builder.Scorecard()
+
+	var buf bytes.Buffer
+	if err := scorecard.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded Scorecard
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Agents[0].Calls != 1 {
+		t.Errorf("Expected JSON scorecard to round trip, got %v: %s", err, buf.String())
+	}
+
+	buf.Reset()
+	if err := scorecard.WriteMarkdown(&buf); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "| <andy> | 1 | 4.20 | 0.0% | 25.0% | 0.0% | Audio: 1 |") {
+		t.Errorf("Unexpected Markdown:\n%s", buf.String())
+	}
+
+	if !strings.Contains(buf.String(), "\n2023-06-01 to 2023-06-08\n") {
+		t.Errorf("Expected the scorecard period in Markdown:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := scorecard.WriteHTML(&buf); err != nil {
+		t.Fatalf("WriteHTML failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "&lt;andy&gt;") || strings.Contains(buf.String(), "<andy>") {
+		t.Errorf("Expected escaped agent name in HTML:\n%s", buf.String())
+	}
+}
+
+func TestScorecardMarkdownEscaping(t *testing.T) {
+	builder := NewScorecardBuilder(testTime, time.Time{})
+	builder.Add(callEvent("andy|ops", 4.2, 0, events.Interaction{}, 0, 0))
+	builder.Add(issueEvent("andy|ops", "Audio|Video\nDrops"))
+
+	var buf bytes.Buffer
+	if err := builder.Scorecard().WriteMarkdown(&buf); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	markdown := buf.String()
+	if !strings.Contains(markdown, "| andy\\|ops | 1 |") || !strings.Contains(markdown, "| Audio\\|Video Drops: 1 |") {
+		t.Errorf("Expected escaped cells in Markdown:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "\nFrom 2023-06-01\n") || strings.Contains(markdown, "0001-01-01") {
+		t.Errorf("Expected an open-ended period in Markdown:\n%s", markdown)
+	}
+}
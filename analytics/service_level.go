@@ -0,0 +1,199 @@
+package analytics
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// ServiceLevelReport summarises queue performance overall and per interval
+type ServiceLevelReport struct {
+	Target   Duration `json:"target"`
+	Interval Duration `json:"interval"`
+	// Queues has one entry per queue covering every interval, with no Start
+	Queues    []QueueServiceLevel `json:"queues"`
+	Intervals []QueueServiceLevel `json:"intervals"`
+}
+
+// QueueServiceLevel is a queue's performance over one interval
+type QueueServiceLevel struct {
+	QueueName string     `json:"queueName"`
+	Start     *time.Time `json:"start,omitempty"`
+	// Offered counts queued calls, excluding invalid ones
+	Offered              int `json:"offered"`
+	Answered             int `json:"answered"`
+	AnsweredWithinTarget int `json:"answeredWithinTarget"`
+	// ServiceLevel is the fraction of offered calls answered within the target
+	ServiceLevel float64 `json:"serviceLevel"`
+	// AverageSpeedOfAnswer is the mean queue wait of answered calls, in seconds
+	AverageSpeedOfAnswer float64 `json:"averageSpeedOfAnswer"`
+	MaxWaitSec           float64 `json:"maxWaitSec"`
+	// Abandoned counts queued calls that never connected to an agent
+	Abandoned int `json:"abandoned"`
+	// ShortAbandoned counts answered calls the customer ended within ShortCall
+	ShortAbandoned int `json:"shortAbandoned"`
+	// AbandonRate is (Abandoned + ShortAbandoned) / Offered
+	AbandonRate float64 `json:"abandonRate"`
+	// Invalid counts calls that connected to an agent before they were
+	// enqueued, which are left out of the other counts
+	Invalid int `json:"invalid"`
+}
+
+// ServiceLevelBuilder accumulates CallSummary events into queue service levels.
+//
+// CallSummary events describe calls that reached an agent, so abandonment is
+// estimated: calls queued but never connected, and answered calls that the
+// customer ended within ShortCall seconds.
+type ServiceLevelBuilder struct {
+	// Target is the answer time the service level is measured against
+	Target time.Duration
+	// Interval is the length of each reporting interval, aligned to UTC
+	Interval time.Duration
+	// ShortCall is the longest call the customer ends that counts as abandoned
+	ShortCall time.Duration
+
+	queues    map[string]*serviceLevelTotals
+	intervals map[queueInterval]*serviceLevelTotals
+}
+
+type queueInterval struct {
+	queue string
+	start time.Time
+}
+
+type serviceLevelTotals struct {
+	offered        int
+	answered       int
+	withinTarget   int
+	wait           mean
+	maxWait        time.Duration
+	abandoned      int
+	shortAbandoned int
+	invalid        int
+}
+
+// NewServiceLevelBuilder creates a builder for an 80/20-style target of
+// 20 seconds, in 30-minute intervals, counting calls under 10 seconds as abandoned
+func NewServiceLevelBuilder() *ServiceLevelBuilder {
+	return &ServiceLevelBuilder{
+		Target:    20 * time.Second,
+		Interval:  30 * time.Minute,
+		ShortCall: 10 * time.Second,
+		queues:    make(map[string]*serviceLevelTotals),
+		intervals: make(map[queueInterval]*serviceLevelTotals),
+	}
+}
+
+// Add accumulates a queued CallSummary event, in the interval it was enqueued.
+// Calls that were not queued, and other event types, are ignored.
+func (b *ServiceLevelBuilder) Add(event interface{}) {
+	e, ok := event.(*events.CallSummaryEvent)
+	if !ok || !e.Detail.WasQueued() {
+		return
+	}
+	detail := &e.Detail
+
+	queue := orUnknown(detail.Contact.QueueName)
+	start := detail.Contact.Events.Enqueued.UTC()
+	if b.Interval > 0 {
+		start = start.Truncate(b.Interval)
+	}
+
+	totals, ok := b.queues[queue]
+	if !ok {
+		totals = &serviceLevelTotals{}
+		b.queues[queue] = totals
+	}
+	totals.add(detail, b.Target, b.ShortCall)
+
+	key := queueInterval{queue: queue, start: start}
+	interval, ok := b.intervals[key]
+	if !ok {
+		interval = &serviceLevelTotals{}
+		b.intervals[key] = interval
+	}
+	interval.add(detail, b.Target, b.ShortCall)
+}
+
+func (t *serviceLevelTotals) add(detail *events.CallSummaryDetail, target, shortCall time.Duration) {
+	if !detail.WasAnswered() {
+		t.offered++
+		t.abandoned++
+		return
+	}
+
+	wait, ok := detail.QueueWait()
+	if !ok {
+		t.invalid++
+		return
+	}
+	t.offered++
+
+	t.answered++
+	t.wait.add(wait.Seconds())
+	if wait <= target {
+		t.withinTarget++
+	}
+	if wait > t.maxWait {
+		t.maxWait = wait
+	}
+
+	duration := time.Duration(detail.ServiceAgent.Interaction.TotalDurationSec) * time.Second
+	if detail.Contact.EndedBy == "Customer" && duration < shortCall {
+		t.shortAbandoned++
+	}
+}
+
+func (t *serviceLevelTotals) serviceLevel(queue string, start *time.Time) QueueServiceLevel {
+	return QueueServiceLevel{
+		QueueName:            queue,
+		Start:                start,
+		Offered:              t.offered,
+		Answered:             t.answered,
+		AnsweredWithinTarget: t.withinTarget,
+		ServiceLevel:         ratio(float64(t.withinTarget), float64(t.offered)),
+		AverageSpeedOfAnswer: t.wait.value(),
+		MaxWaitSec:           t.maxWait.Seconds(),
+		Abandoned:            t.abandoned,
+		ShortAbandoned:       t.shortAbandoned,
+		AbandonRate:          ratio(float64(t.abandoned+t.shortAbandoned), float64(t.offered)),
+		Invalid:              t.invalid,
+	}
+}
+
+// Report returns the service levels so far, ordered by queue and then interval
+func (b *ServiceLevelBuilder) Report() *ServiceLevelReport {
+	report := &ServiceLevelReport{
+		Target:    Duration(b.Target),
+		Interval:  Duration(b.Interval),
+		Queues:    make([]QueueServiceLevel, 0, len(b.queues)),
+		Intervals: make([]QueueServiceLevel, 0, len(b.intervals)),
+	}
+
+	for queue, totals := range b.queues {
+		report.Queues = append(report.Queues, totals.serviceLevel(queue, nil))
+	}
+	for key, totals := range b.intervals {
+		start := key.start
+		report.Intervals = append(report.Intervals, totals.serviceLevel(key.queue, &start))
+	}
+
+	sort.Slice(report.Queues, func(i, j int) bool {
+		return report.Queues[i].QueueName < report.Queues[j].QueueName
+	})
+	sort.Slice(report.Intervals, func(i, j int) bool {
+		a, c := report.Intervals[i], report.Intervals[j]
+		if a.QueueName != c.QueueName {
+			return a.QueueName < c.QueueName
+		}
+		return a.Start.Before(*c.Start)
+	})
+	return report
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *ServiceLevelReport) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
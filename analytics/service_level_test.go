@@ -0,0 +1,92 @@
+package analytics
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// queuedCall builds a CallSummary event enqueued at offset from testTime that waited wait;
+// a wait of -1 means the call never connected, and other negative waits connected before being enqueued
+func queuedCall(queue string, offset, wait time.Duration, endedBy string, durationSec int) *events.CallSummaryEvent {
+	event := callEvent("andy", 4, 0, events.Interaction{TotalDurationSec: durationSec}, 0, 0)
+	event.Detail.Contact.QueueName = queue
+	event.Detail.Contact.EndedBy = endedBy
+	event.Detail.Contact.Events.Enqueued = testTime.Add(offset)
+	if wait != -1 {
+		event.Detail.Contact.Events.ConnectingToAgent = testTime.Add(offset + wait)
+	}
+	return event
+}
+
+func TestServiceLevel(t *testing.T) {
+	builder := NewServiceLevelBuilder()
+	builder.Add(loadFixture(t, "call_summary.json"))
+	builder.Add(queuedCall("Sales", 0, 5*time.Second, "Agent", 300))
+	builder.Add(queuedCall("Sales", 10*time.Minute, 40*time.Second, "Customer", 4))
+	builder.Add(queuedCall("Sales", 20*time.Minute, -1, "", 0))
+	builder.Add(queuedCall("Sales", 40*time.Minute, 15*time.Second, "Agent", 120))
+	// Connected before it was enqueued, so the timestamps are not trusted
+	builder.Add(queuedCall("Sales", 50*time.Minute, -5*time.Second, "Agent", 60))
+	builder.Add(callEvent("andy", 4, 0, events.Interaction{}, 0, 0))
+
+	report := builder.Report()
+
+	expectedQueues := []QueueServiceLevel{
+		{QueueName: "Operata Prod Default Queue", Offered: 1, Answered: 1, AnsweredWithinTarget: 0, ServiceLevel: 0, AverageSpeedOfAnswer: 20.728, MaxWaitSec: 20.728},
+		{QueueName: "Sales", Offered: 4, Answered: 3, AnsweredWithinTarget: 2, ServiceLevel: 0.5, AverageSpeedOfAnswer: 20, MaxWaitSec: 40, Abandoned: 1, ShortAbandoned: 1, AbandonRate: 0.5, Invalid: 1},
+	}
+	if len(report.Queues) != len(expectedQueues) {
+		t.Fatalf("Expected %d queues, got %+v", len(expectedQueues), report.Queues)
+	}
+	for i, expected := range expectedQueues {
+		if !serviceLevelsEqual(report.Queues[i], expected) {
+			t.Errorf("Queue %d = %+v, expected %+v", i, report.Queues[i], expected)
+		}
+	}
+
+	expectedIntervals := []struct {
+		queue   string
+		start   time.Time
+		offered int
+		level   float64
+	}{
+		{"Operata Prod Default Queue", time.Date(2023, 6, 1, 4, 30, 0, 0, time.UTC), 1, 0},
+		{"Sales", testTime, 3, 1.0 / 3},
+		{"Sales", testTime.Add(30 * time.Minute), 1, 1},
+	}
+	if len(report.Intervals) != len(expectedIntervals) {
+		t.Fatalf("Expected %d intervals, got %+v", len(expectedIntervals), report.Intervals)
+	}
+	for i, expected := range expectedIntervals {
+		got := report.Intervals[i]
+		if got.QueueName != expected.queue || got.Start == nil || !got.Start.Equal(expected.start) || got.Offered != expected.offered || math.Abs(got.ServiceLevel-expected.level) > 1e-9 {
+			t.Errorf("Interval %d = %+v, expected %s at %v with %d offered and service level %v", i, got, expected.queue, expected.start, expected.offered, expected.level)
+		}
+	}
+}
+
+func TestServiceLevelReportDurationJSON(t *testing.T) {
+	data, err := json.Marshal(NewServiceLevelBuilder().Report())
+	if err != nil {
+		t.Fatalf("Failed to encode report: %v", err)
+	}
+	if !strings.Contains(string(data), `"target":"20s","interval":"30m0s"`) {
+		t.Errorf("Expected duration strings, got %s", data)
+	}
+}
+
+func serviceLevelsEqual(a, b QueueServiceLevel) bool {
+	const tolerance = 1e-9
+	return a.QueueName == b.QueueName && a.Start == nil && b.Start == nil &&
+		a.Offered == b.Offered && a.Answered == b.Answered && a.AnsweredWithinTarget == b.AnsweredWithinTarget &&
+		a.Abandoned == b.Abandoned && a.ShortAbandoned == b.ShortAbandoned && a.Invalid == b.Invalid &&
+		math.Abs(a.ServiceLevel-b.ServiceLevel) < tolerance &&
+		math.Abs(a.AverageSpeedOfAnswer-b.AverageSpeedOfAnswer) < tolerance &&
+		math.Abs(a.MaxWaitSec-b.MaxWaitSec) < tolerance &&
+		math.Abs(a.AbandonRate-b.AbandonRate) < tolerance
+}
@@ -0,0 +1,107 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Distribution summarises a set of samples
+type Distribution struct {
+	Samples int     `json:"samples"`
+	Min     float64 `json:"min"`
+	Median  float64 `json:"median"`
+	P90     float64 `json:"p90"`
+	Max     float64 `json:"max"`
+}
+
+// newDistribution summarises samples, which it sorts in place
+func newDistribution(samples []float64) Distribution {
+	if len(samples) == 0 {
+		return Distribution{}
+	}
+	sort.Float64s(samples)
+	return Distribution{
+		Samples: len(samples),
+		Min:     samples[0],
+		Median:  percentile(samples, 50),
+		P90:     percentile(samples, 90),
+		Max:     samples[len(samples)-1],
+	}
+}
+
+// percentile returns the pth percentile of sorted samples, interpolating between ranks
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// ratio returns part/whole, or 0 when whole is 0
+func ratio(part, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return part / whole
+}
+
+// inWindow reports whether t falls in [start, end); zero bounds are open
+func inWindow(t, start, end time.Time) bool {
+	return (start.IsZero() || !t.Before(start)) && (end.IsZero() || t.Before(end))
+}
+
+// mean accumulates a running average
+type mean struct {
+	sum float64
+	n   int
+}
+
+func (m *mean) add(v float64) {
+	m.sum += v
+	m.n++
+}
+
+// addPositive adds v only when it is positive, for metrics where zero means not reported
+func (m *mean) addPositive(v float64) {
+	if v > 0 {
+		m.add(v)
+	}
+}
+
+func (m *mean) merge(other mean) {
+	m.sum += other.sum
+	m.n += other.n
+}
+
+func (m mean) value() float64 {
+	return ratio(m.sum, float64(m.n))
+}
+
+// correlation returns the Pearson correlation of paired samples, or 0 when
+// there are fewer than two pairs or either side does not vary
+func correlation(xs, ys []float64) float64 {
+	if len(xs) < 2 || len(xs) != len(ys) {
+		return 0
+	}
+	var mx, my mean
+	for i := range xs {
+		mx.add(xs[i])
+		my.add(ys[i])
+	}
+
+	var cov, vx, vy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx.value(), ys[i]-my.value()
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// Window is a span of event time, including Start and excluding End
+type Window struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Contains reports whether t falls in the window
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// WindowSpec describes windows of Size starting every Slide. Window starts
+// are multiples of Slide since the zero time, which puts them on UTC midnight
+// when Slide divides 24 hours. A zero Slide, or one equal to Size, gives
+// tumbling windows; a shorter Slide gives overlapping sliding windows.
+type WindowSpec struct {
+	Size  Duration `json:"size"`
+	Slide Duration `json:"slide,omitempty"`
+}
+
+// Tumbling returns back-to-back windows of size
+func Tumbling(size time.Duration) WindowSpec {
+	return WindowSpec{Size: Duration(size)}
+}
+
+// Sliding returns windows of size starting every slide
+func Sliding(size, slide time.Duration) WindowSpec {
+	return WindowSpec{Size: Duration(size), Slide: Duration(slide)}
+}
+
+// step returns the time between window starts
+func (s WindowSpec) step() time.Duration {
+	if s.Slide <= 0 {
+		return time.Duration(s.Size)
+	}
+	return time.Duration(s.Slide)
+}
+
+// Windows returns the windows containing t, earliest first. A Slide longer
+// than Size leaves gaps, so some times fall in no window.
+func (s WindowSpec) Windows(t time.Time) []Window {
+	if s.Size <= 0 || t.IsZero() {
+		return nil
+	}
+	size, step := time.Duration(s.Size), s.step()
+
+	var windows []Window
+	for start := t.UTC().Truncate(step); start.After(t.Add(-size)); start = start.Add(-step) {
+		windows = append(windows, Window{Start: start, End: start.Add(size)})
+	}
+	for i, j := 0, len(windows)-1; i < j; i, j = i+1, j-1 {
+		windows[i], windows[j] = windows[j], windows[i]
+	}
+	return windows
+}
+
+// EventTime returns when an event happened: the detail timestamp of a
+// CallSummary event, which marks the end of the call, or the EventBridge
+// time of other events. It returns the zero time when neither is set.
+func EventTime(event interface{}) time.Time {
+	if e, ok := event.(*events.CallSummaryEvent); ok && !e.Detail.Timestamp.IsZero() {
+		return e.Detail.Timestamp
+	}
+	return HeaderTime(event)
+}
+
+// HeaderTime returns an event's EventBridge time
+func HeaderTime(event interface{}) time.Time {
+	if header, ok := events.GetEventHeader(event); ok {
+		return header.Time
+	}
+	return time.Time{}
+}
@@ -0,0 +1,291 @@
+package analytics
+
+import (
+	"io"
+	"sort"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// Workstation classifications
+const (
+	WorkstationHealthy          = "healthy"
+	WorkstationUnderProvisioned = "under-provisioned"
+)
+
+// Reasons a workstation is under-provisioned
+const (
+	ReasonHighCPU    = "high-cpu"
+	ReasonHighMemory = "high-memory"
+	ReasonLowMemory  = "low-memory"
+)
+
+// WorkstationThresholds decide when a workstation is under pressure or under-provisioned
+type WorkstationThresholds struct {
+	// HighCPUPercent and HighMemoryPercent mark a sample as under pressure
+	HighCPUPercent    float64 `json:"highCpuPercent"`
+	HighMemoryPercent float64 `json:"highMemoryPercent"`
+	// PressureShare is the fraction of samples under pressure that makes a
+	// workstation under-provisioned
+	PressureShare float64 `json:"pressureShare"`
+	// MinMemoryGB flags workstations with less memory installed
+	MinMemoryGB float64 `json:"minMemoryGb"`
+	// MinSamples is the number of CPU or memory readings needed before
+	// classifying on that pressure
+	MinSamples int `json:"minSamples"`
+}
+
+// DefaultWorkstationThresholds treat 80% CPU and 90% memory as pressure, on half of samples
+var DefaultWorkstationThresholds = WorkstationThresholds{
+	HighCPUPercent:    80,
+	HighMemoryPercent: 90,
+	PressureShare:     0.5,
+	MinMemoryGB:       8,
+	MinSamples:        3,
+}
+
+// WorkstationReport assesses agent workstations
+type WorkstationReport struct {
+	Thresholds   WorkstationThresholds `json:"thresholds"`
+	Workstations []WorkstationHealth   `json:"workstations"`
+	Impact       PressureImpact        `json:"impact"`
+	// RefreshCandidates groups under-provisioned workstations by CPU model
+	RefreshCandidates []RefreshGroup `json:"refreshCandidates"`
+}
+
+// WorkstationHealth is the assessment of one agent's workstation
+type WorkstationHealth struct {
+	Agent    string `json:"agent"`
+	CPUModel string `json:"cpuModel"`
+	// InstalledMemoryGB is the total memory from AgentReportedIssue telemetry,
+	// or else the CallSummary availableGb, the device memory the browser reports
+	InstalledMemoryGB float64 `json:"installedMemoryGb,omitempty"`
+	// Samples counts CallSummary and AgentReportedIssue events with CPU or
+	// memory readings; events without telemetry are not counted
+	Samples         int          `json:"samples"`
+	CPU             Distribution `json:"cpu"`
+	Memory          Distribution `json:"memory"`
+	HighCPUShare    float64      `json:"highCpuShare"`
+	HighMemoryShare float64      `json:"highMemoryShare"`
+	AverageMOS      float64      `json:"averageMos"`
+	Classification  string       `json:"classification"`
+	Reasons         []string     `json:"reasons"`
+}
+
+// PressureImpact compares call quality with and without machine pressure
+type PressureImpact struct {
+	CallsUnderPressure   int     `json:"callsUnderPressure"`
+	CallsWithoutPressure int     `json:"callsWithoutPressure"`
+	MOSUnderPressure     float64 `json:"mosUnderPressure"`
+	MOSWithoutPressure   float64 `json:"mosWithoutPressure"`
+	// CPUCorrelation and MemoryCorrelation are the Pearson correlations of
+	// utilisation with MOS; negative values mean quality falls as load rises
+	CPUCorrelation    float64 `json:"cpuCorrelation"`
+	MemoryCorrelation float64 `json:"memoryCorrelation"`
+}
+
+// RefreshGroup lists the under-provisioned workstations with one CPU model
+type RefreshGroup struct {
+	CPUModel string   `json:"cpuModel"`
+	Agents   []string `json:"agents"`
+}
+
+// WorkstationBuilder accumulates machine telemetry into a WorkstationReport
+type WorkstationBuilder struct {
+	Thresholds WorkstationThresholds
+
+	workstations map[string]*workstationTotals
+	calls        []callLoad
+}
+
+type workstationTotals struct {
+	cpuModel string
+	// installedGB is set from issue telemetry, which takes precedence over deviceGB
+	installedGB, deviceGB float64
+	samples               int
+	cpu, memory           []float64
+	mos                   mean
+}
+
+// callLoad pairs a call's machine utilisation with its quality
+type callLoad struct {
+	cpu, memory, mos float64
+}
+
+// NewWorkstationBuilder creates a builder using DefaultWorkstationThresholds
+func NewWorkstationBuilder() *WorkstationBuilder {
+	return &WorkstationBuilder{Thresholds: DefaultWorkstationThresholds, workstations: make(map[string]*workstationTotals)}
+}
+
+// Add accumulates telemetry from CallSummary and AgentReportedIssue events;
+// other event types are ignored
+func (b *WorkstationBuilder) Add(event interface{}) {
+	switch e := event.(type) {
+	case *events.CallSummaryEvent:
+		agent := e.Detail.ServiceAgent
+		machine := agent.Machine
+		totals := b.workstation(agent.Username, machine.CPU.ModelName)
+		if machine.Memory.AvailableGB > 0 {
+			totals.deviceGB = machine.Memory.AvailableGB
+		}
+
+		cpu, memory := machine.CPU.UtilisedPercentage.Avg, machine.Memory.UtilisedPercentage.Avg
+		totals.addSample(cpu, cpu > 0, memory, memory > 0)
+
+		if mos := e.Detail.WebRTCSession.Metrics.MOS.Avg; mos > 0 {
+			totals.mos.add(mos)
+			if cpu > 0 && memory > 0 {
+				b.calls = append(b.calls, callLoad{cpu: cpu, memory: memory, mos: mos})
+			}
+		}
+
+	case *events.AgentReportedIssueEvent:
+		system := e.Detail.System
+		totals := b.workstation(e.Detail.Agent, system.CPU.ModelName)
+		if system.Memory.Total > 0 {
+			totals.installedGB = system.Memory.Total
+		}
+		memory := 100 * ratio(system.Memory.Total-system.Memory.Available, system.Memory.Total)
+		totals.addSample(system.CPU.UsedPercentage, system.CPU.UsedPercentage > 0, memory, system.Memory.Total > 0)
+	}
+}
+
+// addSample records the readings an event reported; absent telemetry is zero
+// and would otherwise look like an idle machine
+func (t *workstationTotals) addSample(cpu float64, hasCPU bool, memory float64, hasMemory bool) {
+	if hasCPU {
+		t.cpu = append(t.cpu, cpu)
+	}
+	if hasMemory {
+		t.memory = append(t.memory, memory)
+	}
+	if hasCPU || hasMemory {
+		t.samples++
+	}
+}
+
+// installed returns the workstation's installed memory, or zero if unknown
+func (t *workstationTotals) installed() float64 {
+	if t.installedGB > 0 {
+		return t.installedGB
+	}
+	return t.deviceGB
+}
+
+func (b *WorkstationBuilder) workstation(agent, cpuModel string) *workstationTotals {
+	if agent == "" {
+		agent = UnknownAgent
+	}
+	totals, ok := b.workstations[agent]
+	if !ok {
+		totals = &workstationTotals{}
+		b.workstations[agent] = totals
+	}
+	if cpuModel != "" {
+		totals.cpuModel = cpuModel
+	}
+	return totals
+}
+
+// Report returns the assessment so far, with workstations in agent order
+func (b *WorkstationBuilder) Report() *WorkstationReport {
+	t := b.Thresholds
+	report := &WorkstationReport{
+		Thresholds:        t,
+		Workstations:      make([]WorkstationHealth, 0, len(b.workstations)),
+		RefreshCandidates: []RefreshGroup{},
+	}
+
+	refresh := make(map[string][]string)
+	for agent, totals := range b.workstations {
+		health := WorkstationHealth{
+			Agent:             agent,
+			CPUModel:          totals.cpuModel,
+			InstalledMemoryGB: totals.installed(),
+			Samples:           totals.samples,
+			HighCPUShare:      shareAtLeast(totals.cpu, t.HighCPUPercent),
+			HighMemoryShare:   shareAtLeast(totals.memory, t.HighMemoryPercent),
+			CPU:               newDistribution(append([]float64(nil), totals.cpu...)),
+			Memory:            newDistribution(append([]float64(nil), totals.memory...)),
+			AverageMOS:        totals.mos.value(),
+			Classification:    WorkstationHealthy,
+			Reasons:           []string{},
+		}
+
+		if len(totals.cpu) >= t.MinSamples && health.HighCPUShare >= t.PressureShare {
+			health.Reasons = append(health.Reasons, ReasonHighCPU)
+		}
+		if len(totals.memory) >= t.MinSamples && health.HighMemoryShare >= t.PressureShare {
+			health.Reasons = append(health.Reasons, ReasonHighMemory)
+		}
+		if health.InstalledMemoryGB > 0 && health.InstalledMemoryGB < t.MinMemoryGB {
+			health.Reasons = append(health.Reasons, ReasonLowMemory)
+		}
+		if len(health.Reasons) > 0 {
+			health.Classification = WorkstationUnderProvisioned
+			refresh[health.CPUModel] = append(refresh[health.CPUModel], agent)
+		}
+
+		report.Workstations = append(report.Workstations, health)
+	}
+	sort.Slice(report.Workstations, func(i, j int) bool {
+		return report.Workstations[i].Agent < report.Workstations[j].Agent
+	})
+
+	for model, agents := range refresh {
+		sort.Strings(agents)
+		report.RefreshCandidates = append(report.RefreshCandidates, RefreshGroup{CPUModel: model, Agents: agents})
+	}
+	sort.Slice(report.RefreshCandidates, func(i, j int) bool {
+		a, c := report.RefreshCandidates[i], report.RefreshCandidates[j]
+		if len(a.Agents) != len(c.Agents) {
+			return len(a.Agents) > len(c.Agents)
+		}
+		return a.CPUModel < c.CPUModel
+	})
+
+	report.Impact = b.impact()
+	return report
+}
+
+// impact compares the MOS of calls made with and without machine pressure
+func (b *WorkstationBuilder) impact() PressureImpact {
+	var impact PressureImpact
+	var under, without mean
+	cpu := make([]float64, len(b.calls))
+	memory := make([]float64, len(b.calls))
+	mos := make([]float64, len(b.calls))
+
+	for i, call := range b.calls {
+		cpu[i], memory[i], mos[i] = call.cpu, call.memory, call.mos
+		if call.cpu >= b.Thresholds.HighCPUPercent || call.memory >= b.Thresholds.HighMemoryPercent {
+			under.add(call.mos)
+		} else {
+			without.add(call.mos)
+		}
+	}
+
+	impact.CallsUnderPressure = under.n
+	impact.CallsWithoutPressure = without.n
+	impact.MOSUnderPressure = under.value()
+	impact.MOSWithoutPressure = without.value()
+	impact.CPUCorrelation = correlation(cpu, mos)
+	impact.MemoryCorrelation = correlation(memory, mos)
+	return impact
+}
+
+// shareAtLeast returns the fraction of samples at or above threshold
+func shareAtLeast(samples []float64, threshold float64) float64 {
+	count := 0
+	for _, sample := range samples {
+		if sample >= threshold {
+			count++
+		}
+	}
+	return ratio(float64(count), float64(len(samples)))
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *WorkstationReport) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
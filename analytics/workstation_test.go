@@ -0,0 +1,110 @@
+package analytics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// machineCall builds a CallSummary event for agent on a machine under the given load
+func machineCall(agent, cpuModel string, memoryGB, cpu, memory, mos float64) *events.CallSummaryEvent {
+	event := callEvent(agent, mos, 0, events.Interaction{}, cpu, memory)
+	event.Detail.ServiceAgent.Machine.CPU.ModelName = cpuModel
+	event.Detail.ServiceAgent.Machine.Memory.AvailableGB = memoryGB
+	return event
+}
+
+func TestWorkstationHealth(t *testing.T) {
+	builder := NewWorkstationBuilder()
+	builder.Add(loadFixture(t, "call_summary.json"))
+	builder.Add(loadFixture(t, "agent_reported_issue.json"))
+	builder.Add(machineCall("andy", "", 0, 35, 95, 3.5))
+
+	builder.Add(machineCall("bea", "Celeron N4020", 4, 95, 60, 3.2))
+	builder.Add(machineCall("bea", "Celeron N4020", 4, 90, 70, 3.4))
+	builder.Add(machineCall("bea", "Celeron N4020", 4, 50, 60, 4.0))
+
+	builder.Add(machineCall("cal", "Core i7-1185G7", 32, 20, 40, 4.4))
+	builder.Add(machineCall("cal", "Core i7-1185G7", 32, 25, 45, 4.3))
+	builder.Add(machineCall("cal", "Core i7-1185G7", 32, 30, 50, 4.3))
+
+	builder.Add(machineCall("dee", "Celeron N4020", 4, 20, 30, 4.1))
+
+	// Events without telemetry add no samples
+	builder.Add(machineCall("cal", "Core i7-1185G7", 32, 0, 0, 4.4))
+	silent := &events.AgentReportedIssueEvent{}
+	silent.Detail.Agent = "dee"
+	builder.Add(silent)
+
+	report := builder.Report()
+
+	expected := []struct {
+		agent          string
+		classification string
+		reasons        []string
+	}{
+		{"andy", WorkstationUnderProvisioned, []string{ReasonHighMemory}},
+		{"bea", WorkstationUnderProvisioned, []string{ReasonHighCPU, ReasonLowMemory}},
+		{"cal", WorkstationHealthy, []string{}},
+		{"dee", WorkstationUnderProvisioned, []string{ReasonLowMemory}},
+	}
+	if len(report.Workstations) != len(expected) {
+		t.Fatalf("Expected %d workstations, got %+v", len(expected), report.Workstations)
+	}
+	for i, e := range expected {
+		got := report.Workstations[i]
+		if got.Agent != e.agent || got.Classification != e.classification || !reflect.DeepEqual(got.Reasons, e.reasons) {
+			t.Errorf("Workstation %d = %s %s %v, expected %s %s %v", i, got.Agent, got.Classification, got.Reasons, e.agent, e.classification, e.reasons)
+		}
+	}
+
+	andy := report.Workstations[0]
+	if andy.Samples != 3 || andy.CPUModel != "Intel(R) Core(TM) i7-7700HQ CPU @ 2.80GHz" || andy.InstalledMemoryGB != 16 {
+		t.Errorf("Unexpected andy workstation: %+v", andy)
+	}
+	if math.Abs(andy.Memory.Median-92.5) > 1e-9 {
+		t.Errorf("Expected issue memory 92.5%%, got median %v", andy.Memory.Median)
+	}
+
+	for _, health := range report.Workstations[2:] {
+		if health.Samples != health.CPU.Samples || health.Memory.Min < 30 {
+			t.Errorf("Expected only reported readings for %s, got %+v", health.Agent, health)
+		}
+	}
+
+	expectedRefresh := []RefreshGroup{
+		{CPUModel: "Celeron N4020", Agents: []string{"bea", "dee"}},
+		{CPUModel: "Intel(R) Core(TM) i7-7700HQ CPU @ 2.80GHz", Agents: []string{"andy"}},
+	}
+	if !reflect.DeepEqual(report.RefreshCandidates, expectedRefresh) {
+		t.Errorf("Expected refresh candidates %+v, got %+v", expectedRefresh, report.RefreshCandidates)
+	}
+
+	impact := report.Impact
+	if impact.CallsUnderPressure != 4 || impact.CallsWithoutPressure != 5 {
+		t.Errorf("Unexpected pressure split: %+v", impact)
+	}
+	if impact.MOSUnderPressure >= impact.MOSWithoutPressure || impact.CPUCorrelation >= 0 {
+		t.Errorf("Expected pressure to correlate with lower MOS: %+v", impact)
+	}
+}
+
+func TestCorrelation(t *testing.T) {
+	tests := []struct {
+		xs, ys   []float64
+		expected float64
+	}{
+		{[]float64{1, 2, 3}, []float64{2, 4, 6}, 1},
+		{[]float64{1, 2, 3}, []float64{3, 2, 1}, -1},
+		{[]float64{1, 1, 1}, []float64{1, 2, 3}, 0},
+		{[]float64{1}, []float64{1}, 0},
+	}
+
+	for _, test := range tests {
+		if got := correlation(test.xs, test.ys); math.Abs(got-test.expected) > 1e-9 {
+			t.Errorf("correlation(%v, %v) = %v, expected %v", test.xs, test.ys, got, test.expected)
+		}
+	}
+}
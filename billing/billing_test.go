@@ -0,0 +1,220 @@
+package billing
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+const testGroup = "a28453f9-1111-2222-3333-84d9e67ac297"
+
+var testCard = &RateCard{
+	Currency: "USD",
+	Inbound:  map[string]float64{AnyCountry: 0.002, "AU": 0.012},
+	Outbound: map[string]float64{"US": 0.005},
+}
+
+func loadFixture(t *testing.T, name string) interface{} {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "events", "testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	event, err := events.ParseEventBridgeEvent(data)
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	return event
+}
+
+func call(queue, direction, callerID string, minutes int, at time.Time) *events.CallSummaryEvent {
+	event := &events.CallSummaryEvent{}
+	event.Detail.AccountProperties.OperataGroupID = testGroup
+	event.Detail.Contact.QueueName = queue
+	event.Detail.Contact.Direction = direction
+	event.Detail.Contact.CallerID = callerID
+	event.Detail.Billing.DurationRoundedMin = minutes
+	event.Detail.Timestamp = at
+	return event
+}
+
+func TestRateCard(t *testing.T) {
+	card, err := LoadRateCard(strings.NewReader(`{"currency": "USD", "inbound": {"*": 0.002, "AU": 0.012}, "outbound": {"US": 0.005}}`))
+	if err != nil {
+		t.Fatalf("Failed to load rate card: %v", err)
+	}
+
+	tests := []struct {
+		direction string
+		country   string
+		rate      float64
+		ok        bool
+	}{
+		{"Inbound", "AU", 0.012, true},
+		{"inbound", "au", 0.012, true},
+		{"Inbound", "GB", 0.002, true},
+		{"Inbound", "", 0.002, true},
+		{"Outbound", "US", 0.005, true},
+		{"Outbound", "AU", 0, false},
+		{"Transfer", "AU", 0, false},
+	}
+	for _, test := range tests {
+		rate, ok := card.Rate(test.direction, test.country)
+		if rate != test.rate || ok != test.ok {
+			t.Errorf("Rate(%q, %q) = %v, %v, expected %v, %v", test.direction, test.country, rate, ok, test.rate, test.ok)
+		}
+	}
+
+	var none *RateCard
+	if _, ok := none.Rate("Inbound", "AU"); ok {
+		t.Error("Expected no rate from a nil rate card")
+	}
+
+	for _, invalid := range []string{`{"inbound": {"AU": -1}}`, `{"inbound": {"AU": "cheap"}}`, `{"rates": {}}`} {
+		if _, err := LoadRateCard(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected error loading %s", invalid)
+		}
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	june := time.Date(2023, 6, 15, 9, 0, 0, 0, time.UTC)
+	aggregator := NewAggregator(testCard, PeriodMonth)
+	aggregator.Add(loadFixture(t, "call_summary.json"))
+	aggregator.Add(loadFixture(t, "headset_summary.json"))
+	aggregator.Add(call("Operata Prod Default Queue", "Inbound", "+61400000000", 4, june))
+	aggregator.Add(call("Sales", "Outbound", "+14155550123", 10, june))
+	aggregator.Add(call("Sales", "Outbound", "+442079460000", 3, june))
+	aggregator.Add(call("Sales", "Outbound", "+14155550123", 2, june.AddDate(0, 1, 0)))
+
+	lines := aggregator.Lines()
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 usage lines, got %d: %+v", len(lines), lines)
+	}
+
+	expected := []UsageLine{
+		{UsageKey{testGroup, "Operata Prod Default Queue", "Inbound", "AU", "2023-06"}, 2, 5, 0.06, 0},
+		{UsageKey{testGroup, "Sales", "Outbound", "GB", "2023-06"}, 1, 3, 0, 3},
+		{UsageKey{testGroup, "Sales", "Outbound", "US", "2023-06"}, 1, 10, 0.05, 0},
+		{UsageKey{testGroup, "Sales", "Outbound", "US", "2023-07"}, 1, 2, 0.01, 0},
+	}
+	for i, line := range lines {
+		want := expected[i]
+		if line.UsageKey != want.UsageKey || line.Calls != want.Calls || line.Minutes != want.Minutes ||
+			line.UnratedMinutes != want.UnratedMinutes || !closeTo(line.Cost, want.Cost) {
+			t.Errorf("Line %d: expected %+v, got %+v", i, want, line)
+		}
+	}
+
+	// Periods follow the aggregator's time zone
+	daily := NewAggregator(testCard, PeriodDay)
+	daily.Location = time.FixedZone("AEST", 10*60*60)
+	daily.Add(call("Sales", "Inbound", "", 1, time.Date(2023, 6, 30, 20, 0, 0, 0, time.UTC)))
+	if period := daily.Lines()[0].Period; period != "2023-07-01" {
+		t.Errorf("Expected period 2023-07-01, got %s", period)
+	}
+}
+
+func TestParseInvoice(t *testing.T) {
+	invoice, err := ParseInvoice(strings.NewReader("Period,Direction,Country,Description,Minutes,Cost\n" +
+		"2023-06,Inbound,AU,Australia DID inbound,5,$0.06\n" +
+		"2023-06, Outbound, US, US outbound,12,0.06\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse invoice: %v", err)
+	}
+	if len(invoice) != 2 {
+		t.Fatalf("Expected 2 invoice lines, got %d", len(invoice))
+	}
+	second := invoice[1]
+	if second.Line != 3 || second.Direction != "Outbound" || second.Country != "US" || second.Minutes != 12 || second.Cost != 0.06 || second.Queue != "" {
+		t.Errorf("Unexpected invoice line: %+v", second)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"period,cost\n2023-06,1\n",
+		"period,minutes,cost\n2023-06,five,1\n",
+		"period,minutes,cost\n2023-06,5,free\n",
+		"period,minutes,cost\n2023-06,5\n",
+	} {
+		if _, err := ParseInvoice(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected error parsing %q", invalid)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	usage := []UsageLine{
+		{UsageKey{testGroup, "Support", "Inbound", "AU", "2023-06"}, 3, 5, 0.06, 0},
+		{UsageKey{testGroup, "Sales", "Inbound", "AU", "2023-06"}, 1, 2, 0.024, 0},
+		{UsageKey{testGroup, "Sales", "Outbound", "US", "2023-06"}, 2, 10, 0.05, 0},
+		{UsageKey{testGroup, "Sales", "Outbound", "GB", "2023-06"}, 1, 3, 0, 3},
+		{UsageKey{testGroup, "Sales", "Outbound", "US", "2023-07"}, 1, 0, 0, 0},
+	}
+	invoice := []InvoiceLine{
+		// Covers both inbound AU queues
+		{Line: 2, UsageKey: UsageKey{Direction: "inbound", Country: "AU", Period: "2023-06"}, Minutes: 7, Cost: 0.084},
+		{Line: 3, UsageKey: UsageKey{Direction: "Outbound", Country: "US", Period: "2023-06"}, Minutes: 12, Cost: 0.06},
+		{Line: 4, UsageKey: UsageKey{Direction: "Outbound", Country: "FR", Period: "2023-06"}, Minutes: 1, Cost: 0.01},
+	}
+
+	result := Reconcile(usage, invoice, DefaultTolerance)
+	statuses := make([]string, len(result.Lines))
+	for i, line := range result.Lines {
+		statuses[i] = string(line.Status)
+	}
+	if strings.Join(statuses, ",") != "matched,mismatch,mismatch,unbilled" {
+		t.Fatalf("Unexpected statuses: %v", statuses)
+	}
+
+	if line := result.Lines[0]; line.ExpectedMinutes != 7 || !closeTo(line.ExpectedCost, 0.084) {
+		t.Errorf("Unexpected matched line: %+v", line)
+	}
+	if line := result.Lines[1]; line.ExpectedMinutes != 10 || line.InvoiceMinutes != 12 {
+		t.Errorf("Unexpected mismatched line: %+v", line)
+	}
+	if line := result.Lines[2]; line.ExpectedMinutes != 0 || line.Country != "FR" {
+		t.Errorf("Expected an invoice line without usage, got %+v", line)
+	}
+	// The zero-minute July call is not reported as unbilled
+	if line := result.Lines[3]; line.Line != 0 || line.Country != "GB" || line.ExpectedMinutes != 3 {
+		t.Errorf("Unexpected unbilled line: %+v", line)
+	}
+
+	if result.InvoiceMinutes != 20 || result.ExpectedMinutes != 20 {
+		t.Errorf("Expected 20 minutes invoiced and expected, got %v and %v", result.InvoiceMinutes, result.ExpectedMinutes)
+	}
+	if discrepancies := result.Discrepancies(); len(discrepancies) != 3 {
+		t.Errorf("Expected 3 discrepancies, got %d", len(discrepancies))
+	}
+
+	// A looser tolerance accepts the two extra minutes
+	loose := Reconcile(usage, invoice[1:2], Tolerance{Minutes: 2, Cost: 0.01})
+	if loose.Lines[0].Status != StatusMatched {
+		t.Errorf("Expected a match within tolerance, got %s", loose.Lines[0].Status)
+	}
+
+	var out bytes.Buffer
+	if err := result.WriteCSV(&out); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	rows := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(rows) != 5 {
+		t.Fatalf("Expected 5 CSV rows, got %d", len(rows))
+	}
+	if rows[2] != "3,2023-06,,,Outbound,US,12,10,0.0600,0.0500,mismatch" {
+		t.Errorf("Unexpected CSV row: %s", rows[2])
+	}
+	if rows[4] != ",2023-06,"+testGroup+",Sales,Outbound,GB,0,3,0.0000,0.0000,unbilled" {
+		t.Errorf("Unexpected CSV row: %s", rows[4])
+	}
+}
+
+func closeTo(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
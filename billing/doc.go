@@ -0,0 +1,24 @@
+// Package billing reconciles Amazon Connect telephony bills against Operata
+// CallSummary events.
+//
+// An Aggregator totals each call's Billing.DurationRoundedMin by Operata
+// group, queue, direction, country and billing period, pricing the minutes
+// with a per-minute RateCard. The country is inferred from the call's
+// CallerID with the phone package. Reconcile then compares the totals with
+// the lines of an invoice CSV and reports every line whose minutes or cost
+// disagree, along with usage the invoice does not cover.
+//
+// Example usage:
+//
+//	card, err := billing.LoadRateCard(rateFile)
+//	aggregator := billing.NewAggregator(card, billing.PeriodMonth)
+//	for event, err := range events.NewReader(archive).All() {
+//		if err == nil {
+//			aggregator.Add(event)
+//		}
+//	}
+//
+//	invoice, err := billing.ParseInvoice(invoiceFile)
+//	result := billing.Reconcile(aggregator.Lines(), invoice, billing.DefaultTolerance)
+//	result.WriteCSV(os.Stdout)
+package billing
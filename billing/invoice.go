@@ -0,0 +1,240 @@
+package billing
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// InvoiceLine is one charge from an invoice. Empty key fields cover every
+// value, so a line without a queue column is compared with all queues.
+type InvoiceLine struct {
+	// Line is the line number in the CSV
+	Line int `json:"line"`
+	UsageKey
+	Minutes float64 `json:"minutes"`
+	Cost    float64 `json:"cost"`
+}
+
+// invoiceColumns maps accepted CSV header names to the field they fill
+var invoiceColumns = map[string]string{
+	"period":    "period",
+	"minutes":   "minutes",
+	"cost":      "cost",
+	"group":     "group",
+	"groupid":   "group",
+	"queue":     "queue",
+	"direction": "direction",
+	"country":   "country",
+}
+
+// ParseInvoice reads an invoice CSV. The header row names the columns:
+// period, minutes and cost are required; group (or groupId), queue,
+// direction and country are optional, and other columns are ignored.
+// Periods must use the same layout as the Aggregator, such as 2023-06.
+func ParseInvoice(r io.Reader) ([]InvoiceLine, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read invoice header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		if field, ok := invoiceColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[field] = i
+		}
+	}
+	for _, required := range []string{"period", "minutes", "cost"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("invoice has no %s column", required)
+		}
+	}
+
+	var lines []InvoiceLine
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read invoice: %w", err)
+		}
+		number, _ := reader.FieldPos(0)
+
+		value := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		line := InvoiceLine{
+			Line: number,
+			UsageKey: UsageKey{
+				GroupID:   value("group"),
+				Queue:     value("queue"),
+				Direction: value("direction"),
+				Country:   value("country"),
+				Period:    value("period"),
+			},
+		}
+		if line.Minutes, err = strconv.ParseFloat(value("minutes"), 64); err != nil {
+			return nil, fmt.Errorf("invoice line %d: invalid minutes %q", number, value("minutes"))
+		}
+		if line.Cost, err = strconv.ParseFloat(strings.TrimPrefix(value("cost"), "$"), 64); err != nil {
+			return nil, fmt.Errorf("invoice line %d: invalid cost %q", number, value("cost"))
+		}
+		lines = append(lines, line)
+	}
+}
+
+// Tolerance is how far invoice totals may differ from usage before they are a discrepancy
+type Tolerance struct {
+	Minutes float64 `json:"minutes"`
+	Cost    float64 `json:"cost"`
+}
+
+// DefaultTolerance requires minutes to match exactly and cost to the cent
+var DefaultTolerance = Tolerance{Minutes: 0, Cost: 0.01}
+
+// Status is the outcome of reconciling one line
+type Status string
+
+// Reconciliation statuses
+const (
+	StatusMatched  Status = "matched"
+	StatusMismatch Status = "mismatch"
+	// StatusUnbilled marks usage that no invoice line covers
+	StatusUnbilled Status = "unbilled"
+)
+
+// ReconciledLine compares one invoice line, or uncovered usage line, with the usage it covers
+type ReconciledLine struct {
+	// Line is the invoice line number; 0 for unbilled usage
+	Line int `json:"line,omitempty"`
+	UsageKey
+	InvoiceMinutes  float64 `json:"invoiceMinutes"`
+	ExpectedMinutes float64 `json:"expectedMinutes"`
+	InvoiceCost     float64 `json:"invoiceCost"`
+	ExpectedCost    float64 `json:"expectedCost"`
+	Status          Status  `json:"status"`
+}
+
+// Reconciliation is the result of comparing an invoice with usage
+type Reconciliation struct {
+	// Lines holds the invoice lines in order, then any unbilled usage
+	Lines           []ReconciledLine `json:"lines"`
+	InvoiceMinutes  float64          `json:"invoiceMinutes"`
+	ExpectedMinutes float64          `json:"expectedMinutes"`
+	InvoiceCost     float64          `json:"invoiceCost"`
+	ExpectedCost    float64          `json:"expectedCost"`
+}
+
+// Reconcile compares each invoice line with the usage lines it covers.
+// Overlapping invoice lines each count the usage they share.
+func Reconcile(usage []UsageLine, invoice []InvoiceLine, tolerance Tolerance) *Reconciliation {
+	result := &Reconciliation{Lines: make([]ReconciledLine, 0, len(invoice))}
+	covered := make([]bool, len(usage))
+
+	for _, charge := range invoice {
+		line := ReconciledLine{
+			Line:           charge.Line,
+			UsageKey:       charge.UsageKey,
+			InvoiceMinutes: charge.Minutes,
+			InvoiceCost:    charge.Cost,
+			Status:         StatusMatched,
+		}
+		for i, u := range usage {
+			if charge.covers(u.UsageKey) {
+				covered[i] = true
+				line.ExpectedMinutes += float64(u.Minutes)
+				line.ExpectedCost += u.Cost
+			}
+		}
+		if math.Abs(line.InvoiceMinutes-line.ExpectedMinutes) > tolerance.Minutes ||
+			math.Abs(line.InvoiceCost-line.ExpectedCost) > tolerance.Cost {
+			line.Status = StatusMismatch
+		}
+		result.add(line)
+	}
+
+	for i, u := range usage {
+		if covered[i] || u.Minutes == 0 {
+			continue
+		}
+		result.add(ReconciledLine{
+			UsageKey:        u.UsageKey,
+			ExpectedMinutes: float64(u.Minutes),
+			ExpectedCost:    u.Cost,
+			Status:          StatusUnbilled,
+		})
+	}
+	return result
+}
+
+func (r *Reconciliation) add(line ReconciledLine) {
+	r.Lines = append(r.Lines, line)
+	r.InvoiceMinutes += line.InvoiceMinutes
+	r.ExpectedMinutes += line.ExpectedMinutes
+	r.InvoiceCost += line.InvoiceCost
+	r.ExpectedCost += line.ExpectedCost
+}
+
+// covers reports whether the invoice line applies to a usage key
+func (l InvoiceLine) covers(key UsageKey) bool {
+	return matchField(l.Period, key.Period) &&
+		matchField(l.GroupID, key.GroupID) &&
+		matchField(l.Queue, key.Queue) &&
+		matchField(l.Direction, key.Direction) &&
+		matchField(l.Country, key.Country)
+}
+
+func matchField(invoice, usage string) bool {
+	return invoice == "" || strings.EqualFold(invoice, usage)
+}
+
+// Discrepancies returns the lines that are not matched
+func (r *Reconciliation) Discrepancies() []ReconciledLine {
+	discrepancies := []ReconciledLine{}
+	for _, line := range r.Lines {
+		if line.Status != StatusMatched {
+			discrepancies = append(discrepancies, line)
+		}
+	}
+	return discrepancies
+}
+
+// WriteCSV writes every reconciled line as CSV with a header row
+func (r *Reconciliation) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{
+		"line", "period", "group", "queue", "direction", "country",
+		"invoiceMinutes", "expectedMinutes", "invoiceCost", "expectedCost", "status",
+	})
+	for _, line := range r.Lines {
+		number := ""
+		if line.Line > 0 {
+			number = strconv.Itoa(line.Line)
+		}
+		_ = writer.Write([]string{
+			number, line.Period, line.GroupID, line.Queue, line.Direction, line.Country,
+			formatMinutes(line.InvoiceMinutes), formatMinutes(line.ExpectedMinutes),
+			formatCost(line.InvoiceCost), formatCost(line.ExpectedCost), string(line.Status),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatMinutes(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatCost(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
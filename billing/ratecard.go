@@ -0,0 +1,71 @@
+package billing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Call directions as reported in CallSummary contacts
+const (
+	DirectionInbound  = "Inbound"
+	DirectionOutbound = "Outbound"
+)
+
+// AnyCountry keys the rate used for countries without their own
+const AnyCountry = "*"
+
+// RateCard holds per-minute prices by direction and country. In JSON:
+//
+//	{"currency": "USD",
+//	 "inbound": {"*": 0.0022, "AU": 0.0120},
+//	 "outbound": {"*": 0.0200, "US": 0.0048}}
+type RateCard struct {
+	Currency string `json:"currency"`
+	// Inbound and Outbound map ISO 3166 country codes, or AnyCountry, to prices per minute
+	Inbound  map[string]float64 `json:"inbound"`
+	Outbound map[string]float64 `json:"outbound"`
+}
+
+// LoadRateCard reads a JSON rate card
+func LoadRateCard(r io.Reader) (*RateCard, error) {
+	var card RateCard
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&card); err != nil {
+		return nil, fmt.Errorf("failed to parse rate card: %w", err)
+	}
+	for _, rates := range []map[string]float64{card.Inbound, card.Outbound} {
+		for country, rate := range rates {
+			if rate < 0 {
+				return nil, fmt.Errorf("invalid rate card: negative rate %g for %s", rate, country)
+			}
+		}
+	}
+	return &card, nil
+}
+
+// Rate returns the per-minute price for a direction and country, falling
+// back to the AnyCountry rate. It returns false when neither is set.
+func (c *RateCard) Rate(direction, country string) (float64, bool) {
+	if c == nil {
+		return 0, false
+	}
+
+	var rates map[string]float64
+	switch {
+	case strings.EqualFold(direction, DirectionInbound):
+		rates = c.Inbound
+	case strings.EqualFold(direction, DirectionOutbound):
+		rates = c.Outbound
+	default:
+		return 0, false
+	}
+
+	if rate, ok := rates[strings.ToUpper(country)]; ok && country != "" {
+		return rate, true
+	}
+	rate, ok := rates[AnyCountry]
+	return rate, ok
+}
@@ -0,0 +1,126 @@
+package billing
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+	"github.com/tommyorndorff/operata-events/phone"
+)
+
+// Period is a billing period layout for time.Format
+type Period string
+
+// Billing periods
+const (
+	PeriodDay   Period = "2006-01-02"
+	PeriodMonth Period = "2006-01"
+)
+
+// UsageKey identifies one line of aggregated usage
+type UsageKey struct {
+	GroupID   string `json:"groupId"`
+	Queue     string `json:"queue"`
+	Direction string `json:"direction"`
+	// Country is the ISO 3166 code inferred from the CallerID, or "" when unknown
+	Country string `json:"country"`
+	Period  string `json:"period"`
+}
+
+// UsageLine totals the calls sharing a UsageKey
+type UsageLine struct {
+	UsageKey
+	Calls   int     `json:"calls"`
+	Minutes int     `json:"minutes"`
+	Cost    float64 `json:"cost"`
+	// UnratedMinutes are minutes the rate card has no price for, excluded from Cost
+	UnratedMinutes int `json:"unratedMinutes"`
+}
+
+// Aggregator totals CallSummary billing minutes into usage lines
+type Aggregator struct {
+	RateCard *RateCard
+	Period   Period
+	// Location is the time zone periods are cut in; UTC when nil
+	Location *time.Location
+
+	lines map[UsageKey]*UsageLine
+}
+
+// NewAggregator creates an aggregator pricing minutes with card
+func NewAggregator(card *RateCard, period Period) *Aggregator {
+	return &Aggregator{RateCard: card, Period: period, lines: make(map[UsageKey]*UsageLine)}
+}
+
+// Add accumulates a CallSummary event; other event types are ignored. The
+// call is dated by its detail timestamp, or the event time without one.
+func (a *Aggregator) Add(event interface{}) {
+	e, ok := event.(*events.CallSummaryEvent)
+	if !ok {
+		return
+	}
+	detail := &e.Detail
+
+	at := detail.Timestamp
+	if at.IsZero() {
+		at = e.Time
+	}
+	location := a.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	key := UsageKey{
+		GroupID:   detail.AccountProperties.OperataGroupID,
+		Queue:     detail.Contact.QueueName,
+		Direction: detail.Contact.Direction,
+		Country:   callerCountry(detail.Contact.CallerID),
+		Period:    at.In(location).Format(string(a.Period)),
+	}
+	line, ok := a.lines[key]
+	if !ok {
+		line = &UsageLine{UsageKey: key}
+		a.lines[key] = line
+	}
+
+	minutes := detail.Billing.DurationRoundedMin
+	line.Calls++
+	line.Minutes += minutes
+	if rate, ok := a.RateCard.Rate(key.Direction, key.Country); ok {
+		line.Cost += float64(minutes) * rate
+	} else {
+		line.UnratedMinutes += minutes
+	}
+}
+
+// callerCountry returns the ISO 3166 country of an international caller ID, or ""
+func callerCountry(callerID string) string {
+	number, err := phone.Parse(callerID)
+	if err != nil {
+		return ""
+	}
+	return number.Country
+}
+
+// Lines returns the usage so far, ordered by period, group, queue, direction and country
+func (a *Aggregator) Lines() []UsageLine {
+	lines := make([]UsageLine, 0, len(a.lines))
+	for _, line := range a.lines {
+		lines = append(lines, *line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].UsageKey.less(lines[j].UsageKey)
+	})
+	return lines
+}
+
+func (k UsageKey) less(other UsageKey) bool {
+	a := []string{k.Period, k.GroupID, k.Queue, k.Direction, k.Country}
+	b := []string{other.Period, other.GroupID, other.Queue, other.Direction, other.Country}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package dedup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// Store records which event keys have been processed
+type Store interface {
+	// Reserve records key for ttl unless it is already recorded and unexpired.
+	// It reports whether the key was newly reserved.
+	Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release forgets key so a later delivery is processed again
+	Release(ctx context.Context, key string) error
+}
+
+// KeyFunc derives the deduplication key of a typed event; "" means the event cannot be deduplicated
+type KeyFunc func(event interface{}) string
+
+// Deduplicator skips events whose key is already reserved in its store
+type Deduplicator struct {
+	Store Store
+	// TTL is how long a processed key is remembered
+	TTL time.Duration
+	// KeyFunc derives event keys; Key when nil
+	KeyFunc KeyFunc
+	// OnDuplicate, when set, is called for every skipped event
+	OnDuplicate func(ctx context.Context, key string, event interface{})
+}
+
+// New creates a Deduplicator that remembers keys for ttl
+func New(store Store, ttl time.Duration) *Deduplicator {
+	return &Deduplicator{Store: store, TTL: ttl, KeyFunc: Key}
+}
+
+// Middleware wraps next so each event key is handled at most once while
+// reserved. Events without a key are always passed through. If next fails,
+// the key is released and next's error returned so the delivery is retried.
+func (d *Deduplicator) Middleware(next events.Handler) events.Handler {
+	return func(ctx context.Context, event interface{}) error {
+		keyFunc := d.KeyFunc
+		if keyFunc == nil {
+			keyFunc = Key
+		}
+
+		key := keyFunc(event)
+		if key == "" {
+			return next(ctx, event)
+		}
+
+		reserved, err := d.Store.Reserve(ctx, key, d.TTL)
+		if err != nil {
+			return fmt.Errorf("failed to reserve event key %s: %w", key, err)
+		}
+		if !reserved {
+			if d.OnDuplicate != nil {
+				d.OnDuplicate(ctx, key, event)
+			}
+			return nil
+		}
+
+		if err := next(ctx, event); err != nil {
+			if releaseErr := d.Store.Release(ctx, key); releaseErr != nil {
+				return fmt.Errorf("%w (and failed to release event key %s: %v)", err, key, releaseErr)
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// Key returns the EventBridge event ID, or the contact ID and detail-type
+// when the event has no ID
+func Key(event interface{}) string {
+	header, ok := events.GetEventHeader(event)
+	if !ok {
+		return ""
+	}
+	if header.ID != "" {
+		return "id:" + header.ID
+	}
+
+	if contactID := events.GetContactID(event); contactID != "" {
+		return "contact:" + contactID + ":" + header.DetailType
+	}
+	return ""
+}
@@ -0,0 +1,242 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+func callEvent(id, contactID string) *events.CallSummaryEvent {
+	return &events.CallSummaryEvent{
+		EventBridgeEvent: events.EventBridgeEvent{ID: id, DetailType: events.EventTypeCallSummary},
+		Detail: events.CallSummaryDetail{
+			Contact: events.CallContact{Contact: events.Contact{ID: events.ContactID{Current: contactID}}},
+		},
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		event    interface{}
+		expected string
+	}{
+		{callEvent("event-1", "contact-1"), "id:event-1"},
+		{callEvent("", "contact-1"), "contact:contact-1:CallSummary"},
+		{callEvent("", ""), ""},
+		{"not an event", ""},
+	}
+
+	for _, test := range tests {
+		if key := Key(test.event); key != test.expected {
+			t.Errorf("Key(%v) = %q, expected %q", test.event, key, test.expected)
+		}
+	}
+}
+
+func TestMiddlewareSkipsDuplicates(t *testing.T) {
+	stores := map[string]Store{
+		"memory":   NewMemoryStore(10),
+		"keyvalue": NewKeyValueStore(NewFakeKeyValueClient(), "operata:"),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			var handled, duplicates int
+			d := New(store, time.Hour)
+			d.OnDuplicate = func(context.Context, string, interface{}) { duplicates++ }
+			handler := d.Middleware(func(context.Context, interface{}) error {
+				handled++
+				return nil
+			})
+
+			for _, event := range []interface{}{
+				callEvent("event-1", "contact-1"),
+				callEvent("event-1", "contact-1"),
+				callEvent("event-2", "contact-1"),
+				callEvent("", "contact-2"),
+				callEvent("", "contact-2"),
+				callEvent("", ""),
+				callEvent("", ""),
+			} {
+				if err := handler(context.Background(), event); err != nil {
+					t.Fatalf("Handler failed: %v", err)
+				}
+			}
+
+			if handled != 5 || duplicates != 2 {
+				t.Errorf("Expected 5 handled and 2 duplicates, got %d and %d", handled, duplicates)
+			}
+		})
+	}
+}
+
+func TestMiddlewareReleasesOnFailure(t *testing.T) {
+	failure := errors.New("downstream unavailable")
+	attempts := 0
+	handler := New(NewMemoryStore(10), time.Hour).Middleware(func(context.Context, interface{}) error {
+		attempts++
+		if attempts == 1 {
+			return failure
+		}
+		return nil
+	})
+
+	event := callEvent("event-1", "contact-1")
+	if err := handler(context.Background(), event); !errors.Is(err, failure) {
+		t.Fatalf("Expected handler failure, got %v", err)
+	}
+	if err := handler(context.Background(), event); err != nil {
+		t.Fatalf("Expected retry to succeed, got %v", err)
+	}
+	if err := handler(context.Background(), event); err != nil {
+		t.Fatalf("Expected duplicate to be skipped, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestMiddlewareStoreError(t *testing.T) {
+	client := NewFakeKeyValueClient()
+	client.Err = errors.New("connection refused")
+	handler := New(NewKeyValueStore(client, ""), time.Hour).Middleware(func(context.Context, interface{}) error {
+		t.Error("Handler should not run when the store is unavailable")
+		return nil
+	})
+
+	if err := handler(context.Background(), callEvent("event-1", "")); !errors.Is(err, client.Err) {
+		t.Errorf("Expected store error, got %v", err)
+	}
+}
+
+func TestMemoryStoreTTLAndCapacity(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, 6, 1, 5, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(2)
+	store.now = func() time.Time { return now }
+
+	for _, key := range []string{"a", "b"} {
+		if ok, _ := store.Reserve(ctx, key, time.Minute); !ok {
+			t.Errorf("Expected %q to be reserved", key)
+		}
+	}
+	if ok, _ := store.Reserve(ctx, "a", time.Minute); ok {
+		t.Error("Expected duplicate reservation of 'a' to fail")
+	}
+
+	// Reserving a third key evicts the least recently used, 'b', since the
+	// duplicate refreshed 'a'
+	if ok, _ := store.Reserve(ctx, "c", time.Minute); !ok {
+		t.Error("Expected 'c' to be reserved")
+	}
+	if store.Len() != 2 {
+		t.Errorf("Expected 2 keys, got %d", store.Len())
+	}
+	if ok, _ := store.Reserve(ctx, "a", time.Minute); ok {
+		t.Error("Expected recently seen 'a' to be kept")
+	}
+	if ok, _ := store.Reserve(ctx, "b", time.Minute); !ok {
+		t.Error("Expected evicted 'b' to be reservable again")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if ok, _ := store.Reserve(ctx, "c", time.Minute); !ok {
+		t.Error("Expected expired 'c' to be reservable again")
+	}
+}
+
+func TestFileStorePersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "dedup.log")
+
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	for _, key := range []string{"a", "b", "short"} {
+		ttl := time.Hour
+		if key == "short" {
+			ttl = -time.Second
+		}
+		if ok, err := store.Reserve(ctx, key, ttl); !ok || err != nil {
+			t.Fatalf("Expected %q to be reserved, got %v %v", key, ok, err)
+		}
+	}
+	if err := store.Release(ctx, "b"); err != nil {
+		t.Fatalf("Failed to release: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	// Simulate a crash mid-write
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	_, _ = f.WriteString(`{"key":"tor`)
+	f.Close()
+
+	reopened, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer reopened.Close()
+
+	expected := map[string]bool{"a": false, "b": true, "short": true}
+	for key, reservable := range expected {
+		if ok, _ := reopened.Reserve(ctx, key, time.Hour); ok != reservable {
+			t.Errorf("Reserve(%q) after reopen = %v, expected %v", key, ok, reservable)
+		}
+	}
+
+	if err := reopened.Compact(); err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+	if ok, _ := reopened.Reserve(ctx, "a", time.Hour); ok {
+		t.Error("Expected 'a' to survive compaction")
+	}
+
+	again, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen compacted store: %v", err)
+	}
+	defer again.Close()
+	if len(again.expires) != 3 {
+		t.Errorf("Expected 3 keys after compaction, got %d", len(again.expires))
+	}
+}
+
+func TestFileStoreUnterminatedFinalLine(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "dedup.log")
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano)
+	if err := os.WriteFile(path, []byte(`{"key":"a","expires":"`+expires+`"}`), 0o644); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	if ok, err := store.Reserve(ctx, "b", time.Hour); !ok || err != nil {
+		t.Fatalf("Expected 'b' to be reserved, got %v %v", ok, err)
+	}
+	store.Close()
+
+	reopened, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Expected appended log to stay readable, got %v", err)
+	}
+	defer reopened.Close()
+	for _, key := range []string{"a", "b"} {
+		if ok, _ := reopened.Reserve(ctx, key, time.Hour); ok {
+			t.Errorf("Expected %q to survive reopening", key)
+		}
+	}
+}
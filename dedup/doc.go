@@ -0,0 +1,24 @@
+// Package dedup drops repeated deliveries of the same Operata event.
+//
+// EventBridge and Kinesis both deliver at least once, so a handler can see
+// the same event more than once. A Deduplicator wraps an events.Handler and
+// reserves each event's key in a Store before calling it; a key that is
+// already reserved is skipped. When the handler fails, the key is released
+// so the retried delivery is processed.
+//
+// Keys are the EventBridge event ID, falling back to the contact ID and
+// detail-type for events without one. Stores are pluggable:
+//
+//   - MemoryStore: an in-process LRU with expiry
+//   - FileStore: a local append-only log that survives restarts
+//   - KeyValueStore: an adapter for shared services such as DynamoDB or
+//     Redis, via the KeyValueClient interface, with an in-memory fake
+//
+// Example usage:
+//
+//	d := dedup.New(dedup.NewMemoryStore(100000), 24*time.Hour)
+//	handler := d.Middleware(func(ctx context.Context, event interface{}) error {
+//		// process the event once
+//		return nil
+//	})
+package dedup
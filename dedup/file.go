@@ -0,0 +1,203 @@
+package dedup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore is a Store backed by an append-only JSON lines log, so processed
+// keys survive restarts of a single process. It is not safe for several
+// processes to share one file.
+type FileStore struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	expires map[string]time.Time
+	// partial is set when the log ends in an unterminated line
+	partial bool
+
+	// now returns the current time; replaced in tests
+	now func() time.Time
+}
+
+// fileRecord is one line of the log; a zero Expires releases the key
+type fileRecord struct {
+	Key     string    `json:"key"`
+	Expires time.Time `json:"expires"`
+}
+
+// OpenFileStore opens or creates the log at path and loads its unexpired keys
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, expires: make(map[string]time.Time), now: time.Now}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dedup log: %w", err)
+	}
+	s.file = file
+
+	return s, nil
+}
+
+// Reserve records key unless it is already recorded and unexpired. The
+// reservation is synced to disk before Reserve returns.
+func (s *FileStore) Reserve(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if expires, ok := s.expires[key]; ok && now.Before(expires) {
+		return false, nil
+	}
+
+	expires := now.Add(ttl).UTC()
+	if err := s.append(fileRecord{Key: key, Expires: expires}); err != nil {
+		return false, err
+	}
+	s.expires[key] = expires
+	return true, nil
+}
+
+// Release forgets key
+func (s *FileStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.expires[key]; !ok {
+		return nil
+	}
+	if err := s.append(fileRecord{Key: key}); err != nil {
+		return err
+	}
+	delete(s.expires, key)
+	return nil
+}
+
+// Compact rewrites the log with only the unexpired keys
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create compacted dedup log: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	now := s.now()
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for key, expires := range s.expires {
+		if !now.Before(expires) {
+			delete(s.expires, key)
+			continue
+		}
+		if err := encoder.Encode(fileRecord{Key: key, Expires: expires}); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write compacted dedup log: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write compacted dedup log: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync compacted dedup log: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close compacted dedup log: %w", err)
+	}
+
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close dedup log: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace dedup log: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen dedup log: %w", err)
+	}
+	s.file = file
+	s.partial = false
+	return nil
+}
+
+// Close closes the log
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+func (s *FileStore) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read dedup log: %w", err)
+	}
+
+	// A final line without a newline, even a valid one, needs terminating
+	// before the next record is appended
+	s.partial = len(data) > 0 && data[len(data)-1] != '\n'
+
+	now := s.now()
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+
+		var record fileRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			// A crash can leave a partial final line; anything else is corruption
+			if i == len(lines)-1 {
+				break
+			}
+			return fmt.Errorf("dedup log line %d: %w", i+1, err)
+		}
+
+		if record.Expires.IsZero() || !now.Before(record.Expires) {
+			delete(s.expires, record.Key)
+			continue
+		}
+		s.expires[record.Key] = record.Expires
+	}
+	return nil
+}
+
+func (s *FileStore) append(record fileRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode dedup record: %w", err)
+	}
+	if s.partial {
+		// Terminate the partial line left by a crash so this record stays parseable
+		data = append([]byte("\n"), data...)
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write dedup log: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync dedup log: %w", err)
+	}
+	s.partial = false
+	return nil
+}
@@ -0,0 +1,77 @@
+package dedup
+
+import (
+	"context"
+	"time"
+)
+
+// KeyValueClient is the minimal contract a shared key-value service must
+// meet to back a Store. Implementations map it onto the service's atomic
+// conditional write, for example:
+//
+//   - DynamoDB: PutItem with ConditionExpression
+//     "attribute_not_exists(pk) OR expiresAt < :now" and a TTL attribute,
+//     treating ConditionalCheckFailedException as false; DeleteItem for Delete
+//   - Redis: SET key 1 NX PX ttl, treating a nil reply as false; DEL for Delete
+type KeyValueClient interface {
+	// SetIfAbsent stores key for ttl only if it is absent or expired, and
+	// reports whether it did
+	SetIfAbsent(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Delete removes key
+	Delete(ctx context.Context, key string) error
+}
+
+// KeyValueStore is a Store backed by a shared key-value service, so several
+// consumers can deduplicate against each other
+type KeyValueStore struct {
+	client KeyValueClient
+	prefix string
+}
+
+// NewKeyValueStore creates a Store that namespaces its keys with prefix
+func NewKeyValueStore(client KeyValueClient, prefix string) *KeyValueStore {
+	return &KeyValueStore{client: client, prefix: prefix}
+}
+
+// Reserve stores the key if it is absent
+func (s *KeyValueStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.client.SetIfAbsent(ctx, s.prefix+key, ttl)
+}
+
+// Release deletes the key
+func (s *KeyValueStore) Release(ctx context.Context, key string) error {
+	return s.client.Delete(ctx, s.prefix+key)
+}
+
+// FakeKeyValueClient is an in-memory KeyValueClient for local development
+// and tests. Err, when set, is returned by every call to simulate an outage.
+type FakeKeyValueClient struct {
+	store *MemoryStore
+	Err   error
+}
+
+// NewFakeKeyValueClient creates an empty FakeKeyValueClient
+func NewFakeKeyValueClient() *FakeKeyValueClient {
+	return &FakeKeyValueClient{store: NewMemoryStore(0)}
+}
+
+// SetIfAbsent stores key unless it is present and unexpired
+func (c *FakeKeyValueClient) SetIfAbsent(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if c.Err != nil {
+		return false, c.Err
+	}
+	return c.store.Reserve(ctx, key, ttl)
+}
+
+// Delete removes key
+func (c *FakeKeyValueClient) Delete(ctx context.Context, key string) error {
+	if c.Err != nil {
+		return c.Err
+	}
+	return c.store.Release(ctx, key)
+}
+
+// Len returns the number of keys stored
+func (c *FakeKeyValueClient) Len() int {
+	return c.store.Len()
+}
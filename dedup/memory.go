@@ -0,0 +1,91 @@
+package dedup
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store that keeps at most a fixed number of
+// keys, evicting the least recently used when full. A key is used when it is
+// reserved and each time it is seen again as a duplicate.
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+
+	// now returns the current time; replaced in tests
+	now func() time.Time
+}
+
+type memoryEntry struct {
+	key     string
+	expires time.Time
+}
+
+// NewMemoryStore creates a MemoryStore holding up to capacity keys; 0 means unbounded
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Reserve records key unless it is already recorded and unexpired
+func (s *MemoryStore) Reserve(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if element, ok := s.entries[key]; ok {
+		if now.Before(element.Value.(*memoryEntry).expires) {
+			s.order.MoveToFront(element)
+			return false, nil
+		}
+		s.order.Remove(element)
+		delete(s.entries, key)
+	}
+
+	s.entries[key] = s.order.PushFront(&memoryEntry{key: key, expires: now.Add(ttl)})
+	s.evict(now)
+	return true, nil
+}
+
+// Release forgets key
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		s.order.Remove(element)
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// Len returns the number of keys held, including any not yet evicted after expiry
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// evict drops expired keys from the tail, then the least recently used keys
+// beyond capacity
+func (s *MemoryStore) evict(now time.Time) {
+	for element := s.order.Back(); element != nil; {
+		entry := element.Value.(*memoryEntry)
+		overCapacity := s.capacity > 0 && s.order.Len() > s.capacity
+		if !overCapacity && now.Before(entry.expires) {
+			break
+		}
+		previous := element.Prev()
+		s.order.Remove(element)
+		delete(s.entries, entry.key)
+		element = previous
+	}
+}
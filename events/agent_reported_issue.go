@@ -1,6 +1,9 @@
 package events
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // AgentReportedIssueDetail represents the detail payload for AgentReportedIssue events
 type AgentReportedIssueDetail struct {
@@ -13,6 +16,33 @@ type AgentReportedIssueDetail struct {
 	SoftphoneError  SoftphoneError `json:"softphoneError"`
 	Timestamp       time.Time      `json:"timestamp"`
 	ID              string         `json:"id"`
+
+	// Extra holds JSON members not recognised by this struct so they survive
+	// a parse and marshal round trip. Unrecognised members of nested objects
+	// are kept too, but are not exposed.
+	Extra map[string]json.RawMessage `json:"-"`
+
+	unknown *unknownFields
+}
+
+// UnmarshalJSON decodes the detail payload, keeping unrecognised members in Extra.
+func (d *AgentReportedIssueDetail) UnmarshalJSON(data []byte) error {
+	type plain AgentReportedIssueDetail
+	var p plain
+	extra, unknown, err := unmarshalWithExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*d = AgentReportedIssueDetail(p)
+	d.Extra = extra
+	d.unknown = unknown
+	return nil
+}
+
+// MarshalJSON encodes the detail payload, re-emitting any members held in Extra.
+func (d AgentReportedIssueDetail) MarshalJSON() ([]byte, error) {
+	type plain AgentReportedIssueDetail
+	return marshalWithExtra(plain(d), d.Extra, d.unknown)
 }
 
 // AgentReportedIssueEvent represents a complete AgentReportedIssue EventBridge event
@@ -1,6 +1,10 @@
 package events
 
-import "time"
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
 
 // CallSummaryDetail represents the detail payload for CallSummary events
 type CallSummaryDetail struct {
@@ -10,6 +14,134 @@ type CallSummaryDetail struct {
 	ServiceAgent      ServiceAgent      `json:"serviceAgent"`
 	Billing           Billing           `json:"billing"`
 	Timestamp         time.Time         `json:"timestamp"`
+
+	// Extra holds JSON members not recognised by this struct so they survive
+	// a parse and marshal round trip. Unrecognised members of nested objects
+	// are kept too, but are not exposed.
+	Extra map[string]json.RawMessage `json:"-"`
+
+	unknown *unknownFields
+}
+
+// UnmarshalJSON decodes the detail payload, keeping unrecognised members in Extra.
+func (d *CallSummaryDetail) UnmarshalJSON(data []byte) error {
+	type plain CallSummaryDetail
+	var p plain
+	extra, unknown, err := unmarshalWithExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*d = CallSummaryDetail(p)
+	d.Extra = extra
+	d.unknown = unknown
+	return nil
+}
+
+// MarshalJSON encodes the detail payload, re-emitting any members held in Extra.
+func (d CallSummaryDetail) MarshalJSON() ([]byte, error) {
+	type plain CallSummaryDetail
+	return marshalWithExtra(plain(d), d.Extra, d.unknown)
+}
+
+// WasQueued reports whether the call was placed in a queue
+func (d *CallSummaryDetail) WasQueued() bool {
+	return !d.Contact.Events.Enqueued.IsZero()
+}
+
+// WasAnswered reports whether the call was connected to an agent
+func (d *CallSummaryDetail) WasAnswered() bool {
+	return !d.Contact.Events.ConnectingToAgent.IsZero()
+}
+
+// QueueWait returns how long the call waited between being enqueued and
+// connecting to an agent. It returns false unless both timestamps are set
+// and in order.
+func (d *CallSummaryDetail) QueueWait() (time.Duration, bool) {
+	events := d.Contact.Events
+	if events.Enqueued.IsZero() || events.ConnectingToAgent.IsZero() || events.ConnectingToAgent.Before(events.Enqueued) {
+		return 0, false
+	}
+	return events.ConnectingToAgent.Sub(events.Enqueued), true
+}
+
+// Duration returns the agent's total interaction time, or 0 for a nil detail
+func (d *CallSummaryDetail) Duration() time.Duration {
+	if d == nil {
+		return 0
+	}
+	return d.ServiceAgent.Interaction.Duration()
+}
+
+// TalkRatio returns the share of the interaction spent talking, from 0 to 1
+func (d *CallSummaryDetail) TalkRatio() float64 {
+	if d == nil {
+		return 0
+	}
+	return d.ServiceAgent.Interaction.TalkRatio()
+}
+
+// HoldRatio returns the share of the interaction spent on hold, from 0 to 1
+func (d *CallSummaryDetail) HoldRatio() float64 {
+	if d == nil {
+		return 0
+	}
+	return d.ServiceAgent.Interaction.HoldRatio()
+}
+
+// MuteRatio returns the share of the interaction spent muted, from 0 to 1
+func (d *CallSummaryDetail) MuteRatio() float64 {
+	if d == nil {
+		return 0
+	}
+	return d.ServiceAgent.Interaction.MuteRatio()
+}
+
+// PacketsLost returns the packets lost in both directions
+func (d *CallSummaryDetail) PacketsLost() int {
+	if d == nil {
+		return 0
+	}
+	return d.WebRTCSession.Metrics.PacketsLost()
+}
+
+// PacketLossPercentage returns the packet loss across both directions
+func (d *CallSummaryDetail) PacketLossPercentage() float64 {
+	if d == nil {
+		return 0
+	}
+	return d.WebRTCSession.Metrics.PacketLossPercentage()
+}
+
+// InboundBitrate returns the average received bitrate in bits per second over the interaction
+func (d *CallSummaryDetail) InboundBitrate() float64 {
+	if d == nil {
+		return 0
+	}
+	return d.WebRTCSession.Metrics.InboundBitrate(d.Duration())
+}
+
+// OutboundBitrate returns the average sent bitrate in bits per second over the interaction
+func (d *CallSummaryDetail) OutboundBitrate() float64 {
+	if d == nil {
+		return 0
+	}
+	return d.WebRTCSession.Metrics.OutboundBitrate(d.Duration())
+}
+
+// QualityLevel returns the call quality level for the average MOS
+func (d *CallSummaryDetail) QualityLevel() CallQualityLevel {
+	if d == nil {
+		return GetCallQualityLevel(0)
+	}
+	return GetCallQualityLevel(d.WebRTCSession.Metrics.MOS.Avg)
+}
+
+// PacketLossLevel returns the packet loss level of the worse direction
+func (d *CallSummaryDetail) PacketLossLevel() PacketLossLevel {
+	if d == nil {
+		return GetPacketLossLevel(0)
+	}
+	return GetPacketLossLevel(d.WebRTCSession.Metrics.WorstPacketLossPercentage())
 }
 
 // CallSummaryEvent represents a complete CallSummary EventBridge event
@@ -52,6 +184,49 @@ type WebRTCMetrics struct {
 	MOS      MOSMetrics      `json:"mos"`
 }
 
+// PacketsLost returns the packets lost in both directions
+func (m *WebRTCMetrics) PacketsLost() int {
+	if m == nil {
+		return 0
+	}
+	return m.Inbound.PacketsLost + m.Outbound.PacketsLost
+}
+
+// PacketLossPercentage returns the packets lost in both directions as a
+// percentage of the packets expected: those received plus lost inbound, and
+// those sent outbound. It returns 0 when no packets were expected.
+func (m *WebRTCMetrics) PacketLossPercentage() float64 {
+	if m == nil {
+		return 0
+	}
+	expected := m.Inbound.PacketsReceived + m.Inbound.PacketsLost + m.Outbound.PacketsSent
+	return ratioOf(m.PacketsLost(), expected) * 100
+}
+
+// WorstPacketLossPercentage returns the higher of the reported inbound and outbound packet loss
+func (m *WebRTCMetrics) WorstPacketLossPercentage() float64 {
+	if m == nil {
+		return 0
+	}
+	return max(m.Inbound.PacketsLostPercentage, m.Outbound.PacketsLostPercentage)
+}
+
+// InboundBitrate returns the average received bitrate in bits per second over d
+func (m *WebRTCMetrics) InboundBitrate(d time.Duration) float64 {
+	if m == nil {
+		return 0
+	}
+	return bitrate(m.Inbound.BytesReceived, d)
+}
+
+// OutboundBitrate returns the average sent bitrate in bits per second over d
+func (m *WebRTCMetrics) OutboundBitrate(d time.Duration) float64 {
+	if m == nil {
+		return 0
+	}
+	return bitrate(m.Outbound.BytesSent, d)
+}
+
 // InboundMetrics represents inbound WebRTC metrics
 type InboundMetrics struct {
 	PacketsReceived       int          `json:"packetsReceived"`
@@ -188,7 +363,56 @@ type Interaction struct {
 	OnMuteDurationSec  int `json:"onMuteDurationSec"`
 }
 
+// Duration returns the total interaction time
+func (i *Interaction) Duration() time.Duration {
+	if i == nil || i.TotalDurationSec <= 0 {
+		return 0
+	}
+	return time.Duration(i.TotalDurationSec) * time.Second
+}
+
+// TalkRatio returns the share of the interaction spent talking, or 0 when it has no duration
+func (i *Interaction) TalkRatio() float64 {
+	if i == nil {
+		return 0
+	}
+	return ratioOf(i.TalkingDurationSec, i.TotalDurationSec)
+}
+
+// HoldRatio returns the share of the interaction spent on hold, or 0 when it has no duration
+func (i *Interaction) HoldRatio() float64 {
+	if i == nil {
+		return 0
+	}
+	return ratioOf(i.OnHoldDurationSec, i.TotalDurationSec)
+}
+
+// MuteRatio returns the share of the interaction spent muted, or 0 when it has no duration
+func (i *Interaction) MuteRatio() float64 {
+	if i == nil {
+		return 0
+	}
+	return ratioOf(i.OnMuteDurationSec, i.TotalDurationSec)
+}
+
 // Billing represents billing information
 type Billing struct {
 	DurationRoundedMin int `json:"durationRoundedMin"`
 }
+
+// ratioOf returns part/total, or 0 when either is not positive. Parts longer
+// than the total, from inconsistent durations, are capped at 1.
+func ratioOf(part, total int) float64 {
+	if part <= 0 || total <= 0 {
+		return 0
+	}
+	return math.Min(float64(part)/float64(total), 1)
+}
+
+// bitrate returns bytes over d in bits per second, or 0 when d is not positive
+func bitrate(bytes int, d time.Duration) float64 {
+	if bytes <= 0 || d <= 0 {
+		return 0
+	}
+	return float64(bytes) * 8 / d.Seconds()
+}
@@ -0,0 +1,191 @@
+package events
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestQueueWait(t *testing.T) {
+	enqueued := time.Date(2023, 6, 1, 4, 59, 30, 795000000, time.UTC)
+	connected := time.Date(2023, 6, 1, 4, 59, 51, 523000000, time.UTC)
+
+	tests := []struct {
+		name     string
+		events   CallEvents
+		queued   bool
+		answered bool
+		wait     time.Duration
+		ok       bool
+	}{
+		{"answered", CallEvents{Enqueued: enqueued, ConnectingToAgent: connected}, true, true, 20728 * time.Millisecond, true},
+		{"abandoned", CallEvents{Enqueued: enqueued}, true, false, 0, false},
+		{"not queued", CallEvents{ConnectingToAgent: connected}, false, true, 0, false},
+		{"out of order", CallEvents{Enqueued: connected, ConnectingToAgent: enqueued}, true, true, 0, false},
+		{"empty", CallEvents{}, false, false, 0, false},
+	}
+
+	for _, test := range tests {
+		detail := CallSummaryDetail{Contact: CallContact{Events: test.events}}
+		if queued := detail.WasQueued(); queued != test.queued {
+			t.Errorf("%s: WasQueued() = %v, expected %v", test.name, queued, test.queued)
+		}
+		if answered := detail.WasAnswered(); answered != test.answered {
+			t.Errorf("%s: WasAnswered() = %v, expected %v", test.name, answered, test.answered)
+		}
+		if wait, ok := detail.QueueWait(); wait != test.wait || ok != test.ok {
+			t.Errorf("%s: QueueWait() = %v, %v, expected %v, %v", test.name, wait, ok, test.wait, test.ok)
+		}
+	}
+}
+
+func TestInteractionRatios(t *testing.T) {
+	tests := []struct {
+		name        string
+		interaction *Interaction
+		duration    time.Duration
+		talk        float64
+		hold        float64
+		mute        float64
+	}{
+		{"typical", &Interaction{TotalDurationSec: 200, TalkingDurationSec: 150, OnHoldDurationSec: 40, OnMuteDurationSec: 10}, 200 * time.Second, 0.75, 0.2, 0.05},
+		{"zero length", &Interaction{TalkingDurationSec: 5, OnHoldDurationSec: 5}, 0, 0, 0, 0},
+		{"parts longer than total", &Interaction{TotalDurationSec: 100, TalkingDurationSec: 130, OnHoldDurationSec: 100, OnMuteDurationSec: 101}, 100 * time.Second, 1, 1, 1},
+		{"negative length", &Interaction{TotalDurationSec: -1, TalkingDurationSec: 5}, 0, 0, 0, 0},
+		{"empty", &Interaction{}, 0, 0, 0, 0},
+		{"nil", nil, 0, 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		if duration := test.interaction.Duration(); duration != test.duration {
+			t.Errorf("%s: Duration() = %v, expected %v", test.name, duration, test.duration)
+		}
+		if talk := test.interaction.TalkRatio(); talk != test.talk {
+			t.Errorf("%s: TalkRatio() = %v, expected %v", test.name, talk, test.talk)
+		}
+		if hold := test.interaction.HoldRatio(); hold != test.hold {
+			t.Errorf("%s: HoldRatio() = %v, expected %v", test.name, hold, test.hold)
+		}
+		if mute := test.interaction.MuteRatio(); mute != test.mute {
+			t.Errorf("%s: MuteRatio() = %v, expected %v", test.name, mute, test.mute)
+		}
+	}
+}
+
+func TestWebRTCMetricsDerived(t *testing.T) {
+	tests := []struct {
+		name     string
+		metrics  *WebRTCMetrics
+		duration time.Duration
+		lost     int
+		loss     float64
+		worst    float64
+		inbound  float64
+		outbound float64
+	}{
+		{
+			name: "typical",
+			metrics: &WebRTCMetrics{
+				Inbound:  InboundMetrics{PacketsReceived: 90, PacketsLost: 10, PacketsLostPercentage: 10, BytesReceived: 8000},
+				Outbound: OutboundMetrics{PacketsSent: 100, PacketsLost: 5, PacketsLostPercentage: 5, BytesSent: 4000},
+			},
+			duration: 2 * time.Second,
+			lost:     15,
+			loss:     7.5,
+			worst:    10,
+			inbound:  32000,
+			outbound: 16000,
+		},
+		{
+			name: "zero duration",
+			metrics: &WebRTCMetrics{
+				Inbound:  InboundMetrics{BytesReceived: 8000},
+				Outbound: OutboundMetrics{BytesSent: 4000, PacketsLostPercentage: 2},
+			},
+			worst: 2,
+		},
+		{
+			name:     "no packets",
+			metrics:  &WebRTCMetrics{},
+			duration: time.Minute,
+		},
+		{
+			name:     "nil",
+			duration: time.Minute,
+		},
+	}
+
+	for _, test := range tests {
+		if lost := test.metrics.PacketsLost(); lost != test.lost {
+			t.Errorf("%s: PacketsLost() = %d, expected %d", test.name, lost, test.lost)
+		}
+		if loss := test.metrics.PacketLossPercentage(); loss != test.loss {
+			t.Errorf("%s: PacketLossPercentage() = %v, expected %v", test.name, loss, test.loss)
+		}
+		if worst := test.metrics.WorstPacketLossPercentage(); worst != test.worst {
+			t.Errorf("%s: WorstPacketLossPercentage() = %v, expected %v", test.name, worst, test.worst)
+		}
+		if inbound := test.metrics.InboundBitrate(test.duration); inbound != test.inbound {
+			t.Errorf("%s: InboundBitrate() = %v, expected %v", test.name, inbound, test.inbound)
+		}
+		if outbound := test.metrics.OutboundBitrate(test.duration); outbound != test.outbound {
+			t.Errorf("%s: OutboundBitrate() = %v, expected %v", test.name, outbound, test.outbound)
+		}
+	}
+}
+
+func TestCallSummaryDetailDerived(t *testing.T) {
+	data, err := os.ReadFile("testdata/call_summary.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	var event CallSummaryEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	detail := &event.Detail
+
+	if duration := detail.Duration(); duration != 14*time.Second {
+		t.Errorf("Expected duration 14s, got %v", duration)
+	}
+	if talk := detail.TalkRatio(); talk != 1 {
+		t.Errorf("Expected talk ratio 1, got %v", talk)
+	}
+	if hold, mute := detail.HoldRatio(), detail.MuteRatio(); hold != 0 || mute != 0 {
+		t.Errorf("Expected hold and mute ratios 0, got %v and %v", hold, mute)
+	}
+	if lost := detail.PacketsLost(); lost != 22 {
+		t.Errorf("Expected 22 packets lost, got %d", lost)
+	}
+	// 22 lost of 636 + 12 received and 786 sent
+	if loss := detail.PacketLossPercentage(); math.Abs(loss-22.0/1434*100) > 1e-9 {
+		t.Errorf("Expected packet loss %.4f%%, got %.4f%%", 22.0/1434*100, loss)
+	}
+	if inbound := detail.InboundBitrate(); math.Abs(inbound-67178*8/14.0) > 1e-9 {
+		t.Errorf("Expected inbound bitrate %.1f, got %.1f", 67178*8/14.0, inbound)
+	}
+	if outbound := detail.OutboundBitrate(); math.Abs(outbound-65585*8/14.0) > 1e-9 {
+		t.Errorf("Expected outbound bitrate %.1f, got %.1f", 65585*8/14.0, outbound)
+	}
+	if level := detail.QualityLevel(); level != QualityGood {
+		t.Errorf("Expected quality %s, got %s", QualityGood, level)
+	}
+	if level := detail.PacketLossLevel(); level != PacketLossNoticeable {
+		t.Errorf("Expected packet loss level %s, got %s", PacketLossNoticeable, level)
+	}
+
+	var empty *CallSummaryDetail
+	if empty.Duration() != 0 || empty.TalkRatio() != 0 || empty.HoldRatio() != 0 || empty.MuteRatio() != 0 ||
+		empty.PacketsLost() != 0 || empty.PacketLossPercentage() != 0 ||
+		empty.InboundBitrate() != 0 || empty.OutboundBitrate() != 0 {
+		t.Error("Expected zero metrics for a nil detail")
+	}
+	if level := empty.QualityLevel(); level != QualityBad {
+		t.Errorf("Expected quality %s for a nil detail, got %s", QualityBad, level)
+	}
+	if level := empty.PacketLossLevel(); level != PacketLossMinimal {
+		t.Errorf("Expected packet loss level %s for a nil detail, got %s", PacketLossMinimal, level)
+	}
+}
@@ -0,0 +1,296 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// unknownFields holds the JSON members a struct does not declare, found while
+// decoding a detail payload, so they can be written back when it is encoded.
+// A node describes one JSON value: the unknown members of an object, the
+// unknown members nested in its known object and array members, or those
+// nested in the elements of an array.
+type unknownFields struct {
+	members map[string]json.RawMessage
+	fields  map[string]*unknownFields
+	items   map[int]*unknownFields
+}
+
+// jsonField is a struct field as encoding/json names it
+type jsonField struct {
+	name string
+	typ  reflect.Type
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unmarshalWithExtra decodes data into v and returns the members of the JSON
+// object that do not correspond to any field of v, at the top level and
+// nested within its fields. v must be a pointer to a struct. Matching follows
+// encoding/json, so keys are compared case-insensitively.
+func unmarshalWithExtra(data []byte, v interface{}) (map[string]json.RawMessage, *unknownFields, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, nil, err
+	}
+
+	unknown := collectUnknown(data, reflect.TypeOf(v).Elem(), true)
+	if unknown == nil {
+		return nil, nil, nil
+	}
+	extra := unknown.members
+	unknown.members = nil
+	if len(unknown.fields) == 0 {
+		unknown = nil
+	}
+	return extra, unknown, nil
+}
+
+// collectUnknown walks a JSON value alongside the Go type it decodes into and
+// returns its unknown members, or nil if there are none. Types that decode
+// themselves are not walked unless root is set, since they keep their own.
+func collectUnknown(data []byte, t reflect.Type, root bool) *unknownFields {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if !root && reflect.PointerTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	node := &unknownFields{}
+	switch t.Kind() {
+	case reflect.Struct:
+		var members map[string]json.RawMessage
+		if json.Unmarshal(data, &members) != nil {
+			return nil
+		}
+		fields := jsonFields(t)
+		for key, value := range members {
+			field, ok := findField(fields, key)
+			if !ok {
+				if node.members == nil {
+					node.members = make(map[string]json.RawMessage)
+				}
+				node.members[key] = value
+				continue
+			}
+			if child := collectUnknown(value, field.typ, false); child != nil {
+				if node.fields == nil {
+					node.fields = make(map[string]*unknownFields)
+				}
+				node.fields[field.name] = child
+			}
+		}
+
+	case reflect.Map:
+		var members map[string]json.RawMessage
+		if t.Key().Kind() != reflect.String || json.Unmarshal(data, &members) != nil {
+			return nil
+		}
+		for key, value := range members {
+			if child := collectUnknown(value, t.Elem(), false); child != nil {
+				if node.fields == nil {
+					node.fields = make(map[string]*unknownFields)
+				}
+				node.fields[key] = child
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return nil
+		}
+		for i, item := range items {
+			if child := collectUnknown(item, t.Elem(), false); child != nil {
+				if node.items == nil {
+					node.items = make(map[int]*unknownFields)
+				}
+				node.items[i] = child
+			}
+		}
+
+	default:
+		return nil
+	}
+
+	if node.members == nil && node.fields == nil && node.items == nil {
+		return nil
+	}
+	return node
+}
+
+// marshalWithExtra encodes v, writes back the unknown members found nested in
+// its fields, and appends the extra members, sorted by key, to the resulting
+// JSON object. Extra members named like a field of v are dropped, since they
+// would otherwise be emitted twice.
+func marshalWithExtra(v interface{}, extra map[string]json.RawMessage, unknown *unknownFields) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || (len(extra) == 0 && unknown == nil) {
+		return data, err
+	}
+
+	node := &unknownFields{}
+	if unknown != nil {
+		node.fields = unknown.fields
+	}
+	fields := jsonFields(reflect.TypeOf(v))
+	for key, value := range extra {
+		if _, ok := findField(fields, key); ok {
+			continue
+		}
+		if node.members == nil {
+			node.members = make(map[string]json.RawMessage)
+		}
+		node.members[key] = value
+	}
+
+	var buf bytes.Buffer
+	if err := node.write(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// write copies the JSON value data to buf with the unknown members restored.
+// Members and elements the value no longer has are skipped.
+func (n *unknownFields) write(buf *bytes.Buffer, data []byte) error {
+	if n == nil {
+		return json.Compact(buf, data)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		buf.WriteByte('{')
+		seen := make(map[string]bool)
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key, ok := token.(string)
+			if !ok {
+				return fmt.Errorf("unexpected object key %v", token)
+			}
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return err
+			}
+
+			if len(seen) > 0 {
+				buf.WriteByte(',')
+			}
+			seen[strings.ToLower(key)] = true
+			if err := writeKey(buf, key); err != nil {
+				return err
+			}
+			if err := n.fields[key].write(buf, value); err != nil {
+				return err
+			}
+		}
+
+		keys := make([]string, 0, len(n.members))
+		for key := range n.members {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if seen[strings.ToLower(key)] {
+				continue
+			}
+			if len(seen) > 0 {
+				buf.WriteByte(',')
+			}
+			seen[strings.ToLower(key)] = true
+			if err := writeKey(buf, key); err != nil {
+				return err
+			}
+			if err := json.Compact(buf, n.members[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+	case json.Delim('['):
+		buf.WriteByte('[')
+		for i := 0; decoder.More(); i++ {
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return err
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := n.items[i].write(buf, value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+
+	default:
+		return json.Compact(buf, data)
+	}
+	return nil
+}
+
+func writeKey(buf *bytes.Buffer, key string) error {
+	name, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	buf.Write(name)
+	buf.WriteByte(':')
+	return nil
+}
+
+// jsonFields returns the fields encoding/json uses for the exported fields of
+// struct type t, including promoted fields of embedded structs.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, jsonField{name: name, typ: field.Type})
+	}
+	return fields
+}
+
+// findField returns the field a JSON key decodes into, preferring an exact
+// match as encoding/json does
+func findField(fields []jsonField, key string) (jsonField, bool) {
+	for _, field := range fields {
+		if field.name == key {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field.name, key) {
+			return field, true
+		}
+	}
+	return jsonField{}, false
+}
@@ -0,0 +1,6 @@
+package events
+
+import "context"
+
+// Handler processes a typed event as returned by ParseEventBridgeEvent
+type Handler func(ctx context.Context, event interface{}) error
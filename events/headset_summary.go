@@ -1,10 +1,39 @@
 package events
 
+import "encoding/json"
+
 // HeadsetSummaryDetail represents the detail payload for HeadsetSummary events
 type HeadsetSummaryDetail struct {
 	AccountProperties AccountProperties `json:"accountProperties"`
 	Contact           HeadsetContact    `json:"contact"`
 	Headset           Headset           `json:"headset"`
+
+	// Extra holds JSON members not recognised by this struct so they survive
+	// a parse and marshal round trip. Unrecognised members of nested objects
+	// are kept too, but are not exposed.
+	Extra map[string]json.RawMessage `json:"-"`
+
+	unknown *unknownFields
+}
+
+// UnmarshalJSON decodes the detail payload, keeping unrecognised members in Extra.
+func (d *HeadsetSummaryDetail) UnmarshalJSON(data []byte) error {
+	type plain HeadsetSummaryDetail
+	var p plain
+	extra, unknown, err := unmarshalWithExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*d = HeadsetSummaryDetail(p)
+	d.Extra = extra
+	d.unknown = unknown
+	return nil
+}
+
+// MarshalJSON encodes the detail payload, re-emitting any members held in Extra.
+func (d HeadsetSummaryDetail) MarshalJSON() ([]byte, error) {
+	type plain HeadsetSummaryDetail
+	return marshalWithExtra(plain(d), d.Extra, d.unknown)
 }
 
 // HeadsetSummaryEvent represents a complete HeadsetSummary EventBridge event
@@ -1,10 +1,39 @@
 package events
 
+import "encoding/json"
+
 // InsightsSummaryDetail represents the detail payload for InsightsSummary events
 type InsightsSummaryDetail struct {
 	AccountProperties AccountProperties `json:"accountProperties"`
 	Contact           Contact           `json:"contact"`
 	Insights          Insights          `json:"insights"`
+
+	// Extra holds JSON members not recognised by this struct so they survive
+	// a parse and marshal round trip. Unrecognised members of nested objects
+	// are kept too, but are not exposed.
+	Extra map[string]json.RawMessage `json:"-"`
+
+	unknown *unknownFields
+}
+
+// UnmarshalJSON decodes the detail payload, keeping unrecognised members in Extra.
+func (d *InsightsSummaryDetail) UnmarshalJSON(data []byte) error {
+	type plain InsightsSummaryDetail
+	var p plain
+	extra, unknown, err := unmarshalWithExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*d = InsightsSummaryDetail(p)
+	d.Extra = extra
+	d.unknown = unknown
+	return nil
+}
+
+// MarshalJSON encodes the detail payload, re-emitting any members held in Extra.
+func (d InsightsSummaryDetail) MarshalJSON() ([]byte, error) {
+	type plain InsightsSummaryDetail
+	return marshalWithExtra(plain(d), d.Extra, d.unknown)
 }
 
 // InsightsSummaryEvent represents a complete InsightsSummary EventBridge event
@@ -0,0 +1,140 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// gzipMagic is the two-byte header that starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// RecordError reports an event in a stream that is valid JSON but could not
+// be parsed as an event. Reading can continue past it.
+type RecordError struct {
+	// Offset is the byte offset of the record in the (decompressed) stream
+	Offset int64
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads EventBridge events one at a time from newline-delimited or
+// concatenated JSON, such as the objects Firehose writes to S3. Gzipped
+// input is detected and decompressed automatically.
+type Reader struct {
+	src     io.Reader
+	decoder *json.Decoder
+	raw     json.RawMessage
+	offset  int64
+	err     error
+}
+
+// NewReader creates a Reader over r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{src: r}
+}
+
+// Next returns the next event, parsed with ParseEventBridgeEvent. It returns
+// a *RecordError for a record that cannot be parsed, after which Next may be
+// called again, and io.EOF at the end of the stream. Any other error, such as
+// malformed JSON or a corrupt gzip stream, ends the stream and is returned by
+// every later call.
+func (r *Reader) Next() (interface{}, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.decoder == nil {
+		if err := r.init(); err != nil {
+			r.err = err
+			return nil, err
+		}
+	}
+
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		if !errors.Is(err, io.EOF) {
+			err = fmt.Errorf("failed to read event stream: %w", err)
+		}
+		r.err = err
+		r.raw = nil
+		return nil, err
+	}
+
+	r.raw = raw
+	r.offset = r.decoder.InputOffset() - int64(len(raw))
+
+	event, err := ParseEventBridgeEvent(raw)
+	if err != nil {
+		return nil, &RecordError{Offset: r.offset, Err: err}
+	}
+	return event, nil
+}
+
+// Raw returns the JSON of the record last returned by Next. It is valid until the next call.
+func (r *Reader) Raw() json.RawMessage {
+	return r.raw
+}
+
+// Offset returns the byte offset of the record last returned by Next in the
+// (decompressed) stream
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// All returns an iterator over the remaining events. Records that cannot be
+// parsed are yielded as a nil event with a *RecordError and iteration
+// continues; any other error is yielded once and ends iteration.
+func (r *Reader) All() iter.Seq2[interface{}, error] {
+	return func(yield func(interface{}, error) bool) {
+		for {
+			event, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			var recordErr *RecordError
+			if err != nil && !errors.As(err, &recordErr) {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(event, err) {
+				return
+			}
+		}
+	}
+}
+
+// init wraps the source in a gzip reader when it starts with the gzip header
+func (r *Reader) init() error {
+	buffered := bufio.NewReader(r.src)
+
+	header, err := buffered.Peek(len(gzipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read event stream: %w", err)
+	}
+
+	var src io.Reader = buffered
+	if bytes.Equal(header, gzipMagic) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+		src = gz
+	}
+
+	r.decoder = json.NewDecoder(src)
+	return nil
+}
@@ -0,0 +1,142 @@
+package events
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func compactFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		t.Fatalf("Failed to compact fixture: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestReaderNDJSONAndConcatenated(t *testing.T) {
+	call := compactFixture(t, "call_summary.json")
+	insights := compactFixture(t, "insights_summary.json")
+
+	inputs := map[string][]byte{
+		"ndjson":       bytes.Join([][]byte{call, insights}, []byte("\n")),
+		"concatenated": append(append([]byte{}, call...), insights...),
+		"pretty":       append(append([]byte("  \n"), call...), append([]byte("\n\n"), insights...)...),
+	}
+
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			reader := NewReader(bytes.NewReader(input))
+
+			first, err := reader.Next()
+			if err != nil {
+				t.Fatalf("Failed to read first event: %v", err)
+			}
+			if _, ok := first.(*CallSummaryEvent); !ok {
+				t.Errorf("Expected CallSummaryEvent, got %T", first)
+			}
+			if !bytes.Equal(reader.Raw(), call) {
+				t.Errorf("Expected Raw to return the first record")
+			}
+
+			second, err := reader.Next()
+			if err != nil {
+				t.Fatalf("Failed to read second event: %v", err)
+			}
+			if _, ok := second.(*InsightsSummaryEvent); !ok {
+				t.Errorf("Expected InsightsSummaryEvent, got %T", second)
+			}
+			if got := input[reader.Offset() : reader.Offset()+int64(len(insights))]; !bytes.Equal(got, insights) {
+				t.Errorf("Offset %d does not point at the second record", reader.Offset())
+			}
+
+			if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+				t.Errorf("Expected io.EOF, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReaderGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	for _, name := range []string{"headset_summary.json", "agent_reported_issue.json"} {
+		_, _ = gz.Write(compactFixture(t, name))
+		_, _ = gz.Write([]byte("\n"))
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to gzip fixtures: %v", err)
+	}
+
+	var types []string
+	for event, err := range NewReader(&buf).All() {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		header, _ := GetEventHeader(event)
+		types = append(types, header.DetailType)
+	}
+
+	if strings.Join(types, ",") != "HeadsetSummary,AgentReportedIssue" {
+		t.Errorf("Unexpected event types: %v", types)
+	}
+}
+
+func TestReaderRecordErrors(t *testing.T) {
+	input := `{"detail-type": "CallSummary", "detail": {"contact": "not-an-object"}}
+{"detail-type": "InsightsSummary", "detail": {}}
+{"detail-type": `
+
+	var events []interface{}
+	var errs []error
+	for event, err := range NewReader(strings.NewReader(input)).All() {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		events = append(events, event)
+	}
+
+	if len(events) != 1 {
+		t.Errorf("Expected 1 event, got %d", len(events))
+	}
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %d: %v", len(errs), errs)
+	}
+
+	var recordErr *RecordError
+	if !errors.As(errs[0], &recordErr) || recordErr.Offset != 0 {
+		t.Errorf("Expected RecordError at offset 0, got %v", errs[0])
+	}
+	if errors.As(errs[1], &recordErr) {
+		t.Errorf("Expected truncated stream to be a fatal error, got %v", errs[1])
+	}
+}
+
+func TestReaderStopsWhenYieldReturnsFalse(t *testing.T) {
+	input := bytes.Join([][]byte{compactFixture(t, "call_summary.json"), compactFixture(t, "insights_summary.json")}, nil)
+	reader := NewReader(bytes.NewReader(input))
+
+	for range reader.All() {
+		break
+	}
+
+	event, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Expected to resume reading, got %v", err)
+	}
+	if _, ok := event.(*InsightsSummaryEvent); !ok {
+		t.Errorf("Expected InsightsSummaryEvent, got %T", event)
+	}
+}
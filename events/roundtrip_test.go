@@ -0,0 +1,176 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRoundTripFixtures(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		extraKey string
+	}{
+		{"call_summary.json", "recording"},
+		{"insights_summary.json", "insightsVersion"},
+		{"headset_summary.json", "headsetConnection"},
+		{"agent_reported_issue.json", "reportChannel"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", test.fixture))
+			if err != nil {
+				t.Fatalf("Failed to read fixture: %v", err)
+			}
+
+			event, err := ParseEventBridgeEvent(data)
+			if err != nil {
+				t.Fatalf("Failed to parse fixture: %v", err)
+			}
+
+			if _, ok := extraOf(event)[test.extraKey]; !ok {
+				t.Errorf("Expected unknown member %q to be captured in Extra", test.extraKey)
+			}
+
+			output, err := json.Marshal(event)
+			if err != nil {
+				t.Fatalf("Failed to marshal event: %v", err)
+			}
+
+			var expected bytes.Buffer
+			if err := json.Compact(&expected, data); err != nil {
+				t.Fatalf("Failed to compact fixture: %v", err)
+			}
+
+			if !bytes.Equal(expected.Bytes(), output) {
+				t.Errorf("Round trip mismatch\nexpected: %s\n     got: %s", expected.Bytes(), output)
+			}
+		})
+	}
+}
+
+func TestDetailExtraCaseInsensitiveMatch(t *testing.T) {
+	var detail InsightsSummaryDetail
+	data := `{"Insights": {"count": 2}, "newField": 1}`
+	if err := json.Unmarshal([]byte(data), &detail); err != nil {
+		t.Fatalf("Failed to unmarshal detail: %v", err)
+	}
+
+	if detail.Insights.Count != 2 {
+		t.Errorf("Expected insights count 2, got %d", detail.Insights.Count)
+	}
+	if len(detail.Extra) != 1 {
+		t.Errorf("Expected 1 extra member, got %d: %v", len(detail.Extra), detail.Extra)
+	}
+	if string(detail.Extra["newField"]) != "1" {
+		t.Errorf("Expected extra member newField=1, got %s", detail.Extra["newField"])
+	}
+}
+
+func TestDetailWithoutExtraMarshalsUnchanged(t *testing.T) {
+	detail := InsightsSummaryDetail{Insights: Insights{Count: 1}}
+	output, err := json.Marshal(detail)
+	if err != nil {
+		t.Fatalf("Failed to marshal detail: %v", err)
+	}
+
+	if bytes.Contains(output, []byte("Extra")) {
+		t.Errorf("Expected Extra to be omitted from output, got %s", output)
+	}
+}
+
+func TestNestedUnknownFieldsSurviveEdits(t *testing.T) {
+	data := `{"contact":{"id":{"current":"c1","previous":"c0"},"queueName":"Support","channel":"VOICE"},` +
+		`"webRTCSession":{"metrics":{"mos":{"avg":4.1,"p95":4.4},"concealment":{"avg":0.4}}}}`
+
+	var detail CallSummaryDetail
+	if err := json.Unmarshal([]byte(data), &detail); err != nil {
+		t.Fatalf("Failed to unmarshal detail: %v", err)
+	}
+	if len(detail.Extra) != 0 {
+		t.Errorf("Expected no top-level extra members, got %v", detail.Extra)
+	}
+	detail.Contact.QueueName = "Sales"
+	detail.WebRTCSession.Metrics.MOS.Avg = 3.9
+
+	output, err := json.Marshal(detail)
+	if err != nil {
+		t.Fatalf("Failed to marshal detail: %v", err)
+	}
+	for _, expected := range []string{
+		`"previous":"c0"`,
+		`"queueName":"Sales","callerId":"","channel":"VOICE"}`,
+		`"avg":3.9,"p95":4.4}`,
+		`"concealment":{"avg":0.4}}`,
+	} {
+		if !bytes.Contains(output, []byte(expected)) {
+			t.Errorf("Expected output to contain %s, got %s", expected, output)
+		}
+	}
+}
+
+func TestNestedUnknownFieldsInArrays(t *testing.T) {
+	var detail InsightsSummaryDetail
+	data := `{"insights":{"count":2,"tags":[{"description":"a","confidence":0.9},{"description":"b"}]}}`
+	if err := json.Unmarshal([]byte(data), &detail); err != nil {
+		t.Fatalf("Failed to unmarshal detail: %v", err)
+	}
+
+	output, err := json.Marshal(detail)
+	if err != nil {
+		t.Fatalf("Failed to marshal detail: %v", err)
+	}
+	if !bytes.Contains(output, []byte(`"tags":[{"description":"a","confidence":0.9},{"description":"b"}]`)) {
+		t.Errorf("Expected array element members to survive, got %s", output)
+	}
+
+	// Dropping elements drops their unknown members with them
+	detail.Insights.Tags = nil
+	if output, err = json.Marshal(detail); err != nil || bytes.Contains(output, []byte("confidence")) {
+		t.Errorf("Expected removed element members to be dropped, got %s, %v", output, err)
+	}
+}
+
+func TestExtraCollidingWithKnownFieldDropped(t *testing.T) {
+	detail := InsightsSummaryDetail{
+		Insights: Insights{Count: 1},
+		Extra: map[string]json.RawMessage{
+			"insights":  json.RawMessage(`{"count":9}`),
+			"Contact":   json.RawMessage(`{}`),
+			"newMember": json.RawMessage(`true`),
+		},
+	}
+	output, err := json.Marshal(detail)
+	if err != nil {
+		t.Fatalf("Failed to marshal detail: %v", err)
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(output, &members); err != nil {
+		t.Fatalf("Invalid output %s: %v", output, err)
+	}
+	if bytes.Count(output, []byte(`"insights"`)) != 1 || bytes.Contains(output, []byte(`"Contact"`)) {
+		t.Errorf("Expected colliding extra members to be dropped, got %s", output)
+	}
+	if string(members["newMember"]) != "true" {
+		t.Errorf("Expected newMember to be kept, got %s", output)
+	}
+}
+
+func extraOf(event interface{}) map[string]json.RawMessage {
+	switch e := event.(type) {
+	case *CallSummaryEvent:
+		return e.Detail.Extra
+	case *InsightsSummaryEvent:
+		return e.Detail.Extra
+	case *HeadsetSummaryEvent:
+		return e.Detail.Extra
+	case *AgentReportedIssueEvent:
+		return e.Detail.Extra
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CallSummarySchemaV0 is the CallSummary schema version whose contact uses
+// upper-case direction and endedBy values ("INBOUND", "AGENT") and a callerID
+// member, as in the sample event of the Lambda example. DefaultSchemaRegistry
+// detects it and upgrades it to the current structs.
+const CallSummarySchemaV0 = "0"
+
+// CallContactV0 is the contact of a schema version 0 CallSummary detail
+type CallContactV0 struct {
+	Contact
+	Direction string     `json:"direction"`
+	Events    CallEvents `json:"events"`
+	EndedBy   string     `json:"endedBy"`
+	QueueName string     `json:"queueName"`
+	CallerID  string     `json:"callerID"`
+}
+
+// Upgrade returns the contact in the current CallContact shape
+func (c CallContactV0) Upgrade() CallContact {
+	return CallContact{
+		Contact:   c.Contact,
+		Direction: titleCase(c.Direction),
+		Events:    c.Events,
+		EndedBy:   titleCase(c.EndedBy),
+		QueueName: c.QueueName,
+		CallerID:  c.CallerID,
+	}
+}
+
+// DetectCallSummaryV0 recognises schema version 0 CallSummary details by
+// their callerID member or upper-case direction
+func DetectCallSummaryV0(_ *EventBridgeEvent, detail map[string]interface{}) (string, bool) {
+	contact, ok := detail["contact"].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	if _, ok := contact["callerID"]; ok {
+		return CallSummarySchemaV0, true
+	}
+	if direction, ok := contact["direction"].(string); ok && direction != "" && direction == strings.ToUpper(direction) {
+		return CallSummarySchemaV0, true
+	}
+	return "", false
+}
+
+// MigrateCallSummaryV0 upgrades a schema version 0 CallSummary detail to the
+// current version. Contact members it does not know are kept.
+func MigrateCallSummaryV0(detail map[string]interface{}) error {
+	raw, ok := detail["contact"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("failed to encode contact: %w", err)
+	}
+	var legacy CallContactV0
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return fmt.Errorf("failed to decode version 0 contact: %w", err)
+	}
+	contact := legacy.Upgrade()
+
+	delete(raw, "callerID")
+	raw["callerId"] = contact.CallerID
+	raw["direction"] = contact.Direction
+	raw["endedBy"] = contact.EndedBy
+	return nil
+}
+
+// titleCase converts an upper-case enum value such as "INBOUND" to "Inbound"
+func titleCase(value string) string {
+	if value == "" || value != strings.ToUpper(value) {
+		return value
+	}
+	return value[:1] + strings.ToLower(value[1:])
+}
+
+// newDefaultSchemaRegistry returns a registry with the known older shapes
+func newDefaultSchemaRegistry() *SchemaRegistry {
+	r := NewSchemaRegistry()
+	r.RegisterDetector(EventTypeCallSummary, DetectCallSummaryV0)
+	r.RegisterMigration(EventTypeCallSummary, CallSummarySchemaV0, CurrentSchemaVersion, MigrateCallSummaryV0)
+	return r
+}
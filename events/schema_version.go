@@ -0,0 +1,233 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// CurrentSchemaVersion is the detail schema version described by the structs in this package
+const CurrentSchemaVersion = "1"
+
+// SchemaVersionField is the detail member that, when present, states the payload's schema version
+const SchemaVersionField = "schemaVersion"
+
+// ErrNoMigrationPath is returned when a payload's schema version cannot be upgraded to the current version
+var ErrNoMigrationPath = errors.New("no migration path to current schema version")
+
+// VersionDetector inspects an event and its raw detail payload and reports the
+// detail schema version. It returns false when it does not recognise the shape.
+//
+// EventBridge always sets the envelope version to "0", which is ignored; any
+// other envelope version is taken as the schema version before detectors run.
+type VersionDetector func(envelope *EventBridgeEvent, detail map[string]interface{}) (string, bool)
+
+// Migration upgrades a raw detail payload in place from one schema version to the next
+type Migration func(detail map[string]interface{}) error
+
+type migrationStep struct {
+	to      string
+	migrate Migration
+}
+
+// SchemaRegistry holds version detectors and migrations per detail-type and
+// upgrades older payloads to the current structs before decoding
+type SchemaRegistry struct {
+	mu         sync.RWMutex
+	detectors  map[string][]VersionDetector
+	migrations map[string]map[string]migrationStep
+}
+
+// NewSchemaRegistry creates an empty schema registry
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		detectors:  make(map[string][]VersionDetector),
+		migrations: make(map[string]map[string]migrationStep),
+	}
+}
+
+// DefaultSchemaRegistry is the registry used by ParseEventBridgeEvent. It
+// upgrades CallSummarySchemaV0 payloads.
+var DefaultSchemaRegistry = newDefaultSchemaRegistry()
+
+// RegisterVersionDetector adds a detector to the default registry
+func RegisterVersionDetector(detailType string, detector VersionDetector) {
+	DefaultSchemaRegistry.RegisterDetector(detailType, detector)
+}
+
+// RegisterMigration adds a migration to the default registry
+func RegisterMigration(detailType, from, to string, migration Migration) {
+	DefaultSchemaRegistry.RegisterMigration(detailType, from, to, migration)
+}
+
+// RegisterDetector adds a version detector for a detail-type. Detectors are
+// consulted in registration order after the explicit schemaVersion member.
+func (r *SchemaRegistry) RegisterDetector(detailType string, detector VersionDetector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.detectors[detailType] = append(r.detectors[detailType], detector)
+}
+
+// RegisterMigration adds a migration that upgrades detail payloads of a
+// detail-type from one schema version to another. Registering a second
+// migration from the same version replaces the first.
+func (r *SchemaRegistry) RegisterMigration(detailType, from, to string, migration Migration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.migrations[detailType] == nil {
+		r.migrations[detailType] = make(map[string]migrationStep)
+	}
+	r.migrations[detailType][from] = migrationStep{to: to, migrate: migration}
+}
+
+// DetectVersion reports the schema version of a raw detail payload. The
+// schemaVersion member wins, then an envelope version other than
+// EventBridge's "0", then registered detectors, and payloads that match none
+// of them are assumed to be current.
+func (r *SchemaRegistry) DetectVersion(envelope *EventBridgeEvent, detail map[string]interface{}) string {
+	if version, ok := detail[SchemaVersionField]; ok {
+		switch v := version.(type) {
+		case string:
+			return v
+		case json.Number:
+			return v.String()
+		}
+	}
+
+	if envelope.Version != "" && envelope.Version != "0" {
+		return envelope.Version
+	}
+
+	r.mu.RLock()
+	detectors := r.detectors[envelope.DetailType]
+	r.mu.RUnlock()
+
+	for _, detect := range detectors {
+		if version, ok := detect(envelope, detail); ok {
+			return version
+		}
+	}
+
+	return CurrentSchemaVersion
+}
+
+// Upgrade migrates the detail payload of a raw event to the current schema
+// version and returns the rewritten event. Events that are already current,
+// or whose detail-type has no migrations, are returned unchanged. Only the
+// detail member of a migrated event is rewritten: the rest of the envelope
+// keeps its bytes, while the migrated detail is written compactly with its
+// members in key order.
+func (r *SchemaRegistry) Upgrade(data []byte) ([]byte, error) {
+	var envelope EventBridgeEvent
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse generic event: %w", err)
+	}
+	return r.upgrade(&envelope, data)
+}
+
+// Parse upgrades a raw event to the current schema version and returns the
+// appropriate typed event, as ParseEventBridgeEvent does
+func (r *SchemaRegistry) Parse(data []byte) (interface{}, error) {
+	var genericEvent EventBridgeEvent
+	if err := json.Unmarshal(data, &genericEvent); err != nil {
+		return nil, fmt.Errorf("failed to parse generic event: %w", err)
+	}
+
+	upgraded, err := r.upgrade(&genericEvent, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeEvent(genericEvent.DetailType, upgraded)
+}
+
+func (r *SchemaRegistry) upgrade(envelope *EventBridgeEvent, data []byte) ([]byte, error) {
+	r.mu.RLock()
+	steps := r.migrations[envelope.DetailType]
+	r.mu.RUnlock()
+
+	if len(steps) == 0 {
+		return data, nil
+	}
+
+	start, end, err := memberValue(data, "detail")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse generic event: %w", err)
+	}
+
+	// A missing or non-object detail has no version; decoding reports any error
+	var detail map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data[start:end]))
+	decoder.UseNumber()
+	if err := decoder.Decode(&detail); err != nil || detail == nil {
+		return data, nil
+	}
+
+	version := r.DetectVersion(envelope, detail)
+	if version == CurrentSchemaVersion {
+		return data, nil
+	}
+
+	_, explicit := detail[SchemaVersionField]
+	for i := 0; version != CurrentSchemaVersion; i++ {
+		step, ok := steps[version]
+		if !ok || i >= len(steps) {
+			return nil, fmt.Errorf("%s schema version %q: %w", envelope.DetailType, version, ErrNoMigrationPath)
+		}
+		if err := step.migrate(detail); err != nil {
+			return nil, fmt.Errorf("failed to migrate %s from schema version %q to %q: %w",
+				envelope.DetailType, version, step.to, err)
+		}
+		version = step.to
+	}
+
+	if explicit {
+		detail[SchemaVersionField] = version
+	}
+
+	rawDetail, err := json.Marshal(detail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode migrated %s detail: %w", envelope.DetailType, err)
+	}
+
+	upgraded := make([]byte, 0, len(data)-(end-start)+len(rawDetail))
+	upgraded = append(upgraded, data[:start]...)
+	upgraded = append(upgraded, rawDetail...)
+	return append(upgraded, data[end:]...), nil
+}
+
+// memberValue returns the byte range of the value of a top-level member of
+// a JSON object. When the key repeats, the last value is used, as
+// json.Unmarshal does. A missing member gives an empty range.
+func memberValue(data []byte, key string) (int, int, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return 0, 0, err
+	}
+	if token != json.Delim('{') {
+		return 0, 0, fmt.Errorf("expected a JSON object, got %v", token)
+	}
+
+	start, end := 0, 0
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, 0, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return 0, 0, err
+		}
+		if token == key {
+			end = int(decoder.InputOffset())
+			start = end - len(value)
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
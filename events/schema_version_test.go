@@ -0,0 +1,192 @@
+package events
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// legacyCallSummaryJSON uses a hypothetical earlier shape where the agent was a flat string
+const legacyCallSummaryJSON = `{
+	"version": "0",
+	"id": "legacy-id",
+	"detail-type": "CallSummary",
+	"source": "aws.partner/operata.com/test/eventBus",
+	"time": "2023-01-10T02:00:00Z",
+	"detail": {
+		"agent": "andy",
+		"durationSec": 42,
+		"contact": {"id": {"current": "legacy-contact"}}
+	}
+}`
+
+func newLegacyRegistry() *SchemaRegistry {
+	registry := NewSchemaRegistry()
+	registry.RegisterDetector(EventTypeCallSummary, func(_ *EventBridgeEvent, detail map[string]interface{}) (string, bool) {
+		if _, ok := detail["agent"].(string); ok {
+			return "0", true
+		}
+		return "", false
+	})
+	registry.RegisterMigration(EventTypeCallSummary, "0", CurrentSchemaVersion, func(detail map[string]interface{}) error {
+		detail["serviceAgent"] = map[string]interface{}{
+			"username": detail["agent"],
+			"interaction": map[string]interface{}{
+				"totalDurationSec": detail["durationSec"],
+			},
+		}
+		delete(detail, "agent")
+		delete(detail, "durationSec")
+		return nil
+	})
+	return registry
+}
+
+func TestSchemaRegistryParseMigratesLegacyPayload(t *testing.T) {
+	event, err := newLegacyRegistry().Parse([]byte(legacyCallSummaryJSON))
+	if err != nil {
+		t.Fatalf("Failed to parse legacy event: %v", err)
+	}
+
+	callEvent, ok := event.(*CallSummaryEvent)
+	if !ok {
+		t.Fatalf("Expected CallSummaryEvent, got %T", event)
+	}
+
+	if callEvent.Detail.ServiceAgent.Username != "andy" {
+		t.Errorf("Expected username 'andy', got '%s'", callEvent.Detail.ServiceAgent.Username)
+	}
+	if callEvent.Detail.ServiceAgent.Interaction.TotalDurationSec != 42 {
+		t.Errorf("Expected total duration 42 seconds, got %d", callEvent.Detail.ServiceAgent.Interaction.TotalDurationSec)
+	}
+	if callEvent.Detail.Contact.ID.Current != "legacy-contact" {
+		t.Errorf("Expected contact 'legacy-contact', got '%s'", callEvent.Detail.Contact.ID.Current)
+	}
+	if len(callEvent.Detail.Extra) != 0 {
+		t.Errorf("Expected migrated payload to have no extra members, got %v", callEvent.Detail.Extra)
+	}
+}
+
+func TestSchemaRegistryCurrentPayloadUnchanged(t *testing.T) {
+	data := []byte(`{"detail-type": "CallSummary", "detail": {"serviceAgent": {"username": "andy"}}}`)
+
+	upgraded, err := newLegacyRegistry().Upgrade(data)
+	if err != nil {
+		t.Fatalf("Failed to upgrade event: %v", err)
+	}
+	if string(upgraded) != string(data) {
+		t.Errorf("Expected current payload to be returned unchanged, got %s", upgraded)
+	}
+}
+
+func TestSchemaRegistryExplicitVersion(t *testing.T) {
+	registry := NewSchemaRegistry()
+	registry.RegisterMigration(EventTypeInsightsSummary, "0", CurrentSchemaVersion, func(detail map[string]interface{}) error {
+		detail["insights"] = map[string]interface{}{"count": detail["insightCount"]}
+		delete(detail, "insightCount")
+		return nil
+	})
+
+	data := []byte(`{"detail-type": "InsightsSummary", "detail": {"schemaVersion": 0, "insightCount": 2}}`)
+	event, err := registry.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse event: %v", err)
+	}
+
+	insightsEvent := event.(*InsightsSummaryEvent)
+	if insightsEvent.Detail.Insights.Count != 2 {
+		t.Errorf("Expected insights count 2, got %d", insightsEvent.Detail.Insights.Count)
+	}
+	if string(insightsEvent.Detail.Extra[SchemaVersionField]) != `"1"` {
+		t.Errorf("Expected schemaVersion to be rewritten to \"1\", got %s", insightsEvent.Detail.Extra[SchemaVersionField])
+	}
+}
+
+func TestSchemaRegistryNoMigrationPath(t *testing.T) {
+	registry := newLegacyRegistry()
+	data := []byte(`{"detail-type": "CallSummary", "detail": {"schemaVersion": "-1"}}`)
+
+	_, err := registry.Parse(data)
+	if !errors.Is(err, ErrNoMigrationPath) {
+		t.Errorf("Expected ErrNoMigrationPath, got %v", err)
+	}
+}
+
+func TestSchemaRegistryUpgradeKeepsEnvelope(t *testing.T) {
+	data := []byte(legacyCallSummaryJSON)
+	upgraded, err := newLegacyRegistry().Upgrade(data)
+	if err != nil {
+		t.Fatalf("Failed to upgrade event: %v", err)
+	}
+
+	detail := bytes.Index(data, []byte(`"detail"`))
+	if !bytes.HasPrefix(upgraded, data[:detail]) || !bytes.HasSuffix(upgraded, []byte("\n}")) {
+		t.Errorf("Expected the envelope to keep its bytes, got %s", upgraded)
+	}
+	if !bytes.Contains(upgraded, []byte(`"detail": {"contact":`)) {
+		t.Errorf("Expected the migrated detail in place, got %s", upgraded)
+	}
+}
+
+func TestParseCallSummaryV0Fixture(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "call_summary_v0.json"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	event, err := ParseEventBridgeEvent(data)
+	if err != nil {
+		t.Fatalf("Failed to parse version 0 event: %v", err)
+	}
+	contact := event.(*CallSummaryEvent).Detail.Contact
+	expected := CallContact{Contact: Contact{ID: ContactID{Current: "contact-12345"}}, Direction: "Inbound", EndedBy: "Agent", QueueName: "Support", CallerID: "+1234567890"}
+	if !reflect.DeepEqual(contact, expected) {
+		t.Errorf("Expected upgraded contact %+v, got %+v", expected, contact)
+	}
+
+	upgraded, err := DefaultSchemaRegistry.Upgrade(data)
+	if err != nil {
+		t.Fatalf("Failed to upgrade event: %v", err)
+	}
+	if bytes.Contains(upgraded, []byte(`"callerID"`)) || !bytes.Contains(upgraded, []byte(`"callerId":"+1234567890"`)) {
+		t.Errorf("Expected callerID to be renamed, got %s", upgraded)
+	}
+}
+
+func TestDetectCallSummaryV0(t *testing.T) {
+	registry := DefaultSchemaRegistry
+	call := &EventBridgeEvent{DetailType: EventTypeCallSummary, Version: "0"}
+	tests := []struct {
+		name     string
+		envelope *EventBridgeEvent
+		detail   map[string]interface{}
+		expected string
+	}{
+		{"current", call, map[string]interface{}{"contact": map[string]interface{}{"direction": "Inbound", "callerId": "+61"}}, CurrentSchemaVersion},
+		{"callerID member", call, map[string]interface{}{"contact": map[string]interface{}{"direction": "Inbound", "callerID": "+61"}}, CallSummarySchemaV0},
+		{"upper-case direction", call, map[string]interface{}{"contact": map[string]interface{}{"direction": "OUTBOUND"}}, CallSummarySchemaV0},
+		{"no contact", call, map[string]interface{}{}, CurrentSchemaVersion},
+		{"envelope version", &EventBridgeEvent{DetailType: EventTypeCallSummary, Version: "2"}, map[string]interface{}{}, "2"},
+		{"explicit version", call, map[string]interface{}{SchemaVersionField: "1", "contact": map[string]interface{}{"callerID": "+61"}}, "1"},
+	}
+
+	for _, test := range tests {
+		if version := registry.DetectVersion(test.envelope, test.detail); version != test.expected {
+			t.Errorf("%s: expected version %q, got %q", test.name, test.expected, version)
+		}
+	}
+}
+
+func TestRegisteredFixturesAreCurrent(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "call_summary.json"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	upgraded, err := DefaultSchemaRegistry.Upgrade(data)
+	if err != nil || !bytes.Equal(upgraded, data) {
+		t.Errorf("Expected current fixture to be unchanged, got %v", err)
+	}
+}
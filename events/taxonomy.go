@@ -0,0 +1,166 @@
+package events
+
+import (
+	"math"
+	"strings"
+	"sync"
+)
+
+// InsightCategory groups insights by the part of the call path they concern
+type InsightCategory string
+
+const (
+	CategoryNetwork        InsightCategory = "network"
+	CategoryDevice         InsightCategory = "device"
+	CategoryAgentBehaviour InsightCategory = "agent-behaviour"
+	CategoryPlatform       InsightCategory = "platform"
+	CategoryUnknown        InsightCategory = "unknown"
+)
+
+// InsightSeverity represents the overall severity of a call's insights
+type InsightSeverity string
+
+const (
+	SeverityNone     InsightSeverity = "None"
+	SeverityLow      InsightSeverity = "Low"
+	SeverityMedium   InsightSeverity = "Medium"
+	SeverityHigh     InsightSeverity = "High"
+	SeverityCritical InsightSeverity = "Critical"
+)
+
+// MaxSeverityScore is the highest score SeverityScore returns
+const MaxSeverityScore = 10.0
+
+// UnknownInsightWeight is the weight given to insights that are not in the taxonomy
+const UnknownInsightWeight = 3.0
+
+// InsightDefinition describes a known insight tag
+type InsightDefinition struct {
+	Description string          `json:"description"`
+	Category    InsightCategory `json:"category"`
+	// Weight is the severity of the insight on its own, from 0 to MaxSeverityScore
+	Weight      float64 `json:"weight"`
+	Remediation string  `json:"remediation"`
+}
+
+// Taxonomy maps insight tag descriptions to definitions. It is safe for concurrent use.
+type Taxonomy struct {
+	mu          sync.RWMutex
+	definitions map[string]InsightDefinition
+}
+
+// NewTaxonomy creates a taxonomy holding definitions
+func NewTaxonomy(definitions ...InsightDefinition) *Taxonomy {
+	t := &Taxonomy{definitions: make(map[string]InsightDefinition)}
+	for _, definition := range definitions {
+		t.Register(definition)
+	}
+	return t
+}
+
+// Register adds or replaces the definition for its description. Descriptions
+// are matched case-insensitively, ignoring surrounding whitespace.
+func (t *Taxonomy) Register(definition InsightDefinition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.definitions[normaliseDescription(definition.Description)] = definition
+}
+
+// Lookup returns the definition for a tag description
+func (t *Taxonomy) Lookup(description string) (InsightDefinition, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	definition, ok := t.definitions[normaliseDescription(description)]
+	return definition, ok
+}
+
+// Classify returns the definition for a tag, or an unknown-category
+// definition with UnknownInsightWeight if the tag is not registered
+func (t *Taxonomy) Classify(tag InsightTag) InsightDefinition {
+	if definition, ok := t.Lookup(tag.Description); ok {
+		return definition
+	}
+	return InsightDefinition{Description: tag.Description, Category: CategoryUnknown, Weight: UnknownInsightWeight}
+}
+
+// SeverityScore scores a call's insights from 0 to MaxSeverityScore. The
+// heaviest insight sets the base score and each further insight adds a
+// quarter of its weight. When Count exceeds the number of tags, the
+// untagged insights are scored as unknown.
+func (t *Taxonomy) SeverityScore(insights Insights) float64 {
+	weights := make([]float64, 0, len(insights.Tags))
+	for _, tag := range insights.Tags {
+		weights = append(weights, t.Classify(tag).Weight)
+	}
+	for i := len(insights.Tags); i < insights.Count; i++ {
+		weights = append(weights, UnknownInsightWeight)
+	}
+	if len(weights) == 0 {
+		return 0
+	}
+
+	var heaviest, total float64
+	for _, weight := range weights {
+		heaviest = math.Max(heaviest, weight)
+		total += weight
+	}
+	return math.Min(MaxSeverityScore, heaviest+(total-heaviest)/4)
+}
+
+// DefaultTaxonomy holds the insights Operata is known to report
+var DefaultTaxonomy = NewTaxonomy(
+	InsightDefinition{"High Packet Loss", CategoryNetwork, 7, "Check the agent's connection for congestion or Wi-Fi interference; prefer a wired connection."},
+	InsightDefinition{"High Jitter", CategoryNetwork, 6, "Look for competing traffic on the agent's network and enable QoS for voice where possible."},
+	InsightDefinition{"High Latency", CategoryNetwork, 5, "Check the route to the media endpoint and whether a VPN or proxy is in the path."},
+	InsightDefinition{"Network Congestion", CategoryNetwork, 6, "Reduce other traffic on the agent's link during calls or increase its bandwidth."},
+	InsightDefinition{"Poor Audio Quality", CategoryDevice, 6, "Check the headset and its connection, then the agent's network."},
+	InsightDefinition{"Low Audio Level", CategoryDevice, 4, "Check the microphone gain and headset boom position."},
+	InsightDefinition{"Echo Detected", CategoryDevice, 5, "Replace speakers with a headset or enable echo cancellation."},
+	InsightDefinition{"Headset Disconnected", CategoryDevice, 7, "Check the headset cable, dongle or Bluetooth pairing."},
+	InsightDefinition{"Misaligned Boom Arm", CategoryDevice, 3, "Coach the agent to position the microphone near the mouth."},
+	InsightDefinition{"High CPU Usage", CategoryDevice, 5, "Close unneeded applications or refresh the workstation."},
+	InsightDefinition{"High Memory Usage", CategoryDevice, 5, "Close unneeded browser tabs and applications or add memory."},
+	InsightDefinition{"Agent Over-Talking", CategoryAgentBehaviour, 3, "Coach the agent on active listening."},
+	InsightDefinition{"Long Silence", CategoryAgentBehaviour, 3, "Coach the agent to narrate while looking up information."},
+	InsightDefinition{"Excessive Hold Time", CategoryAgentBehaviour, 4, "Review knowledge base coverage and hold procedures."},
+	InsightDefinition{"Excessive Mute", CategoryAgentBehaviour, 3, "Review why the agent mutes and coach on hold procedures."},
+	InsightDefinition{"Softphone Error", CategoryPlatform, 8, "Check the softphone error details and the contact centre platform status."},
+	InsightDefinition{"Media Connection Failure", CategoryPlatform, 9, "Check firewall rules for the media endpoints and TURN ports."},
+	InsightDefinition{"Signalling Failure", CategoryPlatform, 8, "Check connectivity to the signalling endpoint and platform status."},
+	InsightDefinition{"Call Dropped", CategoryPlatform, 9, "Correlate with network and platform events at the time of the drop."},
+)
+
+// RegisterInsight adds or replaces a definition in DefaultTaxonomy
+func RegisterInsight(definition InsightDefinition) {
+	DefaultTaxonomy.Register(definition)
+}
+
+// GetInsightSeverityScore scores insights using DefaultTaxonomy
+func GetInsightSeverityScore(insights Insights) float64 {
+	return DefaultTaxonomy.SeverityScore(insights)
+}
+
+// GetInsightSeverity returns the severity level for a severity score
+// 8-10: Critical
+// 6-8: High
+// 3-6: Medium
+// 0-3: Low
+// 0: None
+func GetInsightSeverity(score float64) InsightSeverity {
+	switch {
+	case score >= 8:
+		return SeverityCritical
+	case score >= 6:
+		return SeverityHigh
+	case score >= 3:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	default:
+		return SeverityNone
+	}
+}
+
+func normaliseDescription(description string) string {
+	return strings.ToLower(strings.TrimSpace(description))
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"math"
+	"testing"
+)
+
+func tags(descriptions ...string) []InsightTag {
+	out := make([]InsightTag, len(descriptions))
+	for i, description := range descriptions {
+		out[i] = InsightTag{Description: description}
+	}
+	return out
+}
+
+func TestTaxonomyClassify(t *testing.T) {
+	tests := []struct {
+		description string
+		category    InsightCategory
+		weight      float64
+	}{
+		{"High Packet Loss", CategoryNetwork, 7},
+		{"  poor audio QUALITY ", CategoryDevice, 6},
+		{"Excessive Hold Time", CategoryAgentBehaviour, 4},
+		{"Media Connection Failure", CategoryPlatform, 9},
+		{"Something New", CategoryUnknown, UnknownInsightWeight},
+	}
+
+	for _, test := range tests {
+		definition := DefaultTaxonomy.Classify(InsightTag{Description: test.description})
+		if definition.Category != test.category || definition.Weight != test.weight {
+			t.Errorf("Classify(%q) = %s %v, expected %s %v", test.description, definition.Category, definition.Weight, test.category, test.weight)
+		}
+	}
+}
+
+func TestSeverityScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		insights Insights
+		score    float64
+		severity InsightSeverity
+	}{
+		{"none", Insights{}, 0, SeverityNone},
+		{"single minor", Insights{Count: 1, Tags: tags("Misaligned Boom Arm")}, 3, SeverityMedium},
+		{"single severe", Insights{Count: 1, Tags: tags("Call Dropped")}, 9, SeverityCritical},
+		{"several", Insights{Count: 3, Tags: tags("High Packet Loss", "Poor Audio Quality", "Network Congestion")}, 10, SeverityCritical},
+		{"untagged", Insights{Count: 2}, 3.75, SeverityMedium},
+		{"capped", Insights{Count: 4, Tags: tags("Call Dropped", "Call Dropped", "Call Dropped", "Call Dropped")}, MaxSeverityScore, SeverityCritical},
+		{"low", Insights{Count: 1, Tags: tags("Custom")}, UnknownInsightWeight, SeverityMedium},
+	}
+
+	for _, test := range tests {
+		score := GetInsightSeverityScore(test.insights)
+		if math.Abs(score-test.score) > 1e-9 {
+			t.Errorf("%s: score = %v, expected %v", test.name, score, test.score)
+		}
+		if severity := GetInsightSeverity(score); severity != test.severity {
+			t.Errorf("%s: severity = %s, expected %s", test.name, severity, test.severity)
+		}
+	}
+}
+
+func TestTaxonomyRegister(t *testing.T) {
+	taxonomy := NewTaxonomy(InsightDefinition{Description: "Bluetooth Dropout", Category: CategoryDevice, Weight: 2})
+	taxonomy.Register(InsightDefinition{Description: "bluetooth dropout", Category: CategoryDevice, Weight: 1.5, Remediation: "Use a wired headset."})
+
+	definition, ok := taxonomy.Lookup("Bluetooth Dropout")
+	if !ok || definition.Weight != 1.5 || definition.Remediation != "Use a wired headset." {
+		t.Errorf("Expected re-registered definition, got %+v, %v", definition, ok)
+	}
+	if _, ok := taxonomy.Lookup("High Packet Loss"); ok {
+		t.Error("Expected a new taxonomy not to include the defaults")
+	}
+
+	score := taxonomy.SeverityScore(Insights{Count: 1, Tags: tags("Bluetooth Dropout")})
+	if score != 1.5 || GetInsightSeverity(score) != SeverityLow {
+		t.Errorf("Expected low severity score 1.5, got %v", score)
+	}
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNoTenant is returned when an event carries no Operata group ID
+	ErrNoTenant = errors.New("event has no Operata group ID")
+	// ErrTenantMismatch is returned when an event's source and payload name different groups
+	ErrTenantMismatch = errors.New("event source and payload group IDs differ")
+)
+
+// Tenant identifies the Operata group an event belongs to
+type Tenant struct {
+	GroupID string `json:"groupId"`
+	// GroupName is the group's display name, when the payload carries one
+	GroupName string `json:"groupName,omitempty"`
+	// EventBus is the partner event bus name from the source, when there is one
+	EventBus string `json:"eventBus,omitempty"`
+}
+
+// ParseSource splits an Operata partner event source of the form
+// aws.partner/operata.com/<groupId>/<busName>. It returns false for other sources.
+func ParseSource(source string) (groupID, eventBus string, ok bool) {
+	if !IsOperataEvent(source) {
+		return "", "", false
+	}
+	groupID, eventBus, _ = strings.Cut(source[len(operataSourcePrefix):], "/")
+	if groupID == "" {
+		return "", "", false
+	}
+	return groupID, eventBus, true
+}
+
+// GetTenant returns the Operata group an event belongs to, taken from both
+// its source and its payload (see GetGroupID). Either may be missing, but
+// when both are present they must agree: a mismatch means the event was
+// misrouted and GetTenant returns an error wrapping ErrTenantMismatch,
+// along with the tenant named by the payload.
+func GetTenant(event interface{}) (Tenant, error) {
+	var tenant Tenant
+	var sourceGroupID string
+	if header, ok := GetEventHeader(event); ok {
+		sourceGroupID, tenant.EventBus, _ = ParseSource(header.Source)
+	}
+
+	tenant.GroupID = GetGroupID(event)
+	switch e := event.(type) {
+	case *CallSummaryEvent:
+		tenant.GroupName = e.Detail.AccountProperties.OperataGroupName
+	case *InsightsSummaryEvent:
+		tenant.GroupName = e.Detail.AccountProperties.OperataGroupName
+	case *HeadsetSummaryEvent:
+		tenant.GroupName = e.Detail.AccountProperties.OperataGroupName
+	}
+
+	switch {
+	case tenant.GroupID == "" && sourceGroupID == "":
+		return tenant, ErrNoTenant
+	case tenant.GroupID == "":
+		tenant.GroupID = sourceGroupID
+	case sourceGroupID != "" && !strings.EqualFold(sourceGroupID, tenant.GroupID):
+		return tenant, fmt.Errorf("%w: source %s, payload %s", ErrTenantMismatch, sourceGroupID, tenant.GroupID)
+	}
+	return tenant, nil
+}
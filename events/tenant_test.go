@@ -0,0 +1,72 @@
+package events
+
+import (
+	"errors"
+	"testing"
+)
+
+const testGroupID = "a28453f9-1111-2222-3333-84d9e67ac297"
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		source   string
+		groupID  string
+		eventBus string
+		ok       bool
+	}{
+		{"aws.partner/operata.com/" + testGroupID + "/andyEventBus", testGroupID, "andyEventBus", true},
+		{"aws.partner/operata.com/" + testGroupID, testGroupID, "", true},
+		{"aws.partner/operata.com//andyEventBus", "", "", false},
+		{"aws.partner/operata.com/", "", "", false},
+		{"aws.ec2", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, test := range tests {
+		groupID, eventBus, ok := ParseSource(test.source)
+		if groupID != test.groupID || eventBus != test.eventBus || ok != test.ok {
+			t.Errorf("ParseSource(%q) = %q, %q, %v, expected %q, %q, %v", test.source, groupID, eventBus, ok, test.groupID, test.eventBus, test.ok)
+		}
+	}
+}
+
+func TestGetTenant(t *testing.T) {
+	source := "aws.partner/operata.com/" + testGroupID + "/andyEventBus"
+	other := "aws.partner/operata.com/b39564a0-1111-2222-3333-84d9e67ac297/otherBus"
+
+	call := func(source, groupID string) *CallSummaryEvent {
+		event := &CallSummaryEvent{}
+		event.Source = source
+		event.Detail.AccountProperties = AccountProperties{OperataGroupName: "Operata Demo", OperataGroupID: groupID}
+		return event
+	}
+	issue := &AgentReportedIssueEvent{}
+	issue.Source = source
+	issue.Detail.OperataClientID = testGroupID
+
+	tests := []struct {
+		name     string
+		event    interface{}
+		expected Tenant
+		err      error
+	}{
+		{"call", call(source, testGroupID), Tenant{testGroupID, "Operata Demo", "andyEventBus"}, nil},
+		{"issue", issue, Tenant{GroupID: testGroupID, EventBus: "andyEventBus"}, nil},
+		{"payload only", call("", testGroupID), Tenant{GroupID: testGroupID, GroupName: "Operata Demo"}, nil},
+		{"source only", call(source, ""), Tenant{testGroupID, "Operata Demo", "andyEventBus"}, nil},
+		{"generic event", &EventBridgeEvent{Source: source}, Tenant{GroupID: testGroupID, EventBus: "andyEventBus"}, nil},
+		{"mismatch", call(other, testGroupID), Tenant{testGroupID, "Operata Demo", "otherBus"}, ErrTenantMismatch},
+		{"none", call("aws.ec2", ""), Tenant{GroupName: "Operata Demo"}, ErrNoTenant},
+		{"not an event", "event", Tenant{}, ErrNoTenant},
+	}
+
+	for _, test := range tests {
+		tenant, err := GetTenant(test.event)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: expected error %v, got %v", test.name, test.err, err)
+		}
+		if tenant != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, tenant)
+		}
+	}
+}
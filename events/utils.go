@@ -13,14 +13,15 @@ const (
 	EventTypeHeadsetSummary     = "HeadsetSummary"
 )
 
-// ParseEventBridgeEvent parses a generic EventBridge event and returns the appropriate typed event
+// ParseEventBridgeEvent parses a generic EventBridge event and returns the appropriate typed event.
+// Payloads from older schema versions are upgraded using DefaultSchemaRegistry before decoding.
 func ParseEventBridgeEvent(data []byte) (interface{}, error) {
-	var genericEvent EventBridgeEvent
-	if err := json.Unmarshal(data, &genericEvent); err != nil {
-		return nil, fmt.Errorf("failed to parse generic event: %w", err)
-	}
+	return DefaultSchemaRegistry.Parse(data)
+}
 
-	switch genericEvent.DetailType {
+// decodeEvent decodes data into the typed event matching detailType
+func decodeEvent(detailType string, data []byte) (interface{}, error) {
+	switch detailType {
 	case EventTypeCallSummary:
 		var event CallSummaryEvent
 		if err := json.Unmarshal(data, &event); err != nil {
@@ -50,15 +51,21 @@ func ParseEventBridgeEvent(data []byte) (interface{}, error) {
 		return &event, nil
 
 	default:
-		return &genericEvent, nil
+		var event EventBridgeEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse generic event: %w", err)
+		}
+		return &event, nil
 	}
 }
 
+// operataSourcePrefix starts the source of every Operata partner event:
+// aws.partner/operata.com/<groupId>/<busName>
+const operataSourcePrefix = "aws.partner/operata.com/"
+
 // IsOperataEvent checks if an EventBridge event is from Operata based on the source field
 func IsOperataEvent(source string) bool {
-	// Operata events have source in format: aws.partner/operata.com/...
-	prefix := "aws.partner/operata.com/"
-	return len(source) > len(prefix) && source[:len(prefix)] == prefix
+	return len(source) > len(operataSourcePrefix) && source[:len(operataSourcePrefix)] == operataSourcePrefix
 }
 
 // GetEventTypeFromDetailType maps the detail-type to a more friendly event type name
@@ -152,3 +159,52 @@ func GetCallDurationCategory(durationSec int) string {
 		return "Very Long"
 	}
 }
+
+// Header returns the event's EventBridge envelope. It is promoted to every
+// typed event, so GetEventHeader works on any value from ParseEventBridgeEvent.
+func (e *EventBridgeEvent) Header() *EventBridgeEvent {
+	return e
+}
+
+// GetEventHeader returns the EventBridge envelope of a typed event
+func GetEventHeader(event interface{}) (*EventBridgeEvent, bool) {
+	h, ok := event.(interface{ Header() *EventBridgeEvent })
+	if !ok {
+		return nil, false
+	}
+	return h.Header(), true
+}
+
+// GetContactID returns the current contact ID an event relates to, or "" if it has none.
+// AgentReportedIssue events return the contact of the call the issue was raised on.
+func GetContactID(event interface{}) string {
+	switch e := event.(type) {
+	case *CallSummaryEvent:
+		return e.Detail.Contact.ID.Current
+	case *InsightsSummaryEvent:
+		return e.Detail.Contact.ID.Current
+	case *HeadsetSummaryEvent:
+		return e.Detail.Contact.ID.Current
+	case *AgentReportedIssueEvent:
+		return e.Detail.Context.CallContactID
+	default:
+		return ""
+	}
+}
+
+// GetGroupID returns the Operata group an event belongs to, or "" if it has none.
+// AgentReportedIssue events carry the group as their operataClientId.
+func GetGroupID(event interface{}) string {
+	switch e := event.(type) {
+	case *CallSummaryEvent:
+		return e.Detail.AccountProperties.OperataGroupID
+	case *InsightsSummaryEvent:
+		return e.Detail.AccountProperties.OperataGroupID
+	case *HeadsetSummaryEvent:
+		return e.Detail.AccountProperties.OperataGroupID
+	case *AgentReportedIssueEvent:
+		return e.Detail.OperataClientID
+	default:
+		return ""
+	}
+}
@@ -151,3 +151,54 @@ func TestGetCallDurationCategory(t *testing.T) {
 		}
 	}
 }
+
+func TestGetEventHeaderAndContactID(t *testing.T) {
+	tests := []struct {
+		event     interface{}
+		id        string
+		contactID string
+	}{
+		{&CallSummaryEvent{EventBridgeEvent: EventBridgeEvent{ID: "call"}, Detail: CallSummaryDetail{Contact: CallContact{Contact: Contact{ID: ContactID{Current: "c1"}}}}}, "call", "c1"},
+		{&InsightsSummaryEvent{EventBridgeEvent: EventBridgeEvent{ID: "insights"}, Detail: InsightsSummaryDetail{Contact: Contact{ID: ContactID{Current: "c2"}}}}, "insights", "c2"},
+		{&HeadsetSummaryEvent{EventBridgeEvent: EventBridgeEvent{ID: "headset"}, Detail: HeadsetSummaryDetail{Contact: HeadsetContact{Contact: Contact{ID: ContactID{Current: "c3"}}}}}, "headset", "c3"},
+		{&AgentReportedIssueEvent{EventBridgeEvent: EventBridgeEvent{ID: "issue"}, Detail: AgentReportedIssueDetail{Context: IssueContext{CallContactID: "c4"}}}, "issue", "c4"},
+		{&EventBridgeEvent{ID: "generic"}, "generic", ""},
+	}
+
+	for _, test := range tests {
+		header, ok := GetEventHeader(test.event)
+		if !ok {
+			t.Errorf("GetEventHeader(%T) returned no header", test.event)
+			continue
+		}
+		if header.ID != test.id {
+			t.Errorf("GetEventHeader(%T).ID = %q, expected %q", test.event, header.ID, test.id)
+		}
+		if contactID := GetContactID(test.event); contactID != test.contactID {
+			t.Errorf("GetContactID(%T) = %q, expected %q", test.event, contactID, test.contactID)
+		}
+	}
+
+	if _, ok := GetEventHeader("not an event"); ok {
+		t.Error("Expected GetEventHeader to reject non-event values")
+	}
+}
+
+func TestGetGroupID(t *testing.T) {
+	tests := []struct {
+		event    interface{}
+		expected string
+	}{
+		{&CallSummaryEvent{Detail: CallSummaryDetail{AccountProperties: AccountProperties{OperataGroupID: "g1"}}}, "g1"},
+		{&InsightsSummaryEvent{Detail: InsightsSummaryDetail{AccountProperties: AccountProperties{OperataGroupID: "g2"}}}, "g2"},
+		{&HeadsetSummaryEvent{Detail: HeadsetSummaryDetail{AccountProperties: AccountProperties{OperataGroupID: "g3"}}}, "g3"},
+		{&AgentReportedIssueEvent{Detail: AgentReportedIssueDetail{OperataClientID: "g4"}}, "g4"},
+		{&EventBridgeEvent{}, ""},
+	}
+
+	for _, test := range tests {
+		if groupID := GetGroupID(test.event); groupID != test.expected {
+			t.Errorf("GetGroupID(%T) = %q, expected %q", test.event, groupID, test.expected)
+		}
+	}
+}
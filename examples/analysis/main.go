@@ -191,13 +191,11 @@ func analyzeCallSummary(event *events.CallSummaryEvent) {
 	duration := detail.ServiceAgent.Interaction.TotalDurationSec
 	fmt.Printf("Call Duration: %d seconds (%s)\n", duration, events.GetCallDurationCategory(duration))
 	fmt.Printf("Talk Time: %d seconds (%.1f%%)\n",
-		detail.ServiceAgent.Interaction.TalkingDurationSec,
-		float64(detail.ServiceAgent.Interaction.TalkingDurationSec)/float64(duration)*100)
+		detail.ServiceAgent.Interaction.TalkingDurationSec, detail.TalkRatio()*100)
 
 	if detail.ServiceAgent.Interaction.OnHoldDurationSec > 0 {
 		fmt.Printf("Hold Time: %d seconds (%.1f%%)\n",
-			detail.ServiceAgent.Interaction.OnHoldDurationSec,
-			float64(detail.ServiceAgent.Interaction.OnHoldDurationSec)/float64(duration)*100)
+			detail.ServiceAgent.Interaction.OnHoldDurationSec, detail.HoldRatio()*100)
 	}
 
 	// WebRTC quality analysis
@@ -254,17 +252,16 @@ func analyzeInsightsSummary(event *events.InsightsSummaryEvent) {
 	if detail.Insights.Count > 0 {
 		fmt.Printf("Issues:\n")
 		for i, tag := range detail.Insights.Tags {
-			fmt.Printf("  %d. %s\n", i+1, tag.Description)
+			definition := events.DefaultTaxonomy.Classify(tag)
+			fmt.Printf("  %d. %s (%s)\n", i+1, tag.Description, definition.Category)
+			if definition.Remediation != "" {
+				fmt.Printf("     Remediation: %s\n", definition.Remediation)
+			}
 		}
 
-		// Categorize severity based on issue count and types
-		if detail.Insights.Count >= 3 {
-			fmt.Printf("Severity: High - Multiple issues detected\n")
-		} else if detail.Insights.Count == 2 {
-			fmt.Printf("Severity: Medium - Some issues detected\n")
-		} else {
-			fmt.Printf("Severity: Low - Minor issue detected\n")
-		}
+		// Weigh severity by the kinds of issue, not just how many there are
+		score := events.GetInsightSeverityScore(detail.Insights)
+		fmt.Printf("Severity: %s (score %.1f/%.0f)\n", events.GetInsightSeverity(score), score, events.MaxSeverityScore)
 	} else {
 		fmt.Printf("No issues detected\n")
 	}
@@ -155,16 +155,19 @@ func processInsightsSummaryEvent(event *operataEvents.InsightsSummaryEvent) erro
 	if detail.Insights.Count > 0 {
 		fmt.Printf("  Issues:\n")
 		for i, tag := range detail.Insights.Tags {
-			fmt.Printf("    %d. %s\n", i+1, tag.Description)
+			definition := operataEvents.DefaultTaxonomy.Classify(tag)
+			fmt.Printf("    %d. %s (%s)\n", i+1, tag.Description, definition.Category)
 		}
 
 		// Severity assessment
-		if detail.Insights.Count >= 3 {
-			fmt.Printf("  🔴 Severity: High - Multiple issues detected\n")
-		} else if detail.Insights.Count == 2 {
-			fmt.Printf("  🟡 Severity: Medium - Some issues detected\n")
-		} else {
-			fmt.Printf("  🟢 Severity: Low - Minor issue detected\n")
+		score := operataEvents.GetInsightSeverityScore(detail.Insights)
+		switch severity := operataEvents.GetInsightSeverity(score); severity {
+		case operataEvents.SeverityCritical, operataEvents.SeverityHigh:
+			fmt.Printf("  🔴 Severity: %s (score %.1f)\n", severity, score)
+		case operataEvents.SeverityMedium:
+			fmt.Printf("  🟡 Severity: %s (score %.1f)\n", severity, score)
+		default:
+			fmt.Printf("  🟢 Severity: %s (score %.1f)\n", severity, score)
 		}
 	} else {
 		fmt.Printf("  ✅ No issues detected\n")
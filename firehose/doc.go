@@ -0,0 +1,25 @@
+// Package firehose transforms Operata events in flight for Kinesis Data
+// Firehose, so they land in S3 already cleaned up and partitioned.
+//
+// A Transformer is a Firehose data-transformation Lambda handler. It parses
+// each record with events.ParseEventBridgeEvent, runs it through a chain of
+// Transforms (Redact, Flatten, Enrich or your own) and returns every record
+// as Ok, Dropped or ProcessingFailed, together with dynamic-partitioning
+// keys for the event type, Operata group ID and event date.
+//
+// The request and response types mirror the JSON of the aws-lambda-go
+// KinesisFirehoseEvent and KinesisFirehoseResponse, so this package has no
+// AWS dependencies and Handle can be passed straight to lambda.Start.
+//
+// Example usage:
+//
+//	t := firehose.New(
+//		firehose.Redact("detail.serviceAgent.username", "detail.serviceAgent.network.localAddress"),
+//		firehose.Flatten("_"),
+//	)
+//	lambda.Start(t.Handle)
+//
+// In the delivery stream, reference the keys as
+// !{partitionKeyFromLambda:eventType}, !{partitionKeyFromLambda:groupId}
+// and !{partitionKeyFromLambda:date}.
+package firehose
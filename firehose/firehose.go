@@ -0,0 +1,153 @@
+package firehose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// Partition key names set by DefaultPartitionKeys
+const (
+	PartitionKeyEventType = "eventType"
+	PartitionKeyGroupID   = "groupId"
+	PartitionKeyDate      = "date"
+)
+
+// UnknownPartition is used for a partition key an event has no value for
+const UnknownPartition = "unknown"
+
+// ErrDrop is returned by a Transform to drop a record from the delivery stream
+var ErrDrop = errors.New("drop record")
+
+// Record is an event being transformed
+type Record struct {
+	// ID is the Firehose record ID
+	ID string
+	// ArrivalTime is when the record reached the source stream
+	ArrivalTime time.Time
+	// Event is the typed event from events.ParseEventBridgeEvent, as originally received
+	Event interface{}
+	// Document is the JSON object that will be delivered. Transforms edit it in place
+	// or replace it; numbers are json.Number so they are written back unchanged.
+	Document map[string]interface{}
+}
+
+// Transform edits a record before delivery. Returning ErrDrop drops the
+// record; any other error marks it ProcessingFailed.
+type Transform func(record *Record) error
+
+// PartitionKeyFunc returns the dynamic-partitioning keys for a record
+type PartitionKeyFunc func(record *Record) map[string]string
+
+// Transformer is a Firehose data-transformation handler for Operata events
+type Transformer struct {
+	Transforms []Transform
+	// PartitionKeys sets the keys of Ok records. Nil disables dynamic partitioning.
+	PartitionKeys PartitionKeyFunc
+}
+
+// New creates a Transformer that applies transforms in order and partitions with DefaultPartitionKeys
+func New(transforms ...Transform) *Transformer {
+	return &Transformer{Transforms: transforms, PartitionKeys: DefaultPartitionKeys}
+}
+
+// Handle transforms a batch of records. Each output record is a single JSON
+// object followed by a newline, so delivered S3 objects are NDJSON. Records
+// that are not valid events keep their original data and are marked
+// ProcessingFailed, which sends them to the stream's error output.
+func (t *Transformer) Handle(ctx context.Context, event Event) (Response, error) {
+	resp := Response{Records: make([]ResponseRecord, 0, len(event.Records))}
+	for _, record := range event.Records {
+		if err := ctx.Err(); err != nil {
+			return Response{}, err
+		}
+		resp.Records = append(resp.Records, t.transform(record))
+	}
+	return resp, nil
+}
+
+// transform runs a single record through the transform chain. Dropped and
+// failed records keep their original data, since Firehose requires every
+// record to carry data.
+func (t *Transformer) transform(in EventRecord) ResponseRecord {
+	original := in.Data
+	if original == nil {
+		original = []byte{}
+	}
+	failed := ResponseRecord{RecordID: in.RecordID, Result: ResultProcessingFailed, Data: original}
+
+	record, err := newRecord(in)
+	if err != nil {
+		return failed
+	}
+
+	for _, transform := range t.Transforms {
+		if err := transform(record); err != nil {
+			if errors.Is(err, ErrDrop) {
+				return ResponseRecord{RecordID: in.RecordID, Result: ResultDropped, Data: original}
+			}
+			return failed
+		}
+	}
+
+	data, err := json.Marshal(record.Document)
+	if err != nil {
+		return failed
+	}
+
+	out := ResponseRecord{RecordID: in.RecordID, Result: ResultOk, Data: append(data, '\n')}
+	if t.PartitionKeys != nil {
+		out.Metadata = &ResponseRecordMetadata{PartitionKeys: t.PartitionKeys(record)}
+	}
+	return out
+}
+
+// newRecord parses a Firehose record into a typed event and an editable document
+func newRecord(in EventRecord) (*Record, error) {
+	event, err := events.ParseEventBridgeEvent(in.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(in.Data))
+	decoder.UseNumber()
+	var document map[string]interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode event document: %w", err)
+	}
+
+	return &Record{
+		ID:          in.RecordID,
+		ArrivalTime: time.UnixMilli(in.ApproximateArrivalTimestamp).UTC(),
+		Event:       event,
+		Document:    document,
+	}, nil
+}
+
+// DefaultPartitionKeys partitions by detail-type, Operata group ID and the
+// UTC date of the event (or of its arrival, if the event has no time)
+func DefaultPartitionKeys(record *Record) map[string]string {
+	keys := map[string]string{
+		PartitionKeyEventType: UnknownPartition,
+		PartitionKeyGroupID:   UnknownPartition,
+		PartitionKeyDate:      record.ArrivalTime.Format(time.DateOnly),
+	}
+
+	if header, ok := events.GetEventHeader(record.Event); ok {
+		if header.DetailType != "" {
+			keys[PartitionKeyEventType] = header.DetailType
+		}
+		if !header.Time.IsZero() {
+			keys[PartitionKeyDate] = header.Time.UTC().Format(time.DateOnly)
+		}
+	}
+	if groupID := events.GetGroupID(record.Event); groupID != "" {
+		keys[PartitionKeyGroupID] = groupID
+	}
+	return keys
+}
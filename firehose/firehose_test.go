@@ -0,0 +1,208 @@
+package firehose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "events", "testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	return data
+}
+
+func decode(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	return document
+}
+
+func TestHandleResults(t *testing.T) {
+	transformer := New(func(record *Record) error {
+		switch record.Event.(type) {
+		case *events.HeadsetSummaryEvent:
+			return ErrDrop
+		case *events.InsightsSummaryEvent:
+			return errors.New("enrichment failed")
+		}
+		return nil
+	})
+
+	event := Event{Records: []EventRecord{
+		{RecordID: "call", Data: fixture(t, "call_summary.json")},
+		{RecordID: "headset", Data: fixture(t, "headset_summary.json")},
+		{RecordID: "insights", Data: fixture(t, "insights_summary.json")},
+		{RecordID: "garbage", Data: []byte("not json")},
+	}}
+
+	resp, err := transformer.Handle(context.Background(), event)
+	if err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+
+	expected := []string{ResultOk, ResultDropped, ResultProcessingFailed, ResultProcessingFailed}
+	if len(resp.Records) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(resp.Records))
+	}
+	for i, result := range expected {
+		if resp.Records[i].RecordID != event.Records[i].RecordID {
+			t.Errorf("Record %d: expected ID %q, got %q", i, event.Records[i].RecordID, resp.Records[i].RecordID)
+		}
+		if resp.Records[i].Result != result {
+			t.Errorf("Record %d: expected result %s, got %s", i, result, resp.Records[i].Result)
+		}
+	}
+
+	if string(resp.Records[3].Data) != "not json" {
+		t.Errorf("Expected failed record to keep its data, got %q", resp.Records[3].Data)
+	}
+	if data := resp.Records[0].Data; len(data) == 0 || data[len(data)-1] != '\n' {
+		t.Errorf("Expected newline-terminated output, got %q", data)
+	}
+	if string(resp.Records[1].Data) != string(event.Records[1].Data) {
+		t.Errorf("Expected dropped record to keep its data, got %q", resp.Records[1].Data)
+	}
+}
+
+func TestResponseRecordsCarryData(t *testing.T) {
+	transformer := New(func(record *Record) error { return ErrDrop })
+	event := Event{Records: []EventRecord{
+		{RecordID: "headset", Data: fixture(t, "headset_summary.json")},
+		{RecordID: "empty"},
+	}}
+
+	resp, err := transformer.Handle(context.Background(), event)
+	if err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	output, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Failed to encode response: %v", err)
+	}
+
+	var decoded struct {
+		Records []struct {
+			Data     *string         `json:"data"`
+			Metadata json.RawMessage `json:"metadata"`
+		} `json:"records"`
+	}
+	if err := json.Unmarshal(output, &decoded); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	for i, record := range decoded.Records {
+		if record.Data == nil {
+			t.Errorf("Record %d: expected base64 data, got null", i)
+		}
+		if record.Metadata != nil {
+			t.Errorf("Record %d: expected no metadata, got %s", i, record.Metadata)
+		}
+	}
+	if len(decoded.Records) == 2 && decoded.Records[1].Data != nil && *decoded.Records[1].Data != "" {
+		t.Errorf("Expected empty data for the empty record, got %q", *decoded.Records[1].Data)
+	}
+}
+
+func TestDefaultPartitionKeys(t *testing.T) {
+	arrival := time.Date(2023, 6, 2, 0, 0, 1, 0, time.UTC).UnixMilli()
+	event := Event{Records: []EventRecord{
+		{RecordID: "call", Data: fixture(t, "call_summary.json"), ApproximateArrivalTimestamp: arrival},
+		{RecordID: "issue", Data: fixture(t, "agent_reported_issue.json"), ApproximateArrivalTimestamp: arrival},
+		{RecordID: "generic", Data: []byte(`{"detail-type": "HeartbeatWorkflow", "detail": {}}`), ApproximateArrivalTimestamp: arrival},
+	}}
+
+	resp, err := New().Handle(context.Background(), event)
+	if err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+
+	expected := []map[string]string{
+		{"eventType": "CallSummary", "groupId": "a28453f9-1111-2222-3333-84d9e67ac297", "date": "2023-06-01"},
+		{"eventType": "AgentReportedIssue", "groupId": "a28453f9-1111-2222-3333-84d9e67ac297", "date": "2023-06-01"},
+		{"eventType": "HeartbeatWorkflow", "groupId": "unknown", "date": "2023-06-02"},
+	}
+	for i, keys := range expected {
+		if resp.Records[i].Metadata == nil {
+			t.Errorf("Record %d: expected partition keys, got no metadata", i)
+			continue
+		}
+		got := resp.Records[i].Metadata.PartitionKeys
+		if len(got) != len(keys) {
+			t.Errorf("Record %d: expected keys %v, got %v", i, keys, got)
+			continue
+		}
+		for name, value := range keys {
+			if got[name] != value {
+				t.Errorf("Record %d: expected %s=%q, got %q", i, name, value, got[name])
+			}
+		}
+	}
+}
+
+func TestTransforms(t *testing.T) {
+	transformer := New(
+		Redact("detail.serviceAgent.username", "resources", "detail.missing.path"),
+		Enrich(func(event interface{}) map[string]interface{} {
+			call := event.(*events.CallSummaryEvent)
+			return map[string]interface{}{
+				"quality": events.GetCallQualityLevel(call.Detail.WebRTCSession.Metrics.MOS.Avg),
+			}
+		}),
+		Flatten("_"),
+	)
+	transformer.PartitionKeys = nil
+
+	resp, err := transformer.Handle(context.Background(), Event{Records: []EventRecord{
+		{RecordID: "call", Data: fixture(t, "call_summary.json")},
+	}})
+	if err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+
+	record := resp.Records[0]
+	if record.Result != ResultOk || record.Metadata != nil {
+		t.Fatalf("Unexpected record: %+v", record)
+	}
+
+	document := decode(t, record.Data)
+	tests := []struct {
+		key      string
+		expected interface{}
+	}{
+		{"detail_serviceAgent_username", RedactedValue},
+		{"resources", RedactedValue},
+		{"quality", string(events.QualityGood)},
+		{"detail_webRTCSession_metrics_mos_avg", 4.25},
+		{"detail-type", "CallSummary"},
+	}
+	for _, test := range tests {
+		if document[test.key] != test.expected {
+			t.Errorf("%s = %v, expected %v", test.key, document[test.key], test.expected)
+		}
+	}
+	if _, ok := document["detail"]; ok {
+		t.Error("Expected nested detail to be flattened")
+	}
+}
+
+func TestHandleCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := New().Handle(ctx, Event{Records: []EventRecord{{RecordID: "call"}}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
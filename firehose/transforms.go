@@ -0,0 +1,81 @@
+package firehose
+
+import "strings"
+
+// RedactedValue replaces the values removed by Redact
+const RedactedValue = "[REDACTED]"
+
+// Redact replaces the values at the given dot-separated paths, such as
+// "detail.serviceAgent.username", with RedactedValue. A path that passes
+// through an array applies to every element; missing paths are ignored.
+func Redact(paths ...string) Transform {
+	split := make([][]string, len(paths))
+	for i, path := range paths {
+		split[i] = strings.Split(path, ".")
+	}
+
+	return func(record *Record) error {
+		for _, path := range split {
+			redact(record.Document, path)
+		}
+		return nil
+	}
+}
+
+// redact replaces the value at path below value
+func redact(value interface{}, path []string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		child, ok := v[path[0]]
+		if !ok {
+			return
+		}
+		if len(path) == 1 {
+			v[path[0]] = RedactedValue
+			return
+		}
+		redact(child, path[1:])
+
+	case []interface{}:
+		for _, element := range v {
+			redact(element, path)
+		}
+	}
+}
+
+// Flatten replaces nested objects with top-level members whose names join
+// the path with separator, for example detail_webRTCSession_metrics_mos_avg.
+// Arrays are kept as they are.
+func Flatten(separator string) Transform {
+	return func(record *Record) error {
+		flat := make(map[string]interface{})
+		flatten(flat, "", separator, record.Document)
+		record.Document = flat
+		return nil
+	}
+}
+
+// flatten copies the members of object into flat, prefixing their names
+func flatten(flat map[string]interface{}, prefix, separator string, object map[string]interface{}) {
+	for key, value := range object {
+		if prefix != "" {
+			key = prefix + separator + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flatten(flat, key, separator, nested)
+			continue
+		}
+		flat[key] = value
+	}
+}
+
+// Enrich adds the members returned by fn to the top level of the document,
+// replacing any with the same name. fn receives the typed event.
+func Enrich(fn func(event interface{}) map[string]interface{}) Transform {
+	return func(record *Record) error {
+		for key, value := range fn(record.Event) {
+			record.Document[key] = value
+		}
+		return nil
+	}
+}
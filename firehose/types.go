@@ -0,0 +1,54 @@
+package firehose
+
+// Transformation results reported to Firehose
+const (
+	ResultOk               = "Ok"
+	ResultDropped          = "Dropped"
+	ResultProcessingFailed = "ProcessingFailed"
+)
+
+// Event is the batch of records Firehose sends to a transformation Lambda
+type Event struct {
+	InvocationID           string        `json:"invocationId"`
+	DeliveryStreamArn      string        `json:"deliveryStreamArn"`
+	SourceKinesisStreamArn string        `json:"sourceKinesisStreamArn"`
+	Region                 string        `json:"region"`
+	Records                []EventRecord `json:"records"`
+}
+
+// EventRecord is a single record to transform
+type EventRecord struct {
+	RecordID string `json:"recordId"`
+	// ApproximateArrivalTimestamp is in milliseconds since the epoch
+	ApproximateArrivalTimestamp int64          `json:"approximateArrivalTimestamp"`
+	Data                        []byte         `json:"data"`
+	KinesisRecordMetadata       RecordMetadata `json:"kinesisRecordMetadata"`
+}
+
+// RecordMetadata describes the Kinesis record a Firehose record was read from
+type RecordMetadata struct {
+	ShardID                     string `json:"shardId"`
+	PartitionKey                string `json:"partitionKey"`
+	SequenceNumber              string `json:"sequenceNumber"`
+	SubsequenceNumber           int64  `json:"subsequenceNumber"`
+	ApproximateArrivalTimestamp int64  `json:"approximateArrivalTimestamp"`
+}
+
+// Response is returned to Firehose with one record per input record
+type Response struct {
+	Records []ResponseRecord `json:"records"`
+}
+
+// ResponseRecord is the transformed record and its result
+type ResponseRecord struct {
+	RecordID string `json:"recordId"`
+	Result   string `json:"result"`
+	Data     []byte `json:"data"`
+	// Metadata is set on Ok records when dynamic partitioning is enabled
+	Metadata *ResponseRecordMetadata `json:"metadata,omitempty"`
+}
+
+// ResponseRecordMetadata holds the keys used for dynamic partitioning
+type ResponseRecordMetadata struct {
+	PartitionKeys map[string]string `json:"partitionKeys"`
+}
@@ -0,0 +1,28 @@
+// Package pattern evaluates Amazon EventBridge event patterns locally so rule
+// patterns for Operata events can be unit-tested without an AWS account.
+//
+// The supported content filters follow EventBridge semantics:
+//
+//   - exact matching on strings, numbers, booleans and null
+//   - prefix, suffix, equals-ignore-case and wildcard
+//   - anything-but, with a single value, a list, or a prefix/suffix filter
+//   - numeric ranges such as {"numeric": [">=", 3.1, "<", 3.6]}
+//   - exists
+//   - cidr
+//   - $or across groups of fields
+//
+// Arrays in the event match when any of their elements match, and the values
+// in a pattern leaf are alternatives.
+//
+// Example usage:
+//
+//	p, err := pattern.Compile([]byte(`{
+//		"detail-type": ["CallSummary"],
+//		"detail": {"webRTCSession": {"metrics": {"mos": {"avg": [{"numeric": ["<", 3.5]}]}}}}
+//	}`))
+//	if err != nil {
+//		// handle error
+//	}
+//
+//	matched, err := p.Match(data)
+package pattern
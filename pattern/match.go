@@ -0,0 +1,339 @@
+package pattern
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// condition is a single alternative in a pattern leaf
+type condition interface {
+	match(value interface{}) bool
+}
+
+// existsCondition matches on whether the member is present at all
+type existsCondition bool
+
+func (existsCondition) match(interface{}) bool { return false }
+
+// exactCondition matches a literal string, number, boolean or null
+type exactCondition struct {
+	value interface{}
+}
+
+func (c exactCondition) match(value interface{}) bool {
+	return equalValues(c.value, value)
+}
+
+type prefixCondition struct {
+	prefix     string
+	ignoreCase bool
+}
+
+func (c prefixCondition) match(value interface{}) bool {
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	if c.ignoreCase {
+		return len(s) >= len(c.prefix) && strings.EqualFold(s[:len(c.prefix)], c.prefix)
+	}
+	return strings.HasPrefix(s, c.prefix)
+}
+
+type suffixCondition struct {
+	suffix     string
+	ignoreCase bool
+}
+
+func (c suffixCondition) match(value interface{}) bool {
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	if c.ignoreCase {
+		return len(s) >= len(c.suffix) && strings.EqualFold(s[len(s)-len(c.suffix):], c.suffix)
+	}
+	return strings.HasSuffix(s, c.suffix)
+}
+
+type equalsIgnoreCaseCondition string
+
+func (c equalsIgnoreCaseCondition) match(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && strings.EqualFold(s, string(c))
+}
+
+type wildcardCondition string
+
+func (c wildcardCondition) match(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && wildcardMatch(string(c), s)
+}
+
+// anythingButCondition matches any value that the inner conditions do not
+type anythingButCondition struct {
+	excluded []condition
+}
+
+func (c anythingButCondition) match(value interface{}) bool {
+	for _, excluded := range c.excluded {
+		if excluded.match(value) {
+			return false
+		}
+	}
+	return true
+}
+
+type numericBound struct {
+	op    string
+	value float64
+}
+
+// numericCondition matches numbers satisfying every bound
+type numericCondition []numericBound
+
+func (c numericCondition) match(value interface{}) bool {
+	n, ok := toFloat(value)
+	if !ok {
+		return false
+	}
+	for _, bound := range c {
+		var satisfied bool
+		switch bound.op {
+		case "<":
+			satisfied = n < bound.value
+		case "<=":
+			satisfied = n <= bound.value
+		case ">":
+			satisfied = n > bound.value
+		case ">=":
+			satisfied = n >= bound.value
+		case "=":
+			satisfied = n == bound.value
+		}
+		if !satisfied {
+			return false
+		}
+	}
+	return true
+}
+
+type cidrCondition struct {
+	network *net.IPNet
+}
+
+func (c cidrCondition) match(value interface{}) bool {
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	ip := net.ParseIP(s)
+	return ip != nil && c.network.Contains(ip)
+}
+
+func compileCondition(element interface{}, path string) (condition, error) {
+	filter, ok := element.(map[string]interface{})
+	if !ok {
+		if _, isArray := element.([]interface{}); isArray {
+			return nil, fmt.Errorf("invalid pattern at %s: match values cannot be nested arrays", path)
+		}
+		return exactCondition{value: element}, nil
+	}
+
+	op, operand, ok := singleMember(filter)
+	if !ok {
+		return nil, fmt.Errorf("invalid pattern at %s: content filter must have exactly one operator", path)
+	}
+
+	switch op {
+	case "exists":
+		b, ok := operand.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid pattern at %s: exists takes true or false", path)
+		}
+		return existsCondition(b), nil
+
+	case "prefix", "suffix":
+		return compileAffix(op, operand, path)
+
+	case "equals-ignore-case":
+		s, ok := operand.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid pattern at %s: equals-ignore-case takes a string", path)
+		}
+		return equalsIgnoreCaseCondition(s), nil
+
+	case "wildcard":
+		s, ok := operand.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid pattern at %s: wildcard takes a string", path)
+		}
+		return wildcardCondition(s), nil
+
+	case "anything-but":
+		return compileAnythingBut(operand, path)
+
+	case "numeric":
+		return compileNumeric(operand, path)
+
+	case "cidr":
+		s, ok := operand.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid pattern at %s: cidr takes a string", path)
+		}
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern at %s: %w", path, err)
+		}
+		return cidrCondition{network: network}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid pattern at %s: unknown content filter %q", path, op)
+	}
+}
+
+func compileAffix(op string, operand interface{}, path string) (condition, error) {
+	value, ignoreCase := operand, false
+	if nested, ok := operand.(map[string]interface{}); ok {
+		inner, ok := nested["equals-ignore-case"]
+		if !ok || len(nested) != 1 {
+			return nil, fmt.Errorf("invalid pattern at %s: %s takes a string or {\"equals-ignore-case\": string}", path, op)
+		}
+		value, ignoreCase = inner, true
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid pattern at %s: %s takes a string", path, op)
+	}
+
+	if op == "prefix" {
+		return prefixCondition{prefix: s, ignoreCase: ignoreCase}, nil
+	}
+	return suffixCondition{suffix: s, ignoreCase: ignoreCase}, nil
+}
+
+func compileAnythingBut(operand interface{}, path string) (condition, error) {
+	switch v := operand.(type) {
+	case []interface{}:
+		c := anythingButCondition{}
+		for _, element := range v {
+			if _, ok := element.(map[string]interface{}); ok {
+				return nil, fmt.Errorf("invalid pattern at %s: anything-but lists may only hold literals", path)
+			}
+			c.excluded = append(c.excluded, exactCondition{value: element})
+		}
+		return c, nil
+
+	case map[string]interface{}:
+		op, inner, ok := singleMember(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid pattern at %s: anything-but filter must have exactly one operator", path)
+		}
+		switch op {
+		case "prefix", "suffix":
+			c, err := compileAffix(op, inner, path)
+			if err != nil {
+				return nil, err
+			}
+			return anythingButCondition{excluded: []condition{c}}, nil
+		case "equals-ignore-case":
+			s, ok := inner.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid pattern at %s: equals-ignore-case takes a string", path)
+			}
+			return anythingButCondition{excluded: []condition{equalsIgnoreCaseCondition(s)}}, nil
+		default:
+			return nil, fmt.Errorf("invalid pattern at %s: unsupported anything-but filter %q", path, op)
+		}
+	}
+
+	return anythingButCondition{excluded: []condition{exactCondition{value: operand}}}, nil
+}
+
+func compileNumeric(operand interface{}, path string) (condition, error) {
+	terms, ok := operand.([]interface{})
+	if !ok || len(terms) == 0 || len(terms)%2 != 0 || len(terms) > 4 {
+		return nil, fmt.Errorf("invalid pattern at %s: numeric takes one or two operator/value pairs", path)
+	}
+
+	c := make(numericCondition, 0, len(terms)/2)
+	for i := 0; i < len(terms); i += 2 {
+		op, ok := terms[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid pattern at %s: numeric operator must be a string", path)
+		}
+		switch op {
+		case "<", "<=", ">", ">=", "=":
+		default:
+			return nil, fmt.Errorf("invalid pattern at %s: unknown numeric operator %q", path, op)
+		}
+
+		value, ok := toFloat(terms[i+1])
+		if !ok {
+			return nil, fmt.Errorf("invalid pattern at %s: numeric operand for %q must be a number", path, op)
+		}
+		c = append(c, numericBound{op: op, value: value})
+	}
+
+	return c, nil
+}
+
+// singleMember returns the only member of obj
+func singleMember(obj map[string]interface{}) (string, interface{}, bool) {
+	if len(obj) != 1 {
+		return "", nil, false
+	}
+	for name, value := range obj {
+		return name, value, true
+	}
+	return "", nil, false
+}
+
+// equalValues compares JSON literals, treating numbers by value
+func equalValues(a, b interface{}) bool {
+	if an, ok := toFloat(a); ok {
+		bn, ok := toFloat(b)
+		return ok && an == bn
+	}
+	return a == b
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// wildcardMatch reports whether s matches pattern, where * matches any run of characters
+func wildcardMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
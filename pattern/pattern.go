@@ -0,0 +1,261 @@
+package pattern
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Pattern is a compiled EventBridge event pattern
+type Pattern struct {
+	source json.RawMessage
+	root   *objectMatcher
+}
+
+// objectMatcher matches a JSON object: every field must match and, when
+// present, at least one $or alternative must match
+type objectMatcher struct {
+	fields []fieldMatcher
+	or     []*objectMatcher
+}
+
+// fieldMatcher matches one member either against a nested object pattern or
+// against a list of alternative conditions
+type fieldMatcher struct {
+	name       string
+	object     *objectMatcher
+	conditions []condition
+}
+
+// Compile parses an EventBridge event pattern
+func Compile(data []byte) (*Pattern, error) {
+	var root map[string]interface{}
+	if err := decode(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse pattern: %w", err)
+	}
+	if root == nil {
+		return nil, fmt.Errorf("invalid pattern: must be a JSON object")
+	}
+
+	matcher, err := compileObject(root, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pattern{source: append(json.RawMessage(nil), data...), root: matcher}, nil
+}
+
+// MustCompile is like Compile but panics if the pattern is invalid
+func MustCompile(data string) *Pattern {
+	p, err := Compile([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the pattern source
+func (p *Pattern) String() string {
+	return string(p.source)
+}
+
+// MarshalJSON returns the pattern source
+func (p *Pattern) MarshalJSON() ([]byte, error) {
+	return p.source, nil
+}
+
+// UnmarshalJSON compiles a pattern embedded in a larger JSON document
+func (p *Pattern) UnmarshalJSON(data []byte) error {
+	compiled, err := Compile(data)
+	if err != nil {
+		return err
+	}
+	*p = *compiled
+	return nil
+}
+
+// Match reports whether a raw JSON event matches the pattern
+func (p *Pattern) Match(event []byte) (bool, error) {
+	var doc map[string]interface{}
+	if err := decode(event, &doc); err != nil {
+		return false, fmt.Errorf("failed to parse event: %w", err)
+	}
+	return p.MatchDocument(doc), nil
+}
+
+// MatchEvent reports whether a parsed event, such as the value returned by
+// events.ParseEventBridgeEvent, matches the pattern. The event is matched on
+// its JSON encoding.
+func (p *Pattern) MatchEvent(event interface{}) (bool, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode event: %w", err)
+	}
+	return p.Match(data)
+}
+
+// MatchDocument reports whether an event decoded into generic JSON values
+// matches the pattern. Numbers may be float64 or json.Number.
+func (p *Pattern) MatchDocument(doc map[string]interface{}) bool {
+	return p.root.match([]interface{}{doc})
+}
+
+func (m *objectMatcher) match(nodes []interface{}) bool {
+	for _, field := range m.fields {
+		values, present := collect(nodes, field.name)
+
+		if field.object != nil {
+			if !field.object.match(objectsOf(values)) {
+				return false
+			}
+			continue
+		}
+
+		if !matchConditions(field.conditions, values, present) {
+			return false
+		}
+	}
+
+	if len(m.or) == 0 {
+		return true
+	}
+	for _, alternative := range m.or {
+		if alternative.match(nodes) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchConditions(conditions []condition, values []interface{}, present bool) bool {
+	for _, c := range conditions {
+		if e, ok := c.(existsCondition); ok {
+			if bool(e) == present {
+				return true
+			}
+			continue
+		}
+		for _, value := range values {
+			if c.match(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collect returns the values of member name across nodes, flattening arrays
+// as EventBridge does, and whether any node had the member
+func collect(nodes []interface{}, name string) ([]interface{}, bool) {
+	var values []interface{}
+	present := false
+	for _, node := range nodes {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		value, ok := obj[name]
+		if !ok {
+			continue
+		}
+		present = true
+		values = appendFlattened(values, value)
+	}
+	return values, present
+}
+
+func appendFlattened(values []interface{}, value interface{}) []interface{} {
+	if array, ok := value.([]interface{}); ok {
+		for _, element := range array {
+			values = appendFlattened(values, element)
+		}
+		return values
+	}
+	return append(values, value)
+}
+
+func objectsOf(values []interface{}) []interface{} {
+	var objects []interface{}
+	for _, value := range values {
+		if _, ok := value.(map[string]interface{}); ok {
+			objects = append(objects, value)
+		}
+	}
+	return objects
+}
+
+func compileObject(obj map[string]interface{}, path string) (*objectMatcher, error) {
+	m := &objectMatcher{}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := obj[name]
+		fieldPath := joinPath(path, name)
+
+		if name == "$or" {
+			alternatives, ok := value.([]interface{})
+			if !ok || len(alternatives) < 2 {
+				return nil, fmt.Errorf("invalid pattern at %s: $or needs an array of at least two patterns", fieldPath)
+			}
+			for _, alternative := range alternatives {
+				altObj, ok := alternative.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("invalid pattern at %s: $or alternatives must be objects", fieldPath)
+				}
+				compiled, err := compileObject(altObj, path)
+				if err != nil {
+					return nil, err
+				}
+				m.or = append(m.or, compiled)
+			}
+			continue
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			nested, err := compileObject(v, fieldPath)
+			if err != nil {
+				return nil, err
+			}
+			m.fields = append(m.fields, fieldMatcher{name: name, object: nested})
+
+		case []interface{}:
+			if len(v) == 0 {
+				return nil, fmt.Errorf("invalid pattern at %s: empty match list", fieldPath)
+			}
+			conditions := make([]condition, 0, len(v))
+			for _, element := range v {
+				c, err := compileCondition(element, fieldPath)
+				if err != nil {
+					return nil, err
+				}
+				conditions = append(conditions, c)
+			}
+			m.fields = append(m.fields, fieldMatcher{name: name, conditions: conditions})
+
+		default:
+			return nil, fmt.Errorf("invalid pattern at %s: match values must be in an array", fieldPath)
+		}
+	}
+
+	return m, nil
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func decode(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
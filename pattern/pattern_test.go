@@ -0,0 +1,128 @@
+package pattern
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+func loadFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "events", "testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	return data
+}
+
+func TestMatchCallSummaryFixture(t *testing.T) {
+	data := loadFixture(t, "call_summary.json")
+
+	tests := []struct {
+		name     string
+		pattern  string
+		expected bool
+	}{
+		{"detail-type exact", `{"detail-type": ["CallSummary"]}`, true},
+		{"detail-type mismatch", `{"detail-type": ["InsightsSummary"]}`, false},
+		{"source prefix", `{"source": [{"prefix": "aws.partner/operata.com/"}]}`, true},
+		{"source prefix ignore case", `{"source": [{"prefix": {"equals-ignore-case": "AWS.PARTNER/"}}]}`, true},
+		{"suffix", `{"source": [{"suffix": "andyEventBus"}]}`, true},
+		{"mos below threshold", `{"detail": {"webRTCSession": {"metrics": {"mos": {"avg": [{"numeric": ["<", 3.5]}]}}}}}`, false},
+		{"mos in range", `{"detail": {"webRTCSession": {"metrics": {"mos": {"avg": [{"numeric": [">=", 4, "<", 4.3]}]}}}}}`, true},
+		{"numeric equals int as float", `{"detail": {"billing": {"durationRoundedMin": [1.0]}}}`, true},
+		{"anything-but list", `{"detail": {"contact": {"direction": [{"anything-but": ["Outbound", "Transfer"]}]}}}`, true},
+		{"anything-but value", `{"detail": {"contact": {"direction": [{"anything-but": "Inbound"}]}}}`, false},
+		{"anything-but prefix", `{"detail": {"contact": {"queueName": [{"anything-but": {"prefix": "Operata"}}]}}}`, false},
+		{"exists true", `{"detail": {"contact": {"callerId": [{"exists": true}]}}}`, true},
+		{"exists false on missing", `{"detail": {"contact": {"transferredTo": [{"exists": false}]}}}`, true},
+		{"exists false on present", `{"detail": {"contact": {"callerId": [{"exists": false}]}}}`, false},
+		{"missing field never matches exact", `{"detail": {"contact": {"transferredTo": ["x"]}}}`, false},
+		{"array element match", `{"detail": {"webRTCSession": {"usedDevices": {"kind": ["audioinput"]}}}}`, true},
+		{"wildcard", `{"detail": {"webRTCSession": {"usedDevices": {"label": [{"wildcard": "*Elgato*"}]}}}}`, true},
+		{"equals-ignore-case", `{"detail": {"serviceAgent": {"username": [{"equals-ignore-case": "ANDY"}]}}}`, true},
+		{"cidr", `{"detail": {"serviceAgent": {"network": {"mediaIpAddress": [{"cidr": "192.168.0.0/16"}]}}}}`, true},
+		{"unknown detail member", `{"detail": {"recording": {"enabled": [true]}}}`, true},
+		{"$or", `{"$or": [{"detail-type": ["InsightsSummary"]}, {"detail": {"contact": {"endedBy": ["Agent"]}}}]}`, true},
+		{"$or none", `{"$or": [{"detail-type": ["InsightsSummary"]}, {"detail": {"contact": {"endedBy": ["Customer"]}}}]}`, false},
+		{"multiple alternatives", `{"region": ["us-east-1", "ap-southeast-2"]}`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := Compile([]byte(test.pattern))
+			if err != nil {
+				t.Fatalf("Failed to compile pattern: %v", err)
+			}
+
+			matched, err := p.Match(data)
+			if err != nil {
+				t.Fatalf("Failed to match: %v", err)
+			}
+			if matched != test.expected {
+				t.Errorf("Match(%s) = %v, expected %v", test.pattern, matched, test.expected)
+			}
+		})
+	}
+}
+
+func TestMatchParsedEvent(t *testing.T) {
+	event, err := events.ParseEventBridgeEvent(loadFixture(t, "insights_summary.json"))
+	if err != nil {
+		t.Fatalf("Failed to parse event: %v", err)
+	}
+
+	p := MustCompile(`{"detail-type": ["InsightsSummary"], "detail": {"insights": {"tags": {"description": ["High Packet Loss"]}}}}`)
+	matched, err := p.MatchEvent(event)
+	if err != nil {
+		t.Fatalf("Failed to match: %v", err)
+	}
+	if !matched {
+		t.Error("Expected parsed InsightsSummary event to match")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []string{
+		`[]`,
+		`{"detail-type": "CallSummary"}`,
+		`{"detail-type": []}`,
+		`{"a": [{"numeric": ["<"]}]}`,
+		`{"a": [{"numeric": ["~", 3]}]}`,
+		`{"a": [{"exists": "yes"}]}`,
+		`{"a": [{"unknown": 1}]}`,
+		`{"a": [{"prefix": "a", "suffix": "b"}]}`,
+		`{"a": [{"cidr": "not-a-cidr"}]}`,
+		`{"$or": [{"a": ["b"]}]}`,
+	}
+
+	for _, pattern := range tests {
+		if _, err := Compile([]byte(pattern)); err == nil {
+			t.Errorf("Compile(%s) expected error", pattern)
+		}
+	}
+}
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		value    string
+		expected bool
+	}{
+		{"*", "anything", true},
+		{"Jabra*", "Jabra Evolve2 65", true},
+		{"*65", "Jabra Evolve2 65", true},
+		{"J*E*65", "Jabra Evolve2 65", true},
+		{"J*x*65", "Jabra Evolve2 65", false},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+	}
+
+	for _, test := range tests {
+		if result := wildcardMatch(test.pattern, test.value); result != test.expected {
+			t.Errorf("wildcardMatch(%q, %q) = %v, expected %v", test.pattern, test.value, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// Package phone parses caller IDs into E.164 numbers and locates them
+// without a network lookup.
+//
+// Parse normalises a raw caller ID, such as CallContact.CallerID, and looks
+// it up in a numbering plan embedded in the package. The plan gives the
+// country, a region where the number's prefix identifies one, and whether
+// the number is a mobile, landline, toll-free, shared-cost or premium line
+// where the prefix tells them apart. The embedded plan covers the countries
+// Operata customers call most; North American numbers outside the listed
+// Canadian area codes are reported as US. Other plans can be loaded with
+// LoadPlan.
+//
+// Numbers are personal data, so Number also has redacted forms for logs
+// and reports: Redacted keeps the country code and last three digits, and
+// Prefix keeps only the digits the plan used to locate the number.
+//
+// Example usage:
+//
+//	number, err := phone.Parse(event.Detail.Contact.CallerID)
+//	if err == nil {
+//		fmt.Println(number.Country, number.Type, number.Redacted())
+//		// AU mobile +61******149
+//	}
+package phone
@@ -0,0 +1,133 @@
+package phone
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Line types
+const (
+	TypeMobile     = "mobile"
+	TypeLandline   = "landline"
+	TypeTollFree   = "toll-free"
+	TypeSharedCost = "shared-cost"
+	TypePremium    = "premium"
+	TypeUnknown    = "unknown"
+)
+
+// E.164 numbers have at most 15 digits; shorter than 7 is not a callable number
+const (
+	maxDigits = 15
+	minDigits = 7
+)
+
+var (
+	// ErrInvalid is returned for caller IDs that are not phone numbers
+	ErrInvalid = errors.New("invalid phone number")
+	// ErrUnknownCountry is returned when no country calling code in the plan matches
+	ErrUnknownCountry = errors.New("unknown country calling code")
+)
+
+//go:embed numbering_plan.csv
+var embeddedPlan string
+
+// DefaultPlan is the numbering plan embedded in the package
+var DefaultPlan = MustLoadPlan(strings.NewReader(embeddedPlan))
+
+// Number is a parsed phone number
+type Number struct {
+	// E164 is the normalised number, such as +61402960149
+	E164 string `json:"e164"`
+	// CountryCode is the country calling code without "+", such as 61
+	CountryCode string `json:"countryCode"`
+	// Country is the ISO 3166 country code, such as AU
+	Country string `json:"country"`
+	// National is the number after the country calling code
+	National string `json:"national"`
+	// Region is the area the number belongs to, or "" when the plan has none
+	Region string `json:"region,omitempty"`
+	// Type is the line type, or TypeUnknown when the plan does not say
+	Type string `json:"type"`
+
+	// prefix is the length of the longest plan prefix that matched
+	prefix int
+}
+
+// Parse parses a caller ID in international format using DefaultPlan
+func Parse(raw string) (Number, error) {
+	return DefaultPlan.Parse(raw, "")
+}
+
+// ParseIn parses a caller ID using DefaultPlan, reading numbers in national
+// format, such as 0402 960 149, as numbers in the given ISO 3166 country
+func ParseIn(raw, country string) (Number, error) {
+	return DefaultPlan.Parse(raw, country)
+}
+
+// String returns the E.164 form
+func (n Number) String() string {
+	return n.E164
+}
+
+// Redacted returns the number with all but the country calling code and
+// the last three digits masked, such as +61******149
+func (n Number) Redacted() string {
+	if n.E164 == "" {
+		return ""
+	}
+	keep := 3
+	if len(n.National) <= keep {
+		keep = 0
+	}
+	hidden := len(n.National) - keep
+	return "+" + n.CountryCode + strings.Repeat("*", hidden) + n.National[hidden:]
+}
+
+// Prefix returns only the digits the plan used to locate the number, such
+// as +614 for an Australian mobile; the rest of the number is dropped
+func (n Number) Prefix() string {
+	if n.E164 == "" {
+		return ""
+	}
+	return n.E164[:1+n.prefix]
+}
+
+// extension matches an extension marker and its digits at the end of a
+// number, such as " x123", ", ext. 123" or " #123"
+var extension = regexp.MustCompile(`(?i)(\d)[\s,]*(?:extension|ext\.?|x|#)\s*\d{1,6}$`)
+
+// normalise strips formatting from a caller ID and reports whether it was
+// in international format
+func normalise(raw string) (string, bool, error) {
+	s := strings.TrimSpace(raw)
+	// Drop tel URI parameters such as ";ext=123", then a trailing extension
+	if i := strings.Index(s, ";"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	s = extension.ReplaceAllString(s, "$1")
+
+	international := false
+	switch {
+	case strings.HasPrefix(s, "+"):
+		international = true
+		s = s[1:]
+	case strings.HasPrefix(s, "00"):
+		international = true
+		s = s[2:]
+	}
+
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')' || r == '/':
+		default:
+			return "", false, fmt.Errorf("%w: unexpected %q", ErrInvalid, r)
+		}
+	}
+	return digits.String(), international, nil
+}
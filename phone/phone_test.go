@@ -0,0 +1,180 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw         string
+		e164        string
+		countryCode string
+		country     string
+		region      string
+		lineType    string
+	}{
+		{"+61402960149", "+61402960149", "61", "AU", "", TypeMobile},
+		{"+61 3 9123 4567", "+61391234567", "61", "AU", "Victoria / Tasmania", TypeLandline},
+		{"+61 1800 123 456", "+611800123456", "61", "AU", "", TypeTollFree},
+		{"+61 1300 123 456", "+611300123456", "61", "AU", "", TypeSharedCost},
+		{"61 2 9123 4567", "", "", "", "", ""},
+		{"0061 2 9123 4567", "+61291234567", "61", "AU", "New South Wales / ACT", TypeLandline},
+		{"+1 (415) 555-0123", "+14155550123", "1", "US", "California", TypeUnknown},
+		{"+1 416 555 0123", "+14165550123", "1", "CA", "Ontario", TypeUnknown},
+		{"+1-800-555-0123", "+18005550123", "1", "US", "", TypeTollFree},
+		{"+1 505 555 0123", "+15055550123", "1", "US", "", TypeUnknown},
+		{"+44 20 7946 0000", "+442079460000", "44", "GB", "London", TypeLandline},
+		{"+44 161 496 0000", "+441614960000", "44", "GB", "Manchester", TypeLandline},
+		{"+44 7700 900123", "+447700900123", "44", "GB", "", TypeMobile},
+		{"+353 1 800 123 456", "+3531800123456", "353", "IE", "", TypeTollFree},
+		{"+353 1 234 5678", "+35312345678", "353", "IE", "Dublin", TypeLandline},
+		{"+52 55 1234 5678", "+525512345678", "52", "MX", "Mexico City", TypeUnknown},
+		{"+64 21 123 4567 ext. 12", "+64211234567", "64", "NZ", "", TypeMobile},
+		{"+6591234567;ext=9", "+6591234567", "65", "SG", "", TypeMobile},
+		{"+1 (415) 555-0123 x45", "+14155550123", "1", "US", "California", TypeUnknown},
+		{"+1 (415) 555-0123, extension 45", "+14155550123", "1", "US", "California", TypeUnknown},
+		{"+1 415 555 0123#45", "+14155550123", "1", "US", "California", TypeUnknown},
+		{"+14155550123EXT45", "+14155550123", "1", "US", "California", TypeUnknown},
+	}
+
+	for _, test := range tests {
+		number, err := Parse(test.raw)
+		if test.e164 == "" {
+			if err == nil {
+				t.Errorf("Parse(%q): expected error, got %+v", test.raw, number)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): unexpected error: %v", test.raw, err)
+			continue
+		}
+		if number.E164 != test.e164 || number.CountryCode != test.countryCode || number.Country != test.country ||
+			number.Region != test.region || number.Type != test.lineType {
+			t.Errorf("Parse(%q) = %+v, expected %s %s %s %q %s", test.raw, number, test.e164, test.countryCode, test.country, test.region, test.lineType)
+		}
+		if number.CountryCode+number.National != test.e164[1:] {
+			t.Errorf("Parse(%q): national number %s does not follow country code %s", test.raw, number.National, number.CountryCode)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected error
+	}{
+		{"", ErrInvalid},
+		{"anonymous", ErrInvalid},
+		{"+61 4O2 960 149", ErrInvalid},
+		{"x123 +61 402 960 149", ErrInvalid},
+		{"+61 4x02 960 149", ErrInvalid},
+		{"+61 402 960 149 ext", ErrInvalid},
+		{"ext. 12", ErrInvalid},
+		{"+61 402", ErrInvalid},
+		{"+61 4029 6014 9123 4567", ErrInvalid},
+		{"0402 960 149", ErrInvalid},
+		{"+999 123 4567", ErrUnknownCountry},
+	}
+
+	for _, test := range tests {
+		if _, err := Parse(test.raw); !errors.Is(err, test.expected) {
+			t.Errorf("Parse(%q): expected %v, got %v", test.raw, test.expected, err)
+		}
+	}
+}
+
+func TestParseIn(t *testing.T) {
+	tests := []struct {
+		raw     string
+		country string
+		e164    string
+	}{
+		{"0402 960 149", "AU", "+61402960149"},
+		{"(03) 9123 4567", "au", "+61391234567"},
+		{"020 7946 0000", "GB", "+442079460000"},
+		{"1 (415) 555-0123", "US", "+14155550123"},
+		{"416-555-0123", "CA", "+14165550123"},
+		{"+64 9 123 4567", "AU", "+6491234567"},
+	}
+
+	for _, test := range tests {
+		number, err := ParseIn(test.raw, test.country)
+		if err != nil {
+			t.Errorf("ParseIn(%q, %s): unexpected error: %v", test.raw, test.country, err)
+			continue
+		}
+		if number.E164 != test.e164 {
+			t.Errorf("ParseIn(%q, %s) = %s, expected %s", test.raw, test.country, number.E164, test.e164)
+		}
+	}
+
+	if _, err := ParseIn("0402 960 149", "XX"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected ErrInvalid for an unknown country, got %v", err)
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	tests := []struct {
+		raw      string
+		redacted string
+		prefix   string
+	}{
+		{"+61402960149", "+61******149", "+614"},
+		{"+442079460000", "+44*******000", "+4420"},
+		{"+15055550123", "+1*******123", "+1"},
+		{"+3531800123456", "+353*******456", "+3531800"},
+	}
+
+	for _, test := range tests {
+		number, err := Parse(test.raw)
+		if err != nil {
+			t.Fatalf("Parse(%q): unexpected error: %v", test.raw, err)
+		}
+		if redacted := number.Redacted(); redacted != test.redacted {
+			t.Errorf("Redacted(%s) = %s, expected %s", test.raw, redacted, test.redacted)
+		}
+		if prefix := number.Prefix(); prefix != test.prefix {
+			t.Errorf("Prefix(%s) = %s, expected %s", test.raw, prefix, test.prefix)
+		}
+		if number.String() != test.raw {
+			t.Errorf("String() = %s, expected %s", number.String(), test.raw)
+		}
+	}
+
+	var zero Number
+	if zero.Redacted() != "" || zero.Prefix() != "" {
+		t.Error("Expected empty redactions for the zero Number")
+	}
+}
+
+func TestLoadPlan(t *testing.T) {
+	plan, err := LoadPlan(strings.NewReader("prefix,country,region,type\n# comment\n7,RU,,\n77,KZ,,\n7495,RU,Moscow,landline\n"))
+	if err != nil {
+		t.Fatalf("Failed to load plan: %v", err)
+	}
+	number, err := plan.Parse("+7 701 123 4567", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if number.Country != "KZ" || number.CountryCode != "77" {
+		t.Errorf("Expected KZ under calling code 77, got %+v", number)
+	}
+	if number, _ := plan.Parse("0495 123 4567", "RU"); number.Region != "Moscow" {
+		t.Errorf("Expected Moscow, got %+v", number)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"prefix,country\n61,AU\n",
+		"prefix,country,region,type\n+61,AU,,\n",
+		"prefix,country,region,type\n61,AU,,\n61,AU,,\n",
+		"prefix,country,region,type\n614,AU,,mobile\n",
+	} {
+		if _, err := LoadPlan(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected error loading %q", invalid)
+		}
+	}
+}
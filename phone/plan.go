@@ -0,0 +1,154 @@
+package phone
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Plan is a numbering plan: prefixes of E.164 numbers mapped to countries,
+// regions and line types
+type Plan struct {
+	rows         map[string]planRow
+	callingCodes map[string]bool
+	// countryCodes maps ISO 3166 codes to their calling codes, for national numbers
+	countryCodes map[string]string
+	longest      int
+}
+
+type planRow struct {
+	country, region, lineType string
+}
+
+// LoadPlan reads a numbering plan CSV with the header
+// prefix,country,region,type. Lines starting with # are comments. A row
+// with neither region nor type declares a country calling code.
+func LoadPlan(r io.Reader) (*Plan, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 4
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read numbering plan header: %w", err)
+	}
+	if strings.Join(header, ",") != "prefix,country,region,type" {
+		return nil, fmt.Errorf("invalid numbering plan header %q", strings.Join(header, ","))
+	}
+
+	plan := &Plan{
+		rows:         make(map[string]planRow),
+		callingCodes: make(map[string]bool),
+		countryCodes: make(map[string]string),
+	}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read numbering plan: %w", err)
+		}
+
+		prefix := record[0]
+		if prefix == "" || strings.Trim(prefix, "0123456789") != "" {
+			return nil, fmt.Errorf("invalid numbering plan prefix %q", prefix)
+		}
+		if _, ok := plan.rows[prefix]; ok {
+			return nil, fmt.Errorf("duplicate numbering plan prefix %s", prefix)
+		}
+		row := planRow{country: record[1], region: record[2], lineType: record[3]}
+		plan.rows[prefix] = row
+		plan.longest = max(plan.longest, len(prefix))
+		if row.region == "" && row.lineType == "" {
+			plan.callingCodes[prefix] = true
+		}
+	}
+
+	for prefix, row := range plan.rows {
+		code := plan.callingCode(prefix)
+		if code == "" {
+			return nil, fmt.Errorf("numbering plan prefix %s is not under a country calling code", prefix)
+		}
+		if existing, ok := plan.countryCodes[row.country]; !ok || len(code) < len(existing) {
+			plan.countryCodes[row.country] = code
+		}
+	}
+	return plan, nil
+}
+
+// MustLoadPlan is like LoadPlan but panics if the plan is invalid
+func MustLoadPlan(r io.Reader) *Plan {
+	plan, err := LoadPlan(r)
+	if err != nil {
+		panic(err)
+	}
+	return plan
+}
+
+// callingCode returns the longest calling code that prefixes digits
+func (p *Plan) callingCode(digits string) string {
+	for length := min(len(digits), p.longest); length > 0; length-- {
+		if p.callingCodes[digits[:length]] {
+			return digits[:length]
+		}
+	}
+	return ""
+}
+
+// Parse parses a caller ID. Numbers in international format, starting with
+// + or 00, are parsed as they are. Otherwise, when country is set, the
+// number is read in that country's national format with its trunk prefix
+// (0, or 1 in North America) removed.
+func (p *Plan) Parse(raw, country string) (Number, error) {
+	digits, international, err := normalise(raw)
+	if err != nil {
+		return Number{}, err
+	}
+	if !international {
+		code, ok := p.countryCodes[strings.ToUpper(country)]
+		if !ok {
+			return Number{}, fmt.Errorf("%w: %q is not in international format", ErrInvalid, raw)
+		}
+		trunk := "0"
+		if code == "1" {
+			trunk = "1"
+		}
+		digits = code + strings.TrimPrefix(digits, trunk)
+	}
+	if len(digits) < minDigits || len(digits) > maxDigits {
+		return Number{}, fmt.Errorf("%w: %q has %d digits", ErrInvalid, raw, len(digits))
+	}
+
+	code := p.callingCode(digits)
+	if code == "" {
+		return Number{}, fmt.Errorf("%w: %q", ErrUnknownCountry, raw)
+	}
+
+	number := Number{
+		E164:        "+" + digits,
+		CountryCode: code,
+		National:    digits[len(code):],
+		Type:        TypeUnknown,
+	}
+	// The longest matching row gives the country and region; the line type
+	// comes from the longest row that has one
+	for length := min(len(digits), p.longest); length >= len(code); length-- {
+		row, ok := p.rows[digits[:length]]
+		if !ok {
+			continue
+		}
+		if number.Country == "" {
+			number.Country = row.country
+			number.Region = row.region
+			number.prefix = length
+		}
+		if row.lineType != "" {
+			number.Type = row.lineType
+			break
+		}
+	}
+	return number, nil
+}
@@ -0,0 +1,27 @@
+// Package replay provides a small local stand-in for an EventBridge bus that
+// replays captured Operata events into consumers during integration tests.
+//
+// A Server loads event archives (NDJSON or concatenated JSON, optionally
+// gzipped, as Firehose writes them to S3), matches
+// each event against rules written as EventBridge patterns, and delivers
+// matches to HTTP endpoints or in-process handlers. Replays can keep the
+// original spacing between events, scaled by a time-warp factor, and can be
+// capped to a maximum delivery rate.
+//
+// Example usage:
+//
+//	server := replay.NewServer(replay.Options{TimeWarp: 60})
+//	server.AddRule(replay.Rule{
+//		Name:    "poor-calls",
+//		Pattern: pattern.MustCompile(`{"detail-type": ["CallSummary"]}`),
+//		Targets: []replay.Target{replay.HTTPTarget{URL: "http://localhost:8080/events"}},
+//	})
+//	if err := server.LoadFile("testdata/archive.ndjson"); err != nil {
+//		// handle error
+//	}
+//	stats, err := server.Run(ctx)
+//
+// The Server is also an http.Handler that accepts EventBridge PutEvents
+// requests, so producers using an AWS SDK pointed at it publish straight
+// into the same rules.
+package replay
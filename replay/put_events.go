@@ -0,0 +1,161 @@
+package replay
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// maxPutEventsBody bounds a PutEvents request body
+const maxPutEventsBody = 1 << 20
+
+// putEventsRequest is the subset of the EventBridge PutEvents request the server understands
+type putEventsRequest struct {
+	Entries []putEventsEntry `json:"Entries"`
+}
+
+type putEventsEntry struct {
+	Source       string      `json:"Source"`
+	DetailType   string      `json:"DetailType"`
+	Detail       string      `json:"Detail"`
+	Resources    []string    `json:"Resources"`
+	EventBusName string      `json:"EventBusName"`
+	Time         json.Number `json:"Time"`
+}
+
+type putEventsResponse struct {
+	FailedEntryCount int                    `json:"FailedEntryCount"`
+	Entries          []putEventsResultEntry `json:"Entries"`
+}
+
+type putEventsResultEntry struct {
+	EventID      string `json:"EventId,omitempty"`
+	ErrorCode    string `json:"ErrorCode,omitempty"`
+	ErrorMessage string `json:"ErrorMessage,omitempty"`
+}
+
+// ServeHTTP accepts EventBridge PutEvents requests and delivers each entry to
+// matching rules immediately. Entries that cannot be built into an event, or
+// whose delivery fails, are reported as failed entries as EventBridge does.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPutEventsBody+1))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxPutEventsBody {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var req putEventsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "failed to parse PutEvents request", http.StatusBadRequest)
+		return
+	}
+
+	resp := putEventsResponse{Entries: make([]putEventsResultEntry, 0, len(req.Entries))}
+	for _, entry := range req.Entries {
+		record, err := s.recordFromEntry(entry)
+		if err != nil {
+			resp.FailedEntryCount++
+			resp.Entries = append(resp.Entries, putEventsResultEntry{
+				ErrorCode:    "MalformedDetail",
+				ErrorMessage: err.Error(),
+			})
+			continue
+		}
+
+		var stats Stats
+		s.dispatch(r.Context(), record, &stats)
+		if stats.Failed > 0 {
+			resp.FailedEntryCount++
+			resp.Entries = append(resp.Entries, putEventsResultEntry{
+				ErrorCode:    "InternalException",
+				ErrorMessage: stats.Errors[0].Error(),
+			})
+			continue
+		}
+
+		resp.Entries = append(resp.Entries, putEventsResultEntry{EventID: record.ID})
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// recordFromEntry wraps a PutEvents entry in an EventBridge envelope
+func (s *Server) recordFromEntry(entry putEventsEntry) (Record, error) {
+	if !json.Valid([]byte(entry.Detail)) {
+		return Record{}, fmt.Errorf("detail is not valid JSON")
+	}
+
+	eventTime := time.Now().UTC()
+	if entry.Time != "" {
+		seconds, err := entry.Time.Float64()
+		if err != nil {
+			return Record{}, fmt.Errorf("invalid time: %w", err)
+		}
+		whole, frac := math.Modf(seconds)
+		eventTime = time.Unix(int64(whole), int64(frac*1e9)).UTC()
+	}
+
+	resources := entry.Resources
+	if resources == nil {
+		resources = []string{}
+	}
+
+	envelope := struct {
+		Version    string          `json:"version"`
+		ID         string          `json:"id"`
+		DetailType string          `json:"detail-type"`
+		Source     string          `json:"source"`
+		Account    string          `json:"account"`
+		Time       time.Time       `json:"time"`
+		Region     string          `json:"region"`
+		Resources  []string        `json:"resources"`
+		Detail     json.RawMessage `json:"detail"`
+	}{
+		Version:    "0",
+		ID:         newEventID(),
+		DetailType: entry.DetailType,
+		Source:     entry.Source,
+		Account:    s.opts.Account,
+		Time:       eventTime.Truncate(time.Second),
+		Region:     s.opts.Region,
+		Resources:  resources,
+		Detail:     json.RawMessage(entry.Detail),
+	}
+
+	raw, err := json.Marshal(envelope)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	event, err := events.ParseEventBridgeEvent(raw)
+	if err != nil {
+		return Record{}, err
+	}
+	return newRecord(raw, event), nil
+}
+
+// newEventID returns a random UUID in the form EventBridge uses for event IDs
+func newEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
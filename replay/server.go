@@ -0,0 +1,248 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+	"github.com/tommyorndorff/operata-events/pattern"
+)
+
+// Record is an event loaded from an archive or published to the server
+type Record struct {
+	// Raw is the event JSON exactly as delivered to targets
+	Raw json.RawMessage
+	// Event is the typed event returned by events.ParseEventBridgeEvent
+	Event interface{}
+	ID    string
+	Time  time.Time
+	// Offset is the byte offset of the event in its decompressed archive, or -1 when published
+	Offset int64
+}
+
+// Rule delivers events matching Pattern to every target. A nil Pattern matches every event.
+type Rule struct {
+	Name    string
+	Pattern *pattern.Pattern
+	Targets []Target
+}
+
+// Options controls replay pacing
+type Options struct {
+	// TimeWarp scales the original spacing between events: 1 replays in real
+	// time, 60 replays an hour per minute, and 0 disables spacing entirely.
+	TimeWarp float64
+	// Rate caps deliveries at this many events per second; 0 means unlimited
+	Rate float64
+	// Account and Region fill the envelope of events published through
+	// PutEvents; they default to 000000000000 and us-east-1.
+	Account string
+	Region  string
+}
+
+// Stats summarises a replay
+type Stats struct {
+	Events    int
+	Matched   int
+	Delivered int
+	Failed    int
+	Errors    []error
+}
+
+// DeliveryError records a failed delivery to a rule target
+type DeliveryError struct {
+	Rule    string
+	EventID string
+	Err     error
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("rule %s: event %s: %v", e.Rule, e.EventID, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// Server replays archived events to rule targets
+type Server struct {
+	opts Options
+
+	mu      sync.RWMutex
+	rules   []Rule
+	records []Record
+
+	// sleep waits for d or until ctx is done; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewServer creates a replay server
+func NewServer(opts Options) *Server {
+	if opts.Account == "" {
+		opts.Account = "000000000000"
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	return &Server{opts: opts, sleep: sleepContext}
+}
+
+// AddRule registers a rule
+func (s *Server) AddRule(rule Rule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(s.rules, rule)
+}
+
+// LoadFile loads an NDJSON archive from disk
+func (s *Server) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	if err := s.Load(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Load appends the events of an archive to the replay queue. Archives may be
+// newline-delimited or concatenated JSON, optionally gzipped; any record that
+// cannot be parsed fails the whole load.
+func (s *Server) Load(r io.Reader) error {
+	reader := events.NewReader(r)
+
+	var records []Record
+	for event, err := range reader.All() {
+		if err != nil {
+			return err
+		}
+
+		record := newRecord(append(json.RawMessage(nil), reader.Raw()...), event)
+		record.Offset = reader.Offset()
+		records = append(records, record)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, records...)
+	return nil
+}
+
+// Run replays every loaded event in archive order, pacing deliveries per the
+// server options. Delivery failures are counted in Stats and do not stop the
+// replay; Run returns early only when ctx is cancelled.
+func (s *Server) Run(ctx context.Context) (Stats, error) {
+	s.mu.RLock()
+	records := append([]Record(nil), s.records...)
+	s.mu.RUnlock()
+
+	var stats Stats
+	var lastDelivery time.Time
+	for i, record := range records {
+		if wait := s.wait(records, i, lastDelivery); wait > 0 {
+			if err := s.sleep(ctx, wait); err != nil {
+				return stats, err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		lastDelivery = time.Now()
+		s.dispatch(ctx, record, &stats)
+	}
+
+	return stats, nil
+}
+
+// wait returns how long to pause before delivering records[i]
+func (s *Server) wait(records []Record, i int, lastDelivery time.Time) time.Duration {
+	if i == 0 {
+		return 0
+	}
+
+	var wait time.Duration
+	if s.opts.TimeWarp > 0 {
+		gap := records[i].Time.Sub(records[i-1].Time)
+		if gap > 0 {
+			wait = time.Duration(float64(gap) / s.opts.TimeWarp)
+		}
+	}
+
+	if s.opts.Rate > 0 {
+		interval := time.Duration(float64(time.Second) / s.opts.Rate)
+		if remaining := interval - time.Since(lastDelivery); remaining > wait {
+			wait = remaining
+		}
+	}
+
+	return wait
+}
+
+// dispatch delivers a record to the targets of every matching rule
+func (s *Server) dispatch(ctx context.Context, record Record, stats *Stats) {
+	stats.Events++
+
+	var doc map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(record.Raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		stats.Failed++
+		stats.Errors = append(stats.Errors, fmt.Errorf("event %s: %w", record.ID, err))
+		return
+	}
+
+	s.mu.RLock()
+	rules := append([]Rule(nil), s.rules...)
+	s.mu.RUnlock()
+
+	matched := false
+	for _, rule := range rules {
+		if rule.Pattern != nil && !rule.Pattern.MatchDocument(doc) {
+			continue
+		}
+		matched = true
+
+		for _, target := range rule.Targets {
+			if err := target.Deliver(ctx, record); err != nil {
+				stats.Failed++
+				stats.Errors = append(stats.Errors, &DeliveryError{Rule: rule.Name, EventID: record.ID, Err: err})
+				continue
+			}
+			stats.Delivered++
+		}
+	}
+
+	if matched {
+		stats.Matched++
+	}
+}
+
+func newRecord(raw json.RawMessage, event interface{}) Record {
+	record := Record{Raw: raw, Event: event, Offset: -1}
+	if header, ok := events.GetEventHeader(event); ok {
+		record.ID = header.ID
+		record.Time = header.Time
+	}
+	return record
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
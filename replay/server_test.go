@@ -0,0 +1,217 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+	"github.com/tommyorndorff/operata-events/pattern"
+)
+
+// fixtureArchive builds an NDJSON archive from the events package fixtures in time order
+func fixtureArchive(t *testing.T) string {
+	t.Helper()
+	var archive bytes.Buffer
+	for _, name := range []string{"call_summary.json", "insights_summary.json", "headset_summary.json", "agent_reported_issue.json"} {
+		data, err := os.ReadFile(filepath.Join("..", "events", "testdata", name))
+		if err != nil {
+			t.Fatalf("Failed to read fixture: %v", err)
+		}
+		if err := json.Compact(&archive, data); err != nil {
+			t.Fatalf("Failed to compact fixture: %v", err)
+		}
+		archive.WriteString("\n\n")
+	}
+	return archive.String()
+}
+
+func newTestServer(t *testing.T, opts Options) (*Server, *[]time.Duration) {
+	t.Helper()
+	server := NewServer(opts)
+	var sleeps []time.Duration
+	server.sleep = func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	if err := server.Load(strings.NewReader(fixtureArchive(t))); err != nil {
+		t.Fatalf("Failed to load archive: %v", err)
+	}
+	return server, &sleeps
+}
+
+func TestRunDeliversMatchingEvents(t *testing.T) {
+	server, _ := newTestServer(t, Options{})
+
+	var delivered []string
+	server.AddRule(Rule{
+		Name:    "calls-and-issues",
+		Pattern: pattern.MustCompile(`{"detail-type": ["CallSummary", "AgentReportedIssue"]}`),
+		Targets: []Target{TargetFunc(func(_ context.Context, record Record) error {
+			delivered = append(delivered, record.ID)
+			if _, ok := record.Event.(*events.CallSummaryEvent); !ok && record.Offset == 0 {
+				t.Errorf("Expected typed CallSummaryEvent at offset 0, got %T", record.Event)
+			}
+			return nil
+		})},
+	})
+
+	stats, err := server.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if stats.Events != 4 || stats.Matched != 2 || stats.Delivered != 2 || stats.Failed != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if len(delivered) != 2 || delivered[0] != "530848f3-1111-2222-3333-b33ba70c19f0" {
+		t.Errorf("Unexpected deliveries: %v", delivered)
+	}
+}
+
+func TestRunTimeWarpSpacing(t *testing.T) {
+	server, sleeps := newTestServer(t, Options{TimeWarp: 10})
+
+	if _, err := server.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// Fixture times: 05:00:13, 05:00:13, 05:00:20, 05:02:41
+	expected := []time.Duration{700 * time.Millisecond, 14100 * time.Millisecond}
+	if len(*sleeps) != len(expected) {
+		t.Fatalf("Expected sleeps %v, got %v", expected, *sleeps)
+	}
+	for i, d := range expected {
+		if (*sleeps)[i] != d {
+			t.Errorf("Sleep %d = %v, expected %v", i, (*sleeps)[i], d)
+		}
+	}
+}
+
+func TestRunRateLimit(t *testing.T) {
+	server, sleeps := newTestServer(t, Options{Rate: 2})
+
+	if _, err := server.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(*sleeps) != 3 {
+		t.Fatalf("Expected 3 rate-limit pauses, got %v", *sleeps)
+	}
+	for _, d := range *sleeps {
+		if d <= 400*time.Millisecond || d > 500*time.Millisecond {
+			t.Errorf("Expected pause close to 500ms, got %v", d)
+		}
+	}
+}
+
+func TestRunHTTPTargetFailures(t *testing.T) {
+	var received int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected JSON content type, got %q", r.Header.Get("Content-Type"))
+		}
+		if strings.Contains(r.URL.Path, "fail") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	server, _ := newTestServer(t, Options{})
+	server.AddRule(Rule{
+		Name:    "all",
+		Targets: []Target{HTTPTarget{URL: ts.URL + "/ok"}, HTTPTarget{URL: ts.URL + "/fail"}},
+	})
+
+	stats, err := server.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if received != 8 || stats.Delivered != 4 || stats.Failed != 4 {
+		t.Errorf("Unexpected result: received=%d stats=%+v", received, stats)
+	}
+
+	var deliveryErr *DeliveryError
+	if !errors.As(stats.Errors[0], &deliveryErr) || deliveryErr.Rule != "all" {
+		t.Errorf("Expected DeliveryError for rule 'all', got %v", stats.Errors[0])
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	server := NewServer(Options{TimeWarp: 1})
+	if err := server.Load(strings.NewReader(fixtureArchive(t))); err != nil {
+		t.Fatalf("Failed to load archive: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := server.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestLoadMalformedRecord(t *testing.T) {
+	server := NewServer(Options{})
+	err := server.Load(strings.NewReader("{\"detail-type\": \"CallSummary\"}\n{\"detail-type\": \"CallSummary\", \"detail\": []}\n"))
+
+	var recordErr *events.RecordError
+	if !errors.As(err, &recordErr) || recordErr.Offset != 31 {
+		t.Errorf("Expected RecordError at offset 31, got %v", err)
+	}
+}
+
+func TestServeHTTPPutEvents(t *testing.T) {
+	server := NewServer(Options{Region: "ap-southeast-2"})
+
+	var got Record
+	server.AddRule(Rule{
+		Name:    "insights",
+		Pattern: pattern.MustCompile(`{"detail-type": ["InsightsSummary"]}`),
+		Targets: []Target{TargetFunc(func(_ context.Context, record Record) error {
+			got = record
+			return nil
+		})},
+	})
+
+	body := `{"Entries": [
+		{"Source": "aws.partner/operata.com/test/bus", "DetailType": "InsightsSummary", "Detail": "{\"insights\": {\"count\": 1}}", "Time": 1685595613},
+		{"Source": "test", "DetailType": "InsightsSummary", "Detail": "not json"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var resp putEventsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.FailedEntryCount != 1 || len(resp.Entries) != 2 || resp.Entries[0].EventID == "" {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+
+	event, ok := got.Event.(*events.InsightsSummaryEvent)
+	if !ok {
+		t.Fatalf("Expected InsightsSummaryEvent, got %T", got.Event)
+	}
+	if event.Region != "ap-southeast-2" || event.Detail.Insights.Count != 1 {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if !event.Time.Equal(time.Date(2023, 6, 1, 5, 0, 13, 0, time.UTC)) {
+		t.Errorf("Expected event time 2023-06-01T05:00:13Z, got %v", event.Time)
+	}
+}
@@ -0,0 +1,59 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Target receives events matched by a rule
+type Target interface {
+	Deliver(ctx context.Context, record Record) error
+}
+
+// TargetFunc adapts an in-process handler function to a Target
+type TargetFunc func(ctx context.Context, record Record) error
+
+// Deliver calls f
+func (f TargetFunc) Deliver(ctx context.Context, record Record) error {
+	return f(ctx, record)
+}
+
+// HTTPTarget POSTs each event's raw JSON to a URL
+type HTTPTarget struct {
+	URL     string
+	Headers map[string]string
+	// Client is used for requests; http.DefaultClient when nil
+	Client *http.Client
+}
+
+// Deliver POSTs the event and treats any non-2xx response as a failure
+func (t HTTPTarget) Deliver(ctx context.Context, record Record) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(record.Raw))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range t.Headers {
+		req.Header.Set(name, value)
+	}
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver to %s: %w", t.URL, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("delivery to %s failed with status %d", t.URL, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+// Package schema generates JSON Schema documents from the Go structs in the
+// events package and validates raw event payloads against them.
+//
+// The generated documents are committed under schema/json so services written
+// in other languages can validate Operata events with the same rules. Run
+// go generate in this package after changing the event structs to refresh them.
+//
+// Validation happens on raw bytes before typed decoding, and reports every
+// problem with a JSON Pointer to the offending member:
+//
+//	if err := schema.ValidateEvent(data); err != nil {
+//		var verrs schema.ValidationErrors
+//		if errors.As(err, &verrs) {
+//			for _, verr := range verrs {
+//				fmt.Printf("%s: %s\n", verr.Path, verr.Message)
+//			}
+//		}
+//	}
+package schema
+
+//go:generate go run ./internal/schemagen json
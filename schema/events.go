@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// BaseID is the URI prefix of the committed schema documents
+const BaseID = "https://github.com/tommyorndorff/operata-events/schema/json/"
+
+// envelopeRequired lists the header members every EventBridge event carries
+var envelopeRequired = []string{"id", "detail-type", "source", "time", "detail"}
+
+// Document is a generated schema together with the file it is committed as
+type Document struct {
+	Name       string
+	DetailType string
+	Schema     *Schema
+}
+
+// FileName returns the file name the document is committed under
+func (d Document) FileName() string {
+	return d.Name + ".schema.json"
+}
+
+// Documents returns a schema for every event type in the events package
+func Documents() []Document {
+	return []Document{
+		eventDocument("call_summary", events.EventTypeCallSummary, events.CallSummaryEvent{}),
+		eventDocument("insights_summary", events.EventTypeInsightsSummary, events.InsightsSummaryEvent{}),
+		eventDocument("agent_reported_issue", events.EventTypeAgentReportedIssue, events.AgentReportedIssueEvent{}),
+		eventDocument("headset_summary", events.EventTypeHeadsetSummary, events.HeadsetSummaryEvent{}),
+		{
+			Name:   "heartbeat_workflow",
+			Schema: document("heartbeat_workflow", "HeartbeatWorkflow", events.HeartbeatWorkflowEvents{}),
+		},
+	}
+}
+
+// ForDetailType returns the schema for an EventBridge detail-type
+func ForDetailType(detailType string) (*Schema, bool) {
+	for _, doc := range Documents() {
+		if doc.DetailType != "" && doc.DetailType == detailType {
+			return doc.Schema, true
+		}
+	}
+	return nil, false
+}
+
+// ValidateEvent checks a raw EventBridge event against the schema for its
+// detail-type. Events with a detail-type this package does not know are only
+// checked for the common header members.
+func ValidateEvent(data []byte) error {
+	var header struct {
+		DetailType string `json:"detail-type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return fmt.Errorf("failed to parse generic event: %w", err)
+	}
+
+	s, ok := ForDetailType(header.DetailType)
+	if !ok {
+		s = document("event", "EventBridgeEvent", events.EventBridgeEvent{})
+		s.Required = envelopeRequired
+	}
+
+	return Validate(s, data)
+}
+
+func eventDocument(name, detailType string, v interface{}) Document {
+	s := document(name, detailType, v)
+	s.Required = envelopeRequired
+	s.Properties["detail-type"].Const = detailType
+	return Document{Name: name, DetailType: detailType, Schema: s}
+}
+
+func document(name, title string, v interface{}) *Schema {
+	s := Generate(v)
+	s.Schema = Draft
+	s.ID = BaseID + name + ".schema.json"
+	s.Title = title
+	return s
+}
@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Generate builds a schema describing how encoding/json encodes values of
+// v's type. Struct fields tagged json:"-" are skipped and slices may be null.
+func Generate(v interface{}) *Schema {
+	return generateType(reflect.TypeOf(v))
+}
+
+func generateType(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: TypeList{TypeString}, Format: FormatDateTime}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := generateType(t.Elem())
+		if len(s.Type) > 0 {
+			s.Type = append(s.Type, TypeNull)
+		}
+		return s
+	case reflect.Struct:
+		s := &Schema{Type: TypeList{TypeObject}, Properties: make(map[string]*Schema)}
+		addFields(s.Properties, t)
+		return s
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: TypeList{TypeArray, TypeNull}, Items: generateType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: TypeList{TypeObject}}
+	case reflect.String:
+		return &Schema{Type: TypeList{TypeString}}
+	case reflect.Bool:
+		return &Schema{Type: TypeList{TypeBoolean}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: TypeList{TypeInteger}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: TypeList{TypeNumber}}
+	default:
+		return &Schema{}
+	}
+}
+
+// addFields adds the JSON members of struct type t to properties. Fields
+// already present win over promoted fields of embedded structs, matching how
+// encoding/json resolves shadowed names.
+func addFields(properties map[string]*Schema, t reflect.Type) {
+	var embedded []reflect.Type
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, field.Type)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = generateType(field.Type)
+	}
+
+	for _, et := range embedded {
+		promoted := make(map[string]*Schema)
+		addFields(promoted, et)
+		for name, s := range promoted {
+			if _, ok := properties[name]; !ok {
+				properties[name] = s
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Command schemagen writes the JSON Schema documents for every Operata event
+// type into the directory given as its only argument.
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/tommyorndorff/operata-events/schema"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatalf("usage: %s <output-dir>", filepath.Base(os.Args[0]))
+	}
+	dir := os.Args[1]
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Fatalf("Failed to create output directory: %v", err)
+	}
+
+	for _, doc := range schema.Documents() {
+		data, err := json.MarshalIndent(doc.Schema, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to encode %s schema: %v", doc.Name, err)
+		}
+
+		path := filepath.Join(dir, doc.FileName())
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			log.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package schema
+
+import (
+	"encoding/json"
+)
+
+// Draft is the JSON Schema dialect of the generated documents
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema used to describe Operata events
+type Schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	ID          string             `json:"$id,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        TypeList           `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Const       interface{}        `json:"const,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+}
+
+// Type names used in generated schemas
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeNull    = "null"
+)
+
+// FormatDateTime is the format of timestamp strings
+const FormatDateTime = "date-time"
+
+// TypeList holds the permitted JSON types of a value. It is encoded as a
+// single string when it has one entry, as JSON Schema allows.
+type TypeList []string
+
+// MarshalJSON encodes a single type as a string and several as an array
+func (t TypeList) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON accepts either a single type string or an array of types
+func (t *TypeList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = TypeList{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// Allows reports whether typ is one of the permitted types. An empty list allows anything.
+func (t TypeList) Allows(typ string) bool {
+	if len(t) == 0 {
+		return true
+	}
+	for _, allowed := range t {
+		if allowed == typ || (allowed == TypeNumber && typ == TypeInteger) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommittedSchemasUpToDate(t *testing.T) {
+	for _, doc := range Documents() {
+		committed, err := os.ReadFile(filepath.Join("json", doc.FileName()))
+		if err != nil {
+			t.Fatalf("Failed to read committed schema: %v", err)
+		}
+
+		generated, err := json.MarshalIndent(doc.Schema, "", "  ")
+		if err != nil {
+			t.Fatalf("Failed to encode %s schema: %v", doc.Name, err)
+		}
+
+		if string(committed) != string(generated)+"\n" {
+			t.Errorf("Committed %s is stale; run go generate ./schema", doc.FileName())
+		}
+	}
+}
+
+func TestValidateEventFixtures(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("..", "events", "testdata", "*.json"))
+	if err != nil {
+		t.Fatalf("Failed to list fixtures: %v", err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("Expected event fixtures to validate")
+	}
+
+	for _, fixture := range fixtures {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatalf("Failed to read fixture: %v", err)
+		}
+		if err := ValidateEvent(data); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", filepath.Base(fixture), err)
+		}
+	}
+}
+
+func TestValidateEventErrorPaths(t *testing.T) {
+	data := `{
+		"id": "test-id",
+		"detail-type": "CallSummary",
+		"source": "aws.partner/operata.com/test/eventBus",
+		"detail": {
+			"contact": {"id": {"current": 42}},
+			"serviceAgent": {"interaction": {"totalDurationSec": 14.5}},
+			"webRTCSession": {"usedDevices": [{"timestamp": "yesterday"}]}
+		}
+	}`
+
+	err := ValidateEvent([]byte(data))
+
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+
+	expected := []string{
+		"",
+		"/detail/contact/id/current",
+		"/detail/serviceAgent/interaction/totalDurationSec",
+		"/detail/webRTCSession/usedDevices/0/timestamp",
+	}
+	if len(verrs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expected), len(verrs), verrs)
+	}
+	for i, path := range expected {
+		if verrs[i].Path != path {
+			t.Errorf("Expected error %d at %q, got %q (%s)", i, path, verrs[i].Path, verrs[i].Message)
+		}
+	}
+}
+
+func TestValidateEventDetailTypeConst(t *testing.T) {
+	s, ok := ForDetailType("InsightsSummary")
+	if !ok {
+		t.Fatal("Expected schema for InsightsSummary")
+	}
+
+	data := `{"id": "x", "detail-type": "CallSummary", "source": "s", "time": "2023-06-01T05:00:13Z", "detail": {}}`
+	err := Validate(s, []byte(data))
+
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 1 || verrs[0].Path != "/detail-type" {
+		t.Errorf("Expected a single /detail-type error, got %v", err)
+	}
+}
+
+func TestValidateMalformedJSON(t *testing.T) {
+	err := Validate(&Schema{}, []byte(`{"id": `))
+	if err == nil {
+		t.Fatal("Expected error for malformed JSON")
+	}
+
+	var verrs ValidationErrors
+	if errors.As(err, &verrs) {
+		t.Errorf("Expected a decoding error, got ValidationErrors %v", verrs)
+	}
+}
@@ -0,0 +1,152 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidationError describes a single schema violation
+type ValidationError struct {
+	// Path is a JSON Pointer (RFC 6901) to the offending value; "" is the document root
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return "/: " + e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors is every violation found in a document, ordered by path
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "schema validation failed: " + strings.Join(messages, "; ")
+}
+
+// Validate checks raw JSON data against s. It returns ValidationErrors when
+// the data is well-formed JSON that does not match, or a decoding error when
+// the data is not JSON at all.
+func Validate(s *Schema, data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	var errs ValidationErrors
+	validateValue(s, value, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return errs
+}
+
+func validateValue(s *Schema, value interface{}, path string, errs *ValidationErrors) {
+	typ := jsonType(value)
+	if !s.Type.Allows(typ) {
+		*errs = append(*errs, &ValidationError{
+			Path:    path,
+			Message: fmt.Sprintf("expected %s, got %s", strings.Join(s.Type, " or "), typ),
+		})
+		return
+	}
+
+	if s.Const != nil && !reflect.DeepEqual(normalise(s.Const), value) {
+		*errs = append(*errs, &ValidationError{
+			Path:    path,
+			Message: fmt.Sprintf("expected constant %v, got %v", s.Const, value),
+		})
+	}
+
+	switch v := value.(type) {
+	case string:
+		if s.Format == FormatDateTime {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				*errs = append(*errs, &ValidationError{
+					Path:    path,
+					Message: fmt.Sprintf("expected RFC 3339 date-time, got %q", v),
+				})
+			}
+		}
+
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, &ValidationError{
+					Path:    path,
+					Message: fmt.Sprintf("missing required member %q", name),
+				})
+			}
+		}
+		for name, member := range v {
+			if ps, ok := s.Properties[name]; ok {
+				validateValue(ps, member, path+"/"+escapePointer(name), errs)
+			}
+		}
+
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				validateValue(s.Items, item, fmt.Sprintf("%s/%d", path, i), errs)
+			}
+		}
+	}
+}
+
+// jsonType returns the JSON Schema type of a value decoded with UseNumber.
+// Numbers count as integers only when encoding/json could decode them into a
+// Go integer, so 3.0 and 1e3 are numbers.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBoolean
+	case string:
+		return TypeString
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return TypeNumber
+		}
+		return TypeInteger
+	case []interface{}:
+		return TypeArray
+	default:
+		return TypeObject
+	}
+}
+
+// normalise converts a Go constant to the form produced by a UseNumber decode
+func normalise(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var out interface{}
+	if err := decoder.Decode(&out); err != nil {
+		return value
+	}
+	return out
+}
+
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
@@ -0,0 +1,116 @@
+package tenant
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// Builder accumulates events, like the analytics report builders and billing.Aggregator
+type Builder interface {
+	Add(event interface{})
+}
+
+// Aggregator keeps a separate builder per Operata group. It is safe for
+// concurrent use: groups are built in parallel, but calls to one group's
+// builder are serialised.
+type Aggregator[B Builder] struct {
+	mu         sync.Mutex
+	create     func(groupID string) B
+	groups     map[string]*group[B]
+	unassigned int
+}
+
+// group is one tenant's builder, keyed case-insensitively but reported with
+// the group ID as first seen
+type group[B Builder] struct {
+	mu      sync.Mutex
+	id      string
+	builder B
+}
+
+// NewAggregator returns an aggregator that calls create for each new group
+func NewAggregator[B Builder](create func(groupID string) B) *Aggregator[B] {
+	return &Aggregator[B]{create: create, groups: make(map[string]*group[B])}
+}
+
+// Add passes the event to its group's builder. Events without a group, or
+// whose source and payload disagree, are counted in Unassigned.
+func (a *Aggregator[B]) Add(event interface{}) {
+	tenant, err := events.GetTenant(event)
+	if err != nil {
+		a.mu.Lock()
+		a.unassigned++
+		a.mu.Unlock()
+		return
+	}
+	a.group(tenant.GroupID).add(event)
+}
+
+// Process adds the event, using the tenant a Router put in the context when
+// there is one. It has the events.Handler signature and always returns nil.
+func (a *Aggregator[B]) Process(ctx context.Context, event interface{}) error {
+	tenant, ok := FromContext(ctx)
+	if !ok {
+		a.Add(event)
+		return nil
+	}
+	a.group(tenant.GroupID).add(event)
+	return nil
+}
+
+// group returns a group, creating it and its builder on first use
+func (a *Aggregator[B]) group(groupID string) *group[B] {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := strings.ToLower(groupID)
+	g, ok := a.groups[key]
+	if !ok {
+		g = &group[B]{id: groupID, builder: a.create(groupID)}
+		a.groups[key] = g
+	}
+	return g
+}
+
+func (g *group[B]) add(event interface{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.builder.Add(event)
+}
+
+// For returns a group's builder, if it has seen any events. Do not use the
+// builder while events are still being added.
+func (a *Aggregator[B]) For(groupID string) (B, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	g, ok := a.groups[strings.ToLower(groupID)]
+	if !ok {
+		var zero B
+		return zero, false
+	}
+	return g.builder, true
+}
+
+// Unassigned returns the number of events dropped because their tenant could
+// not be identified
+func (a *Aggregator[B]) Unassigned() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.unassigned
+}
+
+// Tenants returns the group IDs seen, sorted, with the casing each group ID
+// was first seen with
+func (a *Aggregator[B]) Tenants() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	groups := make([]string, 0, len(a.groups))
+	for _, g := range a.groups {
+		groups = append(groups, g.id)
+	}
+	sort.Strings(groups)
+	return groups
+}
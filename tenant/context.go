@@ -0,0 +1,20 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+type contextKey struct{}
+
+// WithTenant returns a context carrying the tenant
+func WithTenant(ctx context.Context, tenant events.Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenant)
+}
+
+// FromContext returns the tenant set by WithTenant or a Router
+func FromContext(ctx context.Context) (events.Tenant, bool) {
+	tenant, ok := ctx.Value(contextKey{}).(events.Tenant)
+	return tenant, ok
+}
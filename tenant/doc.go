@@ -0,0 +1,35 @@
+// Package tenant lets one deployment serve many Operata groups.
+//
+// Each Operata group publishes to its own partner event bus, and names
+// itself both in the event source and in the payload; events.GetTenant
+// reads and cross-checks the two. This package builds on it:
+//
+//   - Router dispatches events to a handler per group, rejecting events
+//     whose source and payload disagree, and passes the tenant on in the
+//     context (see FromContext)
+//   - Settings holds a value, such as analytics thresholds or triage
+//     priority rules, with per-group overrides of a default
+//   - Aggregator keeps a separate report builder per group, so reports
+//     never mix clients
+//
+// Example usage:
+//
+//	thresholds := tenant.NewSettings(analytics.DefaultNetworkThresholds)
+//	thresholds.Set(acmeGroupID, analytics.NetworkThresholds{MinCalls: 10, ZScore: 1.645, WiFiLossPercent: 0.5, MinWiFiCalls: 3})
+//
+//	networks := tenant.NewAggregator(func(groupID string) *analytics.NetworkBuilder {
+//		b := analytics.NewNetworkBuilder()
+//		b.Thresholds = thresholds.For(groupID)
+//		return b
+//	})
+//
+//	router := tenant.NewRouter()
+//	router.Fallback = networks.Process
+//	router.Handle(acmeGroupID, acmeHandler)
+//
+//	// Later, per client
+//	for _, groupID := range networks.Tenants() {
+//		b, _ := networks.For(groupID)
+//		b.Report().WriteJSON(os.Stdout)
+//	}
+package tenant
@@ -0,0 +1,57 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// ErrUnknownTenant is returned for events from groups with no handler and no fallback
+var ErrUnknownTenant = errors.New("no handler for tenant")
+
+// Router dispatches events to a handler per Operata group
+type Router struct {
+	mu       sync.RWMutex
+	handlers map[string]events.Handler
+
+	// Fallback handles events from groups without a handler; when nil they
+	// are rejected with ErrUnknownTenant
+	Fallback events.Handler
+}
+
+// NewRouter returns an empty router
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]events.Handler)}
+}
+
+// Handle registers the handler for a group, replacing any existing one
+func (r *Router) Handle(groupID string, handler events.Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[strings.ToLower(groupID)] = handler
+}
+
+// Process passes the event to its group's handler, with the tenant in the
+// context. Events without a group, or whose source and payload name
+// different groups, are rejected. It has the events.Handler signature.
+func (r *Router) Process(ctx context.Context, event interface{}) error {
+	tenant, err := events.GetTenant(event)
+	if err != nil {
+		return fmt.Errorf("failed to identify tenant: %w", err)
+	}
+
+	r.mu.RLock()
+	handler, ok := r.handlers[strings.ToLower(tenant.GroupID)]
+	r.mu.RUnlock()
+	if !ok {
+		handler = r.Fallback
+	}
+	if handler == nil {
+		return fmt.Errorf("%w %s", ErrUnknownTenant, tenant.GroupID)
+	}
+	return handler(WithTenant(ctx, tenant), event)
+}
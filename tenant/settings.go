@@ -0,0 +1,50 @@
+package tenant
+
+import (
+	"strings"
+	"sync"
+)
+
+// Settings holds a value with per-group overrides, such as the thresholds
+// a report is built with. It is safe for concurrent use.
+type Settings[T any] struct {
+	mu        sync.RWMutex
+	def       T
+	overrides map[string]T
+}
+
+// NewSettings returns settings that give def to every group until overridden
+func NewSettings[T any](def T) *Settings[T] {
+	return &Settings[T]{def: def, overrides: make(map[string]T)}
+}
+
+// Set overrides the value for a group
+func (s *Settings[T]) Set(groupID string, value T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[strings.ToLower(groupID)] = value
+}
+
+// Reset removes a group's override
+func (s *Settings[T]) Reset(groupID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.overrides, strings.ToLower(groupID))
+}
+
+// For returns the value for a group: its override, or the default
+func (s *Settings[T]) For(groupID string) T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if value, ok := s.overrides[strings.ToLower(groupID)]; ok {
+		return value
+	}
+	return s.def
+}
+
+// Default returns the value for groups without an override
+func (s *Settings[T]) Default() T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.def
+}
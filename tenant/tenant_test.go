@@ -0,0 +1,203 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+const (
+	groupA = "a28453f9-1111-2222-3333-84d9e67ac297"
+	groupB = "a28453f9-c9d3-4c48-a7cd-84d9e67ac297"
+)
+
+func loadFixture(t *testing.T, name string) interface{} {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "events", "testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	event, err := events.ParseEventBridgeEvent(data)
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	return event
+}
+
+// misrouted returns a call summary whose payload names groupA but whose source names groupB
+func misrouted() *events.CallSummaryEvent {
+	event := &events.CallSummaryEvent{}
+	event.Source = "aws.partner/operata.com/" + groupB + "/otherBus"
+	event.Detail.AccountProperties.OperataGroupID = groupA
+	return event
+}
+
+func TestRouter(t *testing.T) {
+	var routed []string
+	record := func(name string) events.Handler {
+		return func(ctx context.Context, event interface{}) error {
+			tenant, ok := FromContext(ctx)
+			if !ok {
+				t.Errorf("%s: expected tenant in context", name)
+			}
+			routed = append(routed, name+":"+tenant.EventBus)
+			return nil
+		}
+	}
+
+	router := NewRouter()
+	router.Handle("A28453F9-1111-2222-3333-84D9E67AC297", record("a"))
+
+	tests := []struct {
+		name     string
+		event    interface{}
+		fallback events.Handler
+		err      error
+		routed   []string
+	}{
+		{"registered", loadFixture(t, "call_summary.json"), nil, nil, []string{"a:andyEventBus"}},
+		{"registered issue", loadFixture(t, "agent_reported_issue.json"), nil, nil, []string{"a:andyEventBus"}},
+		{"unknown", loadFixture(t, "insights_summary.json"), nil, ErrUnknownTenant, nil},
+		{"fallback", loadFixture(t, "insights_summary.json"), record("fallback"), nil, []string{"fallback:andyEventBus"}},
+		{"mismatch", misrouted(), record("fallback"), events.ErrTenantMismatch, nil},
+		{"no tenant", &events.CallSummaryEvent{}, record("fallback"), events.ErrNoTenant, nil},
+	}
+
+	for _, test := range tests {
+		routed = nil
+		router.Fallback = test.fallback
+		err := router.Process(context.Background(), test.event)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: expected error %v, got %v", test.name, test.err, err)
+		}
+		if !reflect.DeepEqual(routed, test.routed) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.routed, routed)
+		}
+	}
+}
+
+func TestSettings(t *testing.T) {
+	settings := NewSettings(4.0)
+	settings.Set(groupA, 3.5)
+
+	if value := settings.For("A28453F9-1111-2222-3333-84D9E67AC297"); value != 3.5 {
+		t.Errorf("Expected override 3.5, got %v", value)
+	}
+	if value := settings.For(groupB); value != 4.0 {
+		t.Errorf("Expected default 4.0, got %v", value)
+	}
+
+	settings.Reset(groupA)
+	if value := settings.For(groupA); value != settings.Default() {
+		t.Errorf("Expected default after reset, got %v", value)
+	}
+}
+
+type countBuilder struct {
+	groupID string
+	events  int
+}
+
+func (b *countBuilder) Add(event interface{}) {
+	b.events++
+}
+
+func TestAggregator(t *testing.T) {
+	aggregator := NewAggregator(func(groupID string) *countBuilder {
+		return &countBuilder{groupID: groupID}
+	})
+
+	aggregator.Add(loadFixture(t, "call_summary.json"))
+	aggregator.Add(loadFixture(t, "headset_summary.json"))
+	aggregator.Add(loadFixture(t, "insights_summary.json"))
+	aggregator.Add(misrouted())
+	aggregator.Add(&events.CallSummaryEvent{})
+
+	// A router's tenant takes precedence over the event's own
+	ctx := WithTenant(context.Background(), events.Tenant{GroupID: groupB})
+	if err := aggregator.Process(ctx, &events.CallSummaryEvent{}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if tenants := aggregator.Tenants(); !reflect.DeepEqual(tenants, []string{groupA, groupB}) {
+		t.Errorf("Expected tenants %v, got %v", []string{groupA, groupB}, tenants)
+	}
+	if aggregator.Unassigned() != 2 {
+		t.Errorf("Expected 2 unassigned events, got %d", aggregator.Unassigned())
+	}
+
+	tests := []struct {
+		groupID string
+		events  int
+	}{
+		{groupA, 2},
+		{groupB, 2},
+	}
+	for _, test := range tests {
+		b, ok := aggregator.For(test.groupID)
+		if !ok || b.groupID != test.groupID || b.events != test.events {
+			t.Errorf("Expected %d events for %s, got %+v", test.events, test.groupID, b)
+		}
+	}
+	if _, ok := aggregator.For("other"); ok {
+		t.Error("Expected no builder for an unseen group")
+	}
+}
+
+// blockingBuilder waits for release before counting each event
+type blockingBuilder struct {
+	release chan struct{}
+	events  int
+}
+
+func (b *blockingBuilder) Add(event interface{}) {
+	if b.release != nil {
+		<-b.release
+	}
+	b.events++
+}
+
+func TestAggregatorGroups(t *testing.T) {
+	upper := strings.ToUpper(groupA)
+	release := make(chan struct{})
+	aggregator := NewAggregator(func(groupID string) *blockingBuilder {
+		if groupID == upper {
+			return &blockingBuilder{release: release}
+		}
+		return &blockingBuilder{}
+	})
+
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		aggregator.Process(WithTenant(context.Background(), events.Tenant{GroupID: upper}), &events.CallSummaryEvent{})
+	}()
+
+	// A busy builder does not hold up other groups
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		aggregator.Process(WithTenant(context.Background(), events.Tenant{GroupID: groupB}), &events.CallSummaryEvent{})
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected groupB to be built while groupA's builder was busy")
+	}
+	close(release)
+	<-blocked
+
+	if tenants := aggregator.Tenants(); !reflect.DeepEqual(tenants, []string{upper, groupB}) {
+		t.Errorf("Expected tenants as first seen %v, got %v", []string{upper, groupB}, tenants)
+	}
+	if b, ok := aggregator.For(groupA); !ok || b.events != 1 {
+		t.Errorf("Expected 1 event for %s, got %+v", groupA, b)
+	}
+}
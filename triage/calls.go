@@ -0,0 +1,64 @@
+package triage
+
+import (
+	"sync"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// CallLookup finds the CallSummary event for a contact
+type CallLookup interface {
+	FindCall(contactID string) (*events.CallSummaryEvent, bool)
+}
+
+// CallIndex is an in-memory CallLookup holding the most recent calls.
+// It is safe for concurrent use.
+type CallIndex struct {
+	mu       sync.Mutex
+	capacity int
+	calls    map[string]*events.CallSummaryEvent
+	order    []string
+}
+
+// NewCallIndex creates an index that keeps up to capacity calls, forgetting
+// the oldest first. A capacity of 0 or less keeps every call.
+func NewCallIndex(capacity int) *CallIndex {
+	return &CallIndex{capacity: capacity, calls: make(map[string]*events.CallSummaryEvent)}
+}
+
+// Add indexes a CallSummary event by its current contact ID; other events are ignored
+func (i *CallIndex) Add(event interface{}) {
+	call, ok := event.(*events.CallSummaryEvent)
+	if !ok || call.Detail.Contact.ID.Current == "" {
+		return
+	}
+	contactID := call.Detail.Contact.ID.Current
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if _, exists := i.calls[contactID]; !exists {
+		i.order = append(i.order, contactID)
+	}
+	i.calls[contactID] = call
+
+	if i.capacity > 0 && len(i.order) > i.capacity {
+		delete(i.calls, i.order[0])
+		i.order = i.order[1:]
+	}
+}
+
+// FindCall returns the indexed call for contactID
+func (i *CallIndex) FindCall(contactID string) (*events.CallSummaryEvent, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	call, ok := i.calls[contactID]
+	return call, ok
+}
+
+// Len returns the number of indexed calls
+func (i *CallIndex) Len() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return len(i.calls)
+}
@@ -0,0 +1,19 @@
+// Package triage turns AgentReportedIssue events into tickets.
+//
+// A Triager looks up the call an issue was raised on in a CallLookup (such
+// as a CallIndex fed with CallSummary events), assigns a priority from the
+// issue's severity and category, the softphone error and the call's quality,
+// and builds a Ticket with a summary, description and labels. Templates then
+// render the ticket as the request body for a ticketing API; templates for
+// Jira, ServiceNow and GitHub issues are included and custom ones can be
+// parsed with ParseTemplate.
+//
+// Example usage:
+//
+//	calls := triage.NewCallIndex(10000)
+//	triager := triage.New(calls)
+//
+//	// for each event: calls.Add(event)
+//	ticket := triager.Triage(issueEvent)
+//	body, err := triage.Jira.Render(ticket, map[string]string{"project": "OPS"})
+package triage
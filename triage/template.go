@@ -0,0 +1,110 @@
+package triage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// Template renders a Ticket as a JSON request body for a ticketing API.
+//
+// Templates are text/template documents executed with a TemplateData. Use
+// the json function to encode values, so quoting and escaping are always
+// correct, pick to choose a value by priority, and append to extend a list:
+//
+//	{"title": {{json .Summary}}, "priority": {{json (pick .Priority "Highest" "High" "Medium" "Low")}}}
+type Template struct {
+	tmpl *template.Template
+}
+
+// TemplateData is the value a Template is executed with
+type TemplateData struct {
+	*Ticket
+	// Vars holds deployment settings such as a project key or assignment group
+	Vars map[string]string
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"append": func(list []string, values ...string) []string {
+		return append(append([]string(nil), list...), values...)
+	},
+	"pick": func(p Priority, values ...string) string {
+		if len(values) == 0 {
+			return ""
+		}
+		level := p.Level()
+		if level > len(values) {
+			level = len(values)
+		}
+		return values[level-1]
+	},
+}
+
+// ParseTemplate parses a ticket template
+func ParseTemplate(name, text string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// MustParseTemplate is like ParseTemplate but panics on error
+func MustParseTemplate(name, text string) *Template {
+	t, err := ParseTemplate(name, text)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render executes the template for ticket and returns the compacted JSON body.
+// It fails if the output is not valid JSON.
+func (t *Template) Render(ticket *Ticket, vars map[string]string) (json.RawMessage, error) {
+	var out bytes.Buffer
+	if err := t.tmpl.Execute(&out, TemplateData{Ticket: ticket, Vars: vars}); err != nil {
+		return nil, fmt.Errorf("failed to render %s template: %w", t.tmpl.Name(), err)
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, out.Bytes()); err != nil {
+		return nil, fmt.Errorf("%s template did not produce valid JSON: %w", t.tmpl.Name(), err)
+	}
+	return compact.Bytes(), nil
+}
+
+// Jira renders a Jira REST API v2 create-issue body. Vars: project, and optionally issueType (default "Bug").
+var Jira = MustParseTemplate("jira", `{
+	"fields": {
+		"project": {"key": {{json .Vars.project}}},
+		"issuetype": {"name": {{if .Vars.issueType}}{{json .Vars.issueType}}{{else}}"Bug"{{end}}},
+		"summary": {{json .Summary}},
+		"description": {{json .Description}},
+		"priority": {"name": {{json (pick .Priority "Highest" "High" "Medium" "Low")}}},
+		"labels": {{json .Labels}}
+	}
+}`)
+
+// ServiceNow renders a ServiceNow Table API incident body. Vars: optionally assignmentGroup.
+var ServiceNow = MustParseTemplate("servicenow", `{
+	"short_description": {{json .Summary}},
+	"description": {{json .Description}},
+	"urgency": {{json (pick .Priority "1" "2" "2" "3")}},
+	"impact": {{json (pick .Priority "1" "1" "2" "3")}},
+	"category": {{json .Issue.Detail.Context.Category}},
+	"caller_id": {{json .Issue.Detail.Agent}},
+	"correlation_id": {{json .Issue.Detail.ID}}{{if .Vars.assignmentGroup}},
+	"assignment_group": {{json .Vars.assignmentGroup}}{{end}}
+}`)
+
+// GitHub renders a GitHub create-issue body, with the priority as a label
+var GitHub = MustParseTemplate("github", `{
+	"title": {{json .Summary}},
+	"body": {{json .Description}},
+	"labels": {{json (append .Labels (printf "priority:%s" .Priority))}}
+}`)
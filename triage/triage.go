@@ -0,0 +1,238 @@
+package triage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// Priority is a ticket priority, P1 being the most urgent
+type Priority string
+
+const (
+	P1 Priority = "P1"
+	P2 Priority = "P2"
+	P3 Priority = "P3"
+	P4 Priority = "P4"
+)
+
+// Level returns 1 for P1 through 4 for P4
+func (p Priority) Level() int {
+	switch p {
+	case P1:
+		return 1
+	case P2:
+		return 2
+	case P3:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// PriorityRules score an issue; each point above zero raises the priority
+// one step from P4, up to P1 at three points or more. Map keys are matched
+// case-insensitively.
+type PriorityRules struct {
+	// Severity gives the base points for IssueContext.Severity; unlisted severities score DefaultSeverity
+	Severity        map[string]int
+	DefaultSeverity int
+	// Category adds points for IssueContext.Category
+	Category map[string]int
+	// SoftphoneError adds points for SoftphoneError.Type; any other non-empty type adds OtherSoftphoneError
+	SoftphoneError      map[string]int
+	OtherSoftphoneError int
+	// PoorCall adds points when the matching call's MOS was Poor or Bad
+	PoorCall int
+}
+
+// DefaultPriorityRules raise softphone failures that stop calls connecting
+// and issues on calls with poor measured quality
+var DefaultPriorityRules = PriorityRules{
+	Severity:        map[string]int{"critical": 3, "high": 2, "medium": 1, "low": 0},
+	DefaultSeverity: 1,
+	Category:        map[string]int{"connectivity": 1, "call dropped": 1},
+	SoftphoneError: map[string]int{
+		"media_error":                   1,
+		"signalling_connection_failure": 1,
+		"signalling_handshake_failure":  1,
+		"ice_collection_timeout":        1,
+		"microphone_not_shared":         1,
+		"webrtc_error":                  1,
+		"realtime_communication_error":  1,
+	},
+	OtherSoftphoneError: 0,
+	PoorCall:            1,
+}
+
+// Priority assigns a priority to an issue; call may be nil
+func (r PriorityRules) Priority(issue *events.AgentReportedIssueEvent, call *events.CallSummaryEvent) Priority {
+	detail := issue.Detail
+
+	points, ok := lookup(r.Severity, detail.Context.Severity)
+	if !ok {
+		points = r.DefaultSeverity
+	}
+	points += lookupOrZero(r.Category, detail.Context.Category)
+
+	if errorType := detail.SoftphoneError.Type; errorType != "" {
+		if extra, ok := lookup(r.SoftphoneError, errorType); ok {
+			points += extra
+		} else {
+			points += r.OtherSoftphoneError
+		}
+	}
+
+	if call != nil && callWasPoor(call) {
+		points += r.PoorCall
+	}
+
+	switch {
+	case points >= 3:
+		return P1
+	case points == 2:
+		return P2
+	case points == 1:
+		return P3
+	default:
+		return P4
+	}
+}
+
+// Ticket is a triaged issue ready to render
+type Ticket struct {
+	Issue *events.AgentReportedIssueEvent
+	// Call is the CallSummary for the issue's contact, or nil if it was not found
+	Call        *events.CallSummaryEvent
+	Priority    Priority
+	Summary     string
+	Description string
+	Labels      []string
+}
+
+// Triager builds tickets from AgentReportedIssue events
+type Triager struct {
+	// Calls finds the call an issue was raised on; nil disables enrichment
+	Calls CallLookup
+	Rules PriorityRules
+}
+
+// New creates a Triager using DefaultPriorityRules
+func New(calls CallLookup) *Triager {
+	return &Triager{Calls: calls, Rules: DefaultPriorityRules}
+}
+
+// Triage builds a ticket for an issue. The call is enriched from whatever
+// the CallLookup holds at the time, so CallSummary events that arrive after
+// the issue are not included.
+func (t *Triager) Triage(issue *events.AgentReportedIssueEvent) *Ticket {
+	var call *events.CallSummaryEvent
+	if t.Calls != nil && issue.Detail.Context.CallContactID != "" {
+		call, _ = t.Calls.FindCall(issue.Detail.Context.CallContactID)
+	}
+
+	ticket := &Ticket{
+		Issue:    issue,
+		Call:     call,
+		Priority: t.Rules.Priority(issue, call),
+		Labels:   labels(issue),
+	}
+	ticket.Summary = summary(issue)
+	ticket.Description = description(ticket)
+	return ticket
+}
+
+func summary(issue *events.AgentReportedIssueEvent) string {
+	context := issue.Detail.Context
+	text := context.Cause
+	if text == "" {
+		text = context.Message
+	}
+	if text == "" {
+		text = "Agent reported issue"
+	}
+	if context.Category != "" {
+		text = context.Category + ": " + text
+	}
+	if issue.Detail.Agent != "" {
+		text += " (" + issue.Detail.Agent + ")"
+	}
+	return text
+}
+
+func labels(issue *events.AgentReportedIssueEvent) []string {
+	labels := []string{"operata"}
+	if category := issue.Detail.Context.Category; category != "" {
+		labels = append(labels, "category:"+labelValue(category))
+	}
+	if errorType := issue.Detail.SoftphoneError.Type; errorType != "" {
+		labels = append(labels, "softphone:"+labelValue(errorType))
+	}
+	return labels
+}
+
+func description(ticket *Ticket) string {
+	detail := ticket.Issue.Detail
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Agent: %s\n", detail.Agent)
+	fmt.Fprintf(&b, "Reported: %s\n", detail.Timestamp.Format("2006-01-02 15:04:05 MST"))
+	fmt.Fprintf(&b, "Severity: %s\n", detail.Context.Severity)
+	fmt.Fprintf(&b, "Scenario: %s\n", detail.Context.Scenario)
+	fmt.Fprintf(&b, "Message: %s\n", detail.Context.Message)
+	if detail.SoftphoneError.Type != "" {
+		fmt.Fprintf(&b, "Softphone error: %s: %s\n", detail.SoftphoneError.Type, detail.SoftphoneError.Message)
+	}
+	fmt.Fprintf(&b, "Browser: %s %s\n", detail.Browser.Name, detail.Browser.Version)
+	fmt.Fprintf(&b, "CPU: %s, %.1f%% used\n", detail.System.CPU.ModelName, detail.System.CPU.UsedPercentage)
+	fmt.Fprintf(&b, "Memory: %.1f GB available of %.1f GB\n", detail.System.Memory.Available, detail.System.Memory.Total)
+	fmt.Fprintf(&b, "Contact ID: %s\n", detail.Context.CallContactID)
+
+	if ticket.Call == nil {
+		b.WriteString("\nNo call summary was found for this contact.\n")
+		return b.String()
+	}
+
+	call := ticket.Call.Detail
+	metrics := call.WebRTCSession.Metrics
+	network := call.ServiceAgent.Network
+	b.WriteString("\nCall\n")
+	fmt.Fprintf(&b, "Queue: %s\n", call.Contact.QueueName)
+	fmt.Fprintf(&b, "Direction: %s, ended by %s\n", call.Contact.Direction, call.Contact.EndedBy)
+	fmt.Fprintf(&b, "Duration: %ds\n", call.ServiceAgent.Interaction.TotalDurationSec)
+	fmt.Fprintf(&b, "MOS: %.2f (%s)\n", metrics.MOS.Avg, events.GetCallQualityLevel(metrics.MOS.Avg))
+	fmt.Fprintf(&b, "Packet loss: %.2f%% in, %.2f%% out\n", metrics.Inbound.PacketsLostPercentage, metrics.Outbound.PacketsLostPercentage)
+	fmt.Fprintf(&b, "RTT: %dms, jitter: %dms\n", metrics.RTT.Avg, metrics.Jitter.Avg)
+	fmt.Fprintf(&b, "Network: %s via %s (%s)\n", network.Type, network.ISP, network.Geolocation.City)
+	return b.String()
+}
+
+// callWasPoor reports whether a call's MOS was Poor or Bad
+func callWasPoor(call *events.CallSummaryEvent) bool {
+	mos := call.Detail.WebRTCSession.Metrics.MOS.Avg
+	if mos <= 0 {
+		return false
+	}
+	switch events.GetCallQualityLevel(mos) {
+	case events.QualityPoor, events.QualityBad:
+		return true
+	default:
+		return false
+	}
+}
+
+func lookup(m map[string]int, key string) (int, bool) {
+	value, ok := m[strings.ToLower(strings.TrimSpace(key))]
+	return value, ok
+}
+
+func lookupOrZero(m map[string]int, key string) int {
+	value, _ := lookup(m, key)
+	return value
+}
+
+// labelValue lower-cases a label value and replaces spaces with hyphens
+func labelValue(value string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(value)), " ", "-")
+}
@@ -0,0 +1,179 @@
+package triage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+func loadFixture(t *testing.T, name string) interface{} {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "events", "testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	event, err := events.ParseEventBridgeEvent(data)
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	return event
+}
+
+func issue(severity, category, errorType string) *events.AgentReportedIssueEvent {
+	event := &events.AgentReportedIssueEvent{}
+	event.Detail.Context = events.IssueContext{Severity: severity, Category: category, CallContactID: "contact-1"}
+	event.Detail.SoftphoneError.Type = errorType
+	return event
+}
+
+func call(mos float64) *events.CallSummaryEvent {
+	event := &events.CallSummaryEvent{}
+	event.Detail.Contact.ID.Current = "contact-1"
+	event.Detail.WebRTCSession.Metrics.MOS.Avg = mos
+	return event
+}
+
+func TestPriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		issue    *events.AgentReportedIssueEvent
+		call     *events.CallSummaryEvent
+		expected Priority
+	}{
+		{"low", issue("Low", "Audio", ""), nil, P4},
+		{"unknown severity", issue("", "Audio", ""), nil, P3},
+		{"high", issue("High", "Audio", ""), nil, P2},
+		{"high with media error", issue("HIGH", "Audio", "media_error"), nil, P1},
+		{"medium on poor call", issue("Medium", "Audio", ""), call(3.0), P2},
+		{"medium on good call", issue("Medium", "Audio", ""), call(4.4), P3},
+		{"low connectivity with unknown error", issue("Low", "Connectivity", "other"), nil, P3},
+		{"critical", issue("Critical", "", ""), nil, P1},
+	}
+
+	for _, test := range tests {
+		if priority := DefaultPriorityRules.Priority(test.issue, test.call); priority != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, priority)
+		}
+	}
+}
+
+func TestTriageEnrichesWithCall(t *testing.T) {
+	calls := NewCallIndex(10)
+	calls.Add(loadFixture(t, "call_summary.json"))
+	calls.Add(loadFixture(t, "headset_summary.json"))
+
+	ticket := New(calls).Triage(loadFixture(t, "agent_reported_issue.json").(*events.AgentReportedIssueEvent))
+
+	if ticket.Call == nil || ticket.Call.ID != "530848f3-1111-2222-3333-b33ba70c19f0" {
+		t.Fatalf("Expected ticket to be enriched with the fixture call, got %+v", ticket.Call)
+	}
+	if ticket.Priority != P1 {
+		t.Errorf("Expected P1 for a high severity media error, got %s", ticket.Priority)
+	}
+	if ticket.Summary != "Audio: Customer could not hear agent (andy)" {
+		t.Errorf("Unexpected summary: %q", ticket.Summary)
+	}
+	if !reflect.DeepEqual(ticket.Labels, []string{"operata", "category:audio", "softphone:media_error"}) {
+		t.Errorf("Unexpected labels: %v", ticket.Labels)
+	}
+	for _, expected := range []string{"Softphone error: media_error: Media stream interrupted", "Queue: Operata Prod Default Queue", "MOS: 4.25 (Good)"} {
+		if !strings.Contains(ticket.Description, expected) {
+			t.Errorf("Expected description to contain %q:\n%s", expected, ticket.Description)
+		}
+	}
+
+	missing := issue("Low", "Audio", "")
+	missing.Detail.Context.CallContactID = "unknown"
+	if ticket := New(calls).Triage(missing); ticket.Call != nil || !strings.Contains(ticket.Description, "No call summary") {
+		t.Errorf("Expected ticket without call, got %+v", ticket)
+	}
+}
+
+func TestCallIndexCapacity(t *testing.T) {
+	calls := NewCallIndex(2)
+	for _, id := range []string{"a", "b", "a", "c"} {
+		event := call(4)
+		event.Detail.Contact.ID.Current = id
+		calls.Add(event)
+	}
+
+	if calls.Len() != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls.Len())
+	}
+	if _, ok := calls.FindCall("a"); ok {
+		t.Error("Expected oldest call 'a' to be evicted")
+	}
+	if _, ok := calls.FindCall("c"); !ok {
+		t.Error("Expected newest call 'c' to be indexed")
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	calls := NewCallIndex(0)
+	calls.Add(loadFixture(t, "call_summary.json"))
+	ticket := New(calls).Triage(loadFixture(t, "agent_reported_issue.json").(*events.AgentReportedIssueEvent))
+	ticket.Summary = `Audio: "quoted" <summary>`
+
+	tests := []struct {
+		name     string
+		template *Template
+		vars     map[string]string
+		path     []string
+		expected interface{}
+	}{
+		{"jira project", Jira, map[string]string{"project": "OPS"}, []string{"fields", "project", "key"}, "OPS"},
+		{"jira priority", Jira, nil, []string{"fields", "priority", "name"}, "Highest"},
+		{"jira issue type", Jira, map[string]string{"issueType": "Incident"}, []string{"fields", "issuetype", "name"}, "Incident"},
+		{"jira summary", Jira, nil, []string{"fields", "summary"}, `Audio: "quoted" <summary>`},
+		{"servicenow urgency", ServiceNow, nil, []string{"urgency"}, "1"},
+		{"servicenow correlation", ServiceNow, nil, []string{"correlation_id"}, "issue-5f1e2d3c"},
+		{"servicenow group", ServiceNow, map[string]string{"assignmentGroup": "Voice"}, []string{"assignment_group"}, "Voice"},
+		{"github labels", GitHub, nil, []string{"labels"}, []interface{}{"operata", "category:audio", "softphone:media_error", "priority:P1"}},
+	}
+
+	for _, test := range tests {
+		body, err := test.template.Render(ticket, test.vars)
+		if err != nil {
+			t.Errorf("%s: render failed: %v", test.name, err)
+			continue
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			t.Errorf("%s: invalid JSON: %v", test.name, err)
+			continue
+		}
+		for _, key := range test.path {
+			value = value.(map[string]interface{})[key]
+		}
+		if !reflect.DeepEqual(value, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, value)
+		}
+	}
+
+	if !reflect.DeepEqual(ticket.Labels, []string{"operata", "category:audio", "softphone:media_error"}) {
+		t.Errorf("Expected rendering not to modify labels, got %v", ticket.Labels)
+	}
+}
+
+func TestCustomTemplate(t *testing.T) {
+	if _, err := ParseTemplate("broken", `{{json .Summary`); err == nil {
+		t.Error("Expected parse error for broken template")
+	}
+
+	invalid := MustParseTemplate("invalid", `{"title": {{.Summary}}}`)
+	if _, err := invalid.Render(&Ticket{Summary: "not quoted"}, nil); err == nil || !strings.Contains(err.Error(), "valid JSON") {
+		t.Errorf("Expected invalid JSON error, got %v", err)
+	}
+
+	custom := MustParseTemplate("custom", `{"p": {{json (pick .Priority "urgent" "normal")}}}`)
+	body, err := custom.Render(&Ticket{Priority: P3}, nil)
+	if err != nil || string(body) != `{"p":"normal"}` {
+		t.Errorf("Expected P3 to pick the last value, got %s, %v", body, err)
+	}
+}
@@ -0,0 +1,38 @@
+// Package webhook receives Operata events POSTed over HTTPS, such as those
+// sent by an EventBridge API destination.
+//
+// A Handler is an http.Handler. It reads a single event or a JSON array of
+// events, checks the request with a Verifier, parses every event with
+// events.ParseEventBridgeEvent and passes them to an events.Handler,
+// usually a Router that dispatches on detail-type.
+//
+// Status codes tell the sender whether to retry:
+//
+//   - 200: every event was handled
+//   - 400: the body is not valid events; retrying will not help
+//   - 401: verification failed
+//   - 405: the method is not POST
+//   - 413: the body is larger than MaxBodyBytes
+//   - 500: a handler failed, or the Verifier is missing its secret or
+//     header; the whole request should be retried
+//
+// Because a failed batch is retried in full, events that succeeded are
+// delivered again, so handlers must be idempotent or wrapped with
+// dedup.Middleware.
+//
+// EventBridge connections authenticate with a static header, which
+// SharedSecret checks; HMACSignature checks an HMAC-SHA256 of the body for
+// senders that sign their requests.
+//
+// Example usage:
+//
+//	router := webhook.NewRouter()
+//	router.Handle(events.EventTypeCallSummary, handleCall)
+//	router.Handle(events.EventTypeAgentReportedIssue, handleIssue)
+//
+//	handler := webhook.New(router.Process, webhook.SharedSecret{
+//		Header: "X-Api-Key",
+//		Secret: os.Getenv("WEBHOOK_SECRET"),
+//	})
+//	http.Handle("/operata/events", handler)
+package webhook
@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// Router dispatches events to handlers by detail-type. Register handlers
+// before serving; a Router is not safe for concurrent registration.
+type Router struct {
+	routes map[string]events.Handler
+	// Fallback handles events with no registered detail-type; they are ignored when nil
+	Fallback events.Handler
+}
+
+// NewRouter returns an empty router
+func NewRouter() *Router {
+	return &Router{routes: make(map[string]events.Handler)}
+}
+
+// Handle registers the handler for a detail-type, replacing any existing one
+func (r *Router) Handle(detailType string, handler events.Handler) {
+	r.routes[detailType] = handler
+}
+
+// Process passes the event to the handler for its detail-type. It has the
+// events.Handler signature.
+func (r *Router) Process(ctx context.Context, event interface{}) error {
+	var detailType string
+	if header, ok := events.GetEventHeader(event); ok {
+		detailType = header.DetailType
+	}
+
+	if handler, ok := r.routes[detailType]; ok {
+		return handler(ctx, event)
+	}
+	if r.Fallback != nil {
+		return r.Fallback(ctx, event)
+	}
+	return nil
+}
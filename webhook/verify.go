@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultSignatureHeader is the header HMACSignature reads when none is set
+const DefaultSignatureHeader = "X-Operata-Signature"
+
+// ErrUnauthorized is returned by verifiers when a request fails verification
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrNotConfigured is returned by verifiers missing the settings they need
+// to check a request, such as an empty secret
+var ErrNotConfigured = errors.New("verifier is not configured")
+
+// Verifier checks that a request came from a trusted sender
+type Verifier interface {
+	Verify(r *http.Request, body []byte) error
+}
+
+// SharedSecret requires a header to equal a secret, as sent by an
+// EventBridge connection using API key authorization. Both fields are required.
+type SharedSecret struct {
+	Header string
+	Secret string
+}
+
+// Verify compares the header with the secret in constant time
+func (v SharedSecret) Verify(r *http.Request, _ []byte) error {
+	if v.Secret == "" {
+		return fmt.Errorf("%w: shared secret is empty", ErrNotConfigured)
+	}
+	if v.Header == "" {
+		return fmt.Errorf("%w: shared secret header is empty", ErrNotConfigured)
+	}
+	value := r.Header.Get(v.Header)
+	if subtle.ConstantTimeCompare([]byte(value), []byte(v.Secret)) != 1 {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// HMACSignature requires a header holding the hex HMAC-SHA256 of the body,
+// optionally prefixed with "sha256="
+type HMACSignature struct {
+	// Header defaults to DefaultSignatureHeader
+	Header string
+	Secret []byte
+}
+
+// Verify recomputes the signature and compares it in constant time
+func (v HMACSignature) Verify(r *http.Request, body []byte) error {
+	if len(v.Secret) == 0 {
+		return fmt.Errorf("%w: signing secret is empty", ErrNotConfigured)
+	}
+	header := v.Header
+	if header == "" {
+		header = DefaultSignatureHeader
+	}
+
+	signature, err := hex.DecodeString(strings.TrimPrefix(r.Header.Get(header), "sha256="))
+	if err != nil || len(signature) == 0 {
+		return ErrUnauthorized
+	}
+	if !hmac.Equal(signature, mac(v.Secret, body)) {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// Sign returns the signature header value for a body, for senders and tests
+func Sign(secret, body []byte) string {
+	return "sha256=" + hex.EncodeToString(mac(secret, body))
+}
+
+func mac(secret, body []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(body)
+	return h.Sum(nil)
+}
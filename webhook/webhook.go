@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+// DefaultMaxBodyBytes is the request size limit when MaxBodyBytes is zero
+const DefaultMaxBodyBytes = 1 << 20
+
+// Handler receives events over HTTP
+type Handler struct {
+	// Handle is called with each parsed event, in request order. It must be
+	// idempotent, or wrapped with dedup.Middleware, since a batch with any
+	// failed event is redelivered in full.
+	Handle events.Handler
+	// Verifier checks each request; nil accepts every request
+	Verifier Verifier
+	// MaxBodyBytes limits the request size; DefaultMaxBodyBytes when zero
+	MaxBodyBytes int64
+	// Logger receives errors that are not returned to the sender; log.Default when nil
+	Logger *log.Logger
+}
+
+// New returns a handler that verifies requests and passes their events to handle
+func New(handle events.Handler, verifier Verifier) *Handler {
+	return &Handler{Handle: handle, Verifier: verifier}
+}
+
+// response is the JSON body of a successful request
+type response struct {
+	Received int `json:"received"`
+}
+
+// ServeHTTP handles a POST of one event or a JSON array of events. Every
+// event is passed to Handle; if any of them fails, the response is 500 and
+// the sender redelivers the whole batch, including the events that succeeded.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := h.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	if h.Verifier != nil {
+		if err := h.Verifier.Verify(r, body); err != nil {
+			if errors.Is(err, ErrNotConfigured) {
+				h.logf("webhook: %v", err)
+				http.Error(w, "webhook is not configured", http.StatusInternalServerError)
+				return
+			}
+			if !errors.Is(err, ErrUnauthorized) {
+				h.logf("webhook: verification failed: %v", err)
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	parsed, err := Parse(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var errs []error
+	for _, event := range parsed {
+		if err := h.Handle(r.Context(), event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		h.logf("webhook: %d of %d events failed: %v", len(errs), len(parsed), errors.Join(errs...))
+		http.Error(w, "failed to process events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response{Received: len(parsed)})
+}
+
+func (h *Handler) logf(format string, args ...interface{}) {
+	logger := h.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Printf(format, args...)
+}
+
+// Parse parses a request body holding one event or a JSON array of events
+func Parse(body []byte) ([]interface{}, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, fmt.Errorf("empty request body")
+	}
+
+	var raw []json.RawMessage
+	if body[0] == '[' {
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse event batch: %w", err)
+		}
+	} else {
+		raw = []json.RawMessage{body}
+	}
+
+	parsed := make([]interface{}, 0, len(raw))
+	for i, data := range raw {
+		event, err := events.ParseEventBridgeEvent(data)
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		parsed = append(parsed, event)
+	}
+	return parsed, nil
+}
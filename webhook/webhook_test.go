@@ -0,0 +1,215 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tommyorndorff/operata-events/events"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "events", "testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	return data
+}
+
+func TestRouter(t *testing.T) {
+	var routed []string
+	router := NewRouter()
+	router.Handle(events.EventTypeCallSummary, func(_ context.Context, event interface{}) error {
+		routed = append(routed, "call:"+event.(*events.CallSummaryEvent).Detail.ServiceAgent.Username)
+		return nil
+	})
+	router.Handle(events.EventTypeAgentReportedIssue, func(_ context.Context, event interface{}) error {
+		routed = append(routed, "issue:"+event.(*events.AgentReportedIssueEvent).Detail.Context.Category)
+		return nil
+	})
+
+	for _, name := range []string{"call_summary.json", "agent_reported_issue.json", "headset_summary.json"} {
+		event, err := events.ParseEventBridgeEvent(readFixture(t, name))
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", name, err)
+		}
+		if err := router.Process(context.Background(), event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// HeadsetSummary has no route and no fallback, so it is ignored
+	expected := []string{"call:andy", "issue:Audio"}
+	if strings.Join(routed, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, routed)
+	}
+
+	router.Fallback = func(_ context.Context, event interface{}) error {
+		return errors.New("unrouted")
+	}
+	if err := router.Process(context.Background(), &events.EventBridgeEvent{DetailType: "Other"}); err == nil {
+		t.Error("Expected fallback error")
+	}
+}
+
+func TestVerifiers(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	secret := []byte("s3cret")
+
+	tests := []struct {
+		name     string
+		verifier Verifier
+		header   string
+		value    string
+		valid    bool
+	}{
+		{"hmac", HMACSignature{Secret: secret}, DefaultSignatureHeader, Sign(secret, body), true},
+		{"hmac without prefix", HMACSignature{Secret: secret}, DefaultSignatureHeader, strings.TrimPrefix(Sign(secret, body), "sha256="), true},
+		{"hmac custom header", HMACSignature{Header: "X-Sig", Secret: secret}, "X-Sig", Sign(secret, body), true},
+		{"hmac wrong secret", HMACSignature{Secret: secret}, DefaultSignatureHeader, Sign([]byte("other"), body), false},
+		{"hmac not hex", HMACSignature{Secret: secret}, DefaultSignatureHeader, "sha256=zz", false},
+		{"hmac missing", HMACSignature{Secret: secret}, "", "", false},
+		{"hmac unconfigured", HMACSignature{}, DefaultSignatureHeader, Sign(nil, body), false},
+		{"shared secret", SharedSecret{Header: "X-Api-Key", Secret: "key"}, "X-Api-Key", "key", true},
+		{"shared secret wrong", SharedSecret{Header: "X-Api-Key", Secret: "key"}, "X-Api-Key", "nope", false},
+		{"shared secret unconfigured", SharedSecret{Header: "X-Api-Key"}, "X-Api-Key", "", false},
+		{"shared secret without header", SharedSecret{Secret: "key"}, "X-Api-Key", "key", false},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		if test.header != "" {
+			req.Header.Set(test.header, test.value)
+		}
+		err := test.verifier.Verify(req, body)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: expected valid=%v, got error %v", test.name, test.valid, err)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	call := readFixture(t, "call_summary.json")
+	issue := readFixture(t, "agent_reported_issue.json")
+	batch := []byte("[" + string(call) + "," + string(issue) + "]")
+	secret := []byte("s3cret")
+
+	var received []string
+	failing := false
+	handle := func(_ context.Context, event interface{}) error {
+		header, _ := events.GetEventHeader(event)
+		received = append(received, header.DetailType)
+		if failing {
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	}
+	handler := New(handle, HMACSignature{Secret: secret})
+	handler.MaxBodyBytes = 64 << 10
+	handler.Logger = log.New(io.Discard, "", 0)
+
+	tests := []struct {
+		name     string
+		method   string
+		body     []byte
+		sign     bool
+		fail     bool
+		status   int
+		received int
+	}{
+		{"single", http.MethodPost, call, true, false, http.StatusOK, 1},
+		{"batch", http.MethodPost, batch, true, false, http.StatusOK, 2},
+		{"wrong method", http.MethodGet, nil, true, false, http.StatusMethodNotAllowed, 0},
+		{"unsigned", http.MethodPost, call, false, false, http.StatusUnauthorized, 0},
+		{"malformed", http.MethodPost, []byte(`{"detail-type": "CallSummary", `), true, false, http.StatusBadRequest, 0},
+		{"malformed batch member", http.MethodPost, []byte(`[` + string(call) + `, 42]`), true, false, http.StatusBadRequest, 0},
+		{"empty", http.MethodPost, []byte(" "), true, false, http.StatusBadRequest, 0},
+		{"too large", http.MethodPost, bytes.Repeat([]byte(" "), 65<<10), true, false, http.StatusRequestEntityTooLarge, 0},
+		{"handler failure", http.MethodPost, batch, true, true, http.StatusInternalServerError, 2},
+	}
+
+	for _, test := range tests {
+		received = nil
+		failing = test.fail
+
+		req := httptest.NewRequest(test.method, "/events", bytes.NewReader(test.body))
+		if test.sign {
+			req.Header.Set(DefaultSignatureHeader, Sign(secret, test.body))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d (%s)", test.name, test.status, rec.Code, rec.Body.String())
+		}
+		if len(received) != test.received {
+			t.Errorf("%s: expected %d events, got %d", test.name, test.received, len(received))
+		}
+	}
+
+	received = nil
+	failing = false
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(batch))
+	req.Header.Set(DefaultSignatureHeader, Sign(secret, batch))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var resp map[string]int
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp["received"] != 2 {
+		t.Errorf("Expected received 2, got %d", resp["received"])
+	}
+	if strings.Join(received, ",") != "CallSummary,AgentReportedIssue" {
+		t.Errorf("Expected events in request order, got %v", received)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/events", nil))
+	if allow := rec.Header().Get("Allow"); allow != http.MethodPost {
+		t.Errorf("Expected Allow POST, got %q", allow)
+	}
+}
+
+func TestUnconfiguredVerifier(t *testing.T) {
+	call := readFixture(t, "call_summary.json")
+	handle := func(context.Context, interface{}) error { return nil }
+
+	tests := []struct {
+		name     string
+		verifier Verifier
+	}{
+		{"hmac", HMACSignature{}},
+		{"shared secret", SharedSecret{Header: "X-Api-Key"}},
+		{"shared secret without header", SharedSecret{Secret: "key"}},
+	}
+
+	for _, test := range tests {
+		handler := New(handle, test.verifier)
+		handler.Logger = log.New(io.Discard, "", 0)
+
+		req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(call))
+		req.Header.Set("X-Api-Key", "key")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		// A misconfigured server is not the sender's fault
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("%s: expected status %d, got %d", test.name, http.StatusInternalServerError, rec.Code)
+		}
+		if err := test.verifier.Verify(req, call); !errors.Is(err, ErrNotConfigured) {
+			t.Errorf("%s: expected ErrNotConfigured, got %v", test.name, err)
+		}
+	}
+}